@@ -16,6 +16,14 @@
 package backend
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/logger"
 	"github.com/rs/zerolog"
@@ -57,15 +65,115 @@ type ProverConfig struct {
 	Force         bool                      // defaults to false
 	HintFunctions map[hint.ID]hint.Function // defaults to all built-in hint functions
 	CircuitLogger zerolog.Logger            // defaults to gnark.Logger
+
+	RichHintFunctions map[hint.ID]hint.RichFunction // defaults to all registered rich hint functions
+	HintContext       interface{}                   // given to rich hint functions in hint.Context.Value
+
+	SolutionDump       io.Writer  // if set, the solver writes the solution to it, see WithSolutionDump
+	SolutionDumpFormat DumpFormat // defaults to DumpJSON
+
+	Context context.Context // defaults to context.Background(), see WithContext
+
+	Progress func(ev ProverEvent) // if set, receives the progress of the prover, see WithProgress
+
+	NbTasks int // defaults to runtime.NumCPU(), see WithNbTasks
 }
 
+// ProverPhase is a phase of the prover, see WithProgress.
+type ProverPhase uint8
+
+const (
+	PhaseProve    ProverPhase = iota + 1 // the whole proof
+	PhaseSolve                           // solving of the constraint system
+	PhaseFFT                             // FFTs (groth16: H; plonk: l, r, o)
+	PhaseMSM                             // multi-exponentiation of a part of a groth16 proof
+	PhaseCommit                          // KZG commitments of a plonk proof
+	PhaseQuotient                        // computation of the quotient polynomial of a plonk proof
+	PhaseOpen                            // KZG opening proofs of a plonk proof
+)
+
+// String returns the string representation of a ProverPhase
+func (p ProverPhase) String() string {
+	switch p {
+	case PhaseProve:
+		return "prove"
+	case PhaseSolve:
+		return "solve"
+	case PhaseFFT:
+		return "fft"
+	case PhaseMSM:
+		return "msm"
+	case PhaseCommit:
+		return "commit"
+	case PhaseQuotient:
+		return "quotient"
+	case PhaseOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// ProverEvent is emitted when a phase of the prover starts and when it ends,
+// see WithProgress. A step which starts always ends, even if it fails or the
+// proof is cancelled (see WithContext): its end event then holds the error.
+type ProverEvent struct {
+	Phase   ProverPhase
+	Name    string        // step of the phase, such as "ar" for the multi-exponentiation of Ar
+	End     bool          // false when the step starts, true when it ends
+	Elapsed time.Duration // duration of the step, set when it ends
+	NbItems int           // size of the step: constraints, FFT domain or points
+	Err     error         // error which stopped the step, set when it ends; nil if it succeeded
+}
+
+// StartPhase is used by the provers to report their progress: it emits the
+// start of a step to the Progress function and returns a function emitting its
+// end, with the error of the step if it failed. Both are no-ops if Progress is
+// nil.
+func (cfg *ProverConfig) StartPhase(phase ProverPhase, name string, nbItems int) (end func(err error)) {
+	if cfg.Progress == nil {
+		return func(error) {}
+	}
+	progress := cfg.Progress
+	progress(ProverEvent{Phase: phase, Name: name, NbItems: nbItems})
+	start := time.Now()
+	return func(err error) {
+		progress(ProverEvent{Phase: phase, Name: name, End: true, Elapsed: time.Since(start), NbItems: nbItems, Err: err})
+	}
+}
+
+// DumpFormat is the format of the solution written by the solver, see
+// WithSolutionDump.
+type DumpFormat uint8
+
+const (
+	DumpJSON DumpFormat = iota
+	DumpCSV
+)
+
 // NewProverConfig returns a default ProverConfig with given prover options opts
 // applied.
 func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
 	log := logger.Logger()
-	opt := ProverConfig{CircuitLogger: log, HintFunctions: make(map[hint.ID]hint.Function)}
+	opt := ProverConfig{
+		CircuitLogger:     log,
+		Context:           context.Background(),
+		NbTasks:           runtime.NumCPU(),
+		HintFunctions:     make(map[hint.ID]hint.Function),
+		RichHintFunctions: make(map[hint.ID]hint.RichFunction),
+	}
 	for _, v := range hint.GetRegistered() {
 		opt.HintFunctions[hint.UUID(v)] = v
+		// constraint systems compiled before v was named refer to it by a legacy ID
+		for _, id := range hint.Aliases(v) {
+			opt.HintFunctions[id] = v
+		}
+	}
+	for _, v := range hint.GetRegisteredRich() {
+		opt.RichHintFunctions[hint.RichUUID(v)] = v
+		for _, id := range hint.RichAliases(v) {
+			opt.RichHintFunctions[id] = v
+		}
 	}
 	for _, option := range opts {
 		if err := option(&opt); err != nil {
@@ -86,6 +194,60 @@ func IgnoreSolverError() ProverOption {
 	}
 }
 
+// WithContext is a prover option that makes the proof cancellable: the solver
+// checks ctx between its levels and the provers between their FFTs and
+// multi-exponentiations, and return ctx.Err() once ctx is done. The steps in
+// progress when ctx is done run to completion, the following ones don't; the
+// provers return once the steps in progress are done, so that no work on the
+// proof continues after they return.
+func WithContext(ctx context.Context) ProverOption {
+	return func(opt *ProverConfig) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		opt.Context = ctx
+		return nil
+	}
+}
+
+// WithProgress is a prover option that sets a function receiving the progress
+// of the prover: an event when each of its phases starts and ends, with the
+// error of the phase if it fails (see ProverEvent). Some phases run
+// concurrently, but fn is not called concurrently; as it blocks the
+// prover, it must return quickly.
+func WithProgress(fn func(ev ProverEvent)) ProverOption {
+	return func(opt *ProverConfig) error {
+		if fn == nil {
+			opt.Progress = nil
+			return nil
+		}
+		var lock sync.Mutex
+		opt.Progress = func(ev ProverEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			fn(ev)
+		}
+		return nil
+	}
+}
+
+// WithNbTasks is a prover option that sets the number of tasks the solver and
+// the provers split their parallel steps in: the levels of the solver, the
+// conversions of the witness and the multi-exponentiations. It defaults to
+// runtime.NumCPU().
+//
+// The FFTs and the KZG openings of gnark-crypto always use runtime.NumCPU()
+// goroutines; to bound them too, set GOMAXPROCS.
+func WithNbTasks(nbTasks int) ProverOption {
+	return func(opt *ProverConfig) error {
+		if nbTasks < 1 {
+			return fmt.Errorf("invalid number of tasks %d", nbTasks)
+		}
+		opt.NbTasks = nbTasks
+		return nil
+	}
+}
+
 // WithHints is a prover option that specifies additional hint functions to be used
 // by the constraint solver.
 func WithHints(hintFunctions ...hint.Function) ProverOption {
@@ -100,11 +262,63 @@ func WithHints(hintFunctions ...hint.Function) ProverOption {
 			} else {
 				opt.HintFunctions[uuid] = h
 			}
+			for _, id := range hint.Aliases(h) {
+				opt.HintFunctions[id] = h
+			}
 		}
 		return nil
 	}
 }
 
+// WithRichHints is a prover option that specifies additional rich hint functions
+// to be used by the constraint solver.
+func WithRichHints(hintFunctions ...hint.RichFunction) ProverOption {
+	log := logger.Logger()
+	return func(opt *ProverConfig) error {
+		for _, h := range hintFunctions {
+			uuid := hint.RichUUID(h)
+			if _, ok := opt.RichHintFunctions[uuid]; ok {
+				log.Warn().Int("hintID", int(uuid)).Str("name", hint.RichName(h)).Msg("duplicate hint function")
+			} else {
+				opt.RichHintFunctions[uuid] = h
+			}
+			for _, id := range hint.RichAliases(h) {
+				opt.RichHintFunctions[id] = h
+			}
+		}
+		return nil
+	}
+}
+
+// WithHintContext is a prover option that specifies the per-proof value given
+// to the rich hint functions in hint.Context.Value.
+func WithHintContext(v interface{}) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.HintContext = v
+		return nil
+	}
+}
+
+// WithSolutionDump is a prover option that makes the constraint solver write
+// the solution to w, whether solving succeeded or not, in the given format.
+// For each wire, the dump holds its ID, its name (the name of the input or the
+// label set with frontend.Compiler.Label), its visibility, its value and the
+// constraint which solved it; the value and the constraint are empty if the
+// solver didn't reach the wire.
+//
+// Diffing the dump of a failing run against the dump of a working one shows
+// where their solutions diverge.
+func WithSolutionDump(w io.Writer, format DumpFormat) ProverOption {
+	return func(opt *ProverConfig) error {
+		if format != DumpJSON && format != DumpCSV {
+			return errors.New("unknown solution dump format")
+		}
+		opt.SolutionDump = w
+		opt.SolutionDumpFormat = format
+		return nil
+	}
+}
+
 // WithCircuitLogger is a prover option that specifies zerolog.Logger as a destination for the
 // logs printed by api.Println(). By default, uses gnark/logger.
 // zerolog.Nop() will disable logging
@@ -114,3 +328,41 @@ func WithCircuitLogger(l zerolog.Logger) ProverOption {
 		return nil
 	}
 }
+
+// SetupOption defines option for altering the behaviour of the Setup methods.
+// See the descriptions of functions returning instances of this type for
+// implemented options.
+type SetupOption func(*SetupConfig) error
+
+// SetupConfig is the configuration for the setup with the options applied.
+type SetupConfig struct {
+	NbTasks int // defaults to runtime.NumCPU(), see WithSetupNbTasks
+}
+
+// NewSetupConfig returns a default SetupConfig with given setup options opts
+// applied.
+func NewSetupConfig(opts ...SetupOption) (SetupConfig, error) {
+	opt := SetupConfig{
+		NbTasks: runtime.NumCPU(),
+	}
+	for _, option := range opts {
+		if err := option(&opt); err != nil {
+			return SetupConfig{}, err
+		}
+	}
+	return opt, nil
+}
+
+// WithSetupNbTasks is a setup option that sets the number of tasks the setup
+// splits its parallel steps in: the scalars of the groth16 keys and the KZG
+// commitments of the plonk verifying key. It defaults to runtime.NumCPU(), as
+// the batch scalar multiplications and FFTs of gnark-crypto always do.
+func WithSetupNbTasks(nbTasks int) SetupOption {
+	return func(opt *SetupConfig) error {
+		if nbTasks < 1 {
+			return fmt.Errorf("invalid number of tasks %d", nbTasks)
+		}
+		opt.NbTasks = nbTasks
+		return nil
+	}
+}
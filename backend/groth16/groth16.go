@@ -40,6 +40,7 @@ import (
 	witness_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/witness"
 	witness_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/witness"
 
+	"github.com/consensys/gnark/internal/utils"
 	gnarkio "github.com/consensys/gnark/io"
 
 	groth16_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/groth16"
@@ -70,6 +71,8 @@ type Proof interface {
 type ProvingKey interface {
 	groth16Object
 	gnarkio.UnsafeReaderFrom
+	gnarkio.WriterMappedTo
+	gnarkio.ReaderMappedFrom
 
 	// NbG1 returns the number of G1 elements in the ProvingKey
 	NbG1() int
@@ -156,6 +159,8 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness *witness.Witness) error
 // 	will executes all the prover computations, even if the witness is invalid
 //  will produce an invalid proof
 //	internally, the solution vector to the R1CS will be filled with random values which may impact benchmarking
+//
+// the outputs of the circuit (see schema.Output) are computed by the solver and set in fullWitness
 func Prove(r1cs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, error) {
 
 	// apply options
@@ -206,6 +211,21 @@ func Prove(r1cs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *w
 	}
 }
 
+// ProveWithOutputs runs groth16.Prove and returns the proof together with the public witness
+// to verify it, whose outputs (see schema.Output) are the values computed by the solver.
+// fullWitness must have a Schema, as returned by frontend.NewWitness.
+func ProveWithOutputs(r1cs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, *witness.Witness, error) {
+	proof, err := Prove(r1cs, pk, fullWitness, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, publicWitness, nil
+}
+
 // Setup runs groth16.Setup with provided R1CS and outputs a key pair associated with the circuit.
 //
 // Note that careful consideration must be given to this step in production environment.
@@ -214,48 +234,50 @@ func Prove(r1cs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *w
 //
 // Two main solutions to this deployment issues are: running the Setup through a MPC (multi party computation)
 // or using a ZKP backend like PLONK where the per-circuit Setup is deterministic.
-func Setup(r1cs frontend.CompiledConstraintSystem) (ProvingKey, VerifyingKey, error) {
+//
+// See backend.SetupOption for the options.
+func Setup(r1cs frontend.CompiledConstraintSystem, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
 
 	switch _r1cs := r1cs.(type) {
 	case *backend_bls12377.R1CS:
 		var pk groth16_bls12377.ProvingKey
 		var vk groth16_bls12377.VerifyingKey
-		if err := groth16_bls12377.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls12377.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bls12381.R1CS:
 		var pk groth16_bls12381.ProvingKey
 		var vk groth16_bls12381.VerifyingKey
-		if err := groth16_bls12381.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls12381.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bn254.R1CS:
 		var pk groth16_bn254.ProvingKey
 		var vk groth16_bn254.VerifyingKey
-		if err := groth16_bn254.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bn254.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bw6761.R1CS:
 		var pk groth16_bw6761.ProvingKey
 		var vk groth16_bw6761.VerifyingKey
-		if err := groth16_bw6761.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bw6761.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bls24315.R1CS:
 		var pk groth16_bls24315.ProvingKey
 		var vk groth16_bls24315.VerifyingKey
-		if err := groth16_bls24315.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls24315.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bw6633.R1CS:
 		var pk groth16_bw6633.ProvingKey
 		var vk groth16_bw6633.VerifyingKey
-		if err := groth16_bw6633.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bw6633.Setup(_r1cs, &pk, &vk, opts...); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
@@ -332,6 +354,24 @@ func NewProvingKey(curveID ecc.ID) ProvingKey {
 	return pk
 }
 
+// ReadMappedProvingKey memory-maps the file at path, written by
+// ProvingKey.WriteMappedTo, and returns the ProvingKey of curveID which uses it
+// in place: its pages are loaded when the prover reads them instead of being
+// decoded to the heap. unmap releases the file; the ProvingKey must not be used
+// after.
+func ReadMappedProvingKey(curveID ecc.ID, path string) (pk ProvingKey, unmap func() error, err error) {
+	data, unmap, err := utils.MapFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk = NewProvingKey(curveID)
+	if _, err := pk.ReadMappedFrom(data); err != nil {
+		_ = unmap()
+		return nil, nil, err
+	}
+	return pk, unmap, nil
+}
+
 // NewVerifyingKey instantiates a curve-typed VerifyingKey and returns an interface
 // This function exists for serialization purposes
 func NewVerifyingKey(curveID ecc.ID) VerifyingKey {
@@ -0,0 +1,468 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groth16
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/witness"
+	groth16_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/groth16"
+	witness_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// snarkjs JSON layout (proof.json, verification_key.json and public.json)
+//
+// The points are lists of decimal projective coordinates: [x, y, "1"] in G1
+// and [[x.A0, x.A1], [y.A0, y.A1], ["1", "0"]] in G2, the point at infinity
+// having a zero z coordinate. The curves are named as in snarkjs.
+const (
+	snarkjsProtocol = "groth16"
+
+	snarkjsBN254     = "bn128"
+	snarkjsBLS12_381 = "bls12381"
+)
+
+var errSnarkJSCurve = errors.New("snarkjs only supports BN254 and BLS12-381")
+
+type snarkjsProof struct {
+	A        []string   `json:"pi_a"`
+	B        [][]string `json:"pi_b"`
+	C        []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+}
+
+// snarkjsVerifyingKey omits vk_alphabeta_12, the snarkjs verifier doesn't use
+// it and its representation in Fp12 isn't the one of gnark-crypto
+type snarkjsVerifyingKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha    []string   `json:"vk_alpha_1"`
+	Beta     [][]string `json:"vk_beta_2"`
+	Gamma    [][]string `json:"vk_gamma_2"`
+	Delta    [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+}
+
+// WriteSnarkJSProof writes a BN254 or BLS12-381 proof in the layout of the
+// proof.json file of snarkjs
+func WriteSnarkJSProof(w io.Writer, proof Proof) error {
+	var p snarkjsProof
+	switch _proof := proof.(type) {
+	case *groth16_bn254.Proof:
+		p.Curve = snarkjsBN254
+		p.A = bn254G1ToSnarkJS(&_proof.Ar)
+		p.B = bn254G2ToSnarkJS(&_proof.Bs)
+		p.C = bn254G1ToSnarkJS(&_proof.Krs)
+	case *groth16_bls12381.Proof:
+		p.Curve = snarkjsBLS12_381
+		p.A = bls12381G1ToSnarkJS(&_proof.Ar)
+		p.B = bls12381G2ToSnarkJS(&_proof.Bs)
+		p.C = bls12381G1ToSnarkJS(&_proof.Krs)
+	default:
+		return errSnarkJSCurve
+	}
+	p.Protocol = snarkjsProtocol
+	return json.NewEncoder(w).Encode(&p)
+}
+
+// ReadSnarkJSProof reads a proof from a snarkjs proof.json file. The points
+// are checked to be in the correct subgroups.
+func ReadSnarkJSProof(r io.Reader) (Proof, error) {
+	var p snarkjsProof
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Protocol != snarkjsProtocol {
+		return nil, fmt.Errorf("unsupported snarkjs protocol %q", p.Protocol)
+	}
+	curveID, err := snarkjsCurveID(p.Curve)
+	if err != nil {
+		return nil, err
+	}
+	q := curveID.Info().Fp.Modulus()
+
+	switch curveID {
+	case ecc.BN254:
+		var proof groth16_bn254.Proof
+		if err := bn254G1FromSnarkJS(&proof.Ar, p.A, q); err != nil {
+			return nil, fmt.Errorf("pi_a: %w", err)
+		}
+		if err := bn254G2FromSnarkJS(&proof.Bs, p.B, q); err != nil {
+			return nil, fmt.Errorf("pi_b: %w", err)
+		}
+		if err := bn254G1FromSnarkJS(&proof.Krs, p.C, q); err != nil {
+			return nil, fmt.Errorf("pi_c: %w", err)
+		}
+		return &proof, nil
+	default:
+		var proof groth16_bls12381.Proof
+		if err := bls12381G1FromSnarkJS(&proof.Ar, p.A, q); err != nil {
+			return nil, fmt.Errorf("pi_a: %w", err)
+		}
+		if err := bls12381G2FromSnarkJS(&proof.Bs, p.B, q); err != nil {
+			return nil, fmt.Errorf("pi_b: %w", err)
+		}
+		if err := bls12381G1FromSnarkJS(&proof.Krs, p.C, q); err != nil {
+			return nil, fmt.Errorf("pi_c: %w", err)
+		}
+		return &proof, nil
+	}
+}
+
+// WriteSnarkJSVerifyingKey writes a BN254 or BLS12-381 VerifyingKey in the
+// layout of the verification_key.json file of snarkjs. vk_alphabeta_12 is not
+// written.
+func WriteSnarkJSVerifyingKey(w io.Writer, vk VerifyingKey) error {
+	var v snarkjsVerifyingKey
+	switch _vk := vk.(type) {
+	case *groth16_bn254.VerifyingKey:
+		v.Curve = snarkjsBN254
+		v.Alpha = bn254G1ToSnarkJS(&_vk.G1.Alpha)
+		v.Beta = bn254G2ToSnarkJS(&_vk.G2.Beta)
+		v.Gamma = bn254G2ToSnarkJS(&_vk.G2.Gamma)
+		v.Delta = bn254G2ToSnarkJS(&_vk.G2.Delta)
+		for i := range _vk.G1.K {
+			v.IC = append(v.IC, bn254G1ToSnarkJS(&_vk.G1.K[i]))
+		}
+	case *groth16_bls12381.VerifyingKey:
+		v.Curve = snarkjsBLS12_381
+		v.Alpha = bls12381G1ToSnarkJS(&_vk.G1.Alpha)
+		v.Beta = bls12381G2ToSnarkJS(&_vk.G2.Beta)
+		v.Gamma = bls12381G2ToSnarkJS(&_vk.G2.Gamma)
+		v.Delta = bls12381G2ToSnarkJS(&_vk.G2.Delta)
+		for i := range _vk.G1.K {
+			v.IC = append(v.IC, bls12381G1ToSnarkJS(&_vk.G1.K[i]))
+		}
+	default:
+		return errSnarkJSCurve
+	}
+	v.Protocol = snarkjsProtocol
+	v.NPublic = vk.NbPublicWitness()
+	return json.NewEncoder(w).Encode(&v)
+}
+
+// ReadSnarkJSVerifyingKey reads a VerifyingKey from a snarkjs
+// verification_key.json file. The points are checked to be in the correct
+// subgroups; vk_alphabeta_12 is ignored and recomputed.
+func ReadSnarkJSVerifyingKey(r io.Reader) (VerifyingKey, error) {
+	var v snarkjsVerifyingKey
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	if v.Protocol != snarkjsProtocol {
+		return nil, fmt.Errorf("unsupported snarkjs protocol %q", v.Protocol)
+	}
+	if len(v.IC) != v.NPublic+1 {
+		return nil, fmt.Errorf("invalid verifying key: %d IC points for %d public inputs", len(v.IC), v.NPublic)
+	}
+	curveID, err := snarkjsCurveID(v.Curve)
+	if err != nil {
+		return nil, err
+	}
+	q := curveID.Info().Fp.Modulus()
+
+	switch curveID {
+	case ecc.BN254:
+		var vk groth16_bn254.VerifyingKey
+		if err := bn254G1FromSnarkJS(&vk.G1.Alpha, v.Alpha, q); err != nil {
+			return nil, fmt.Errorf("vk_alpha_1: %w", err)
+		}
+		if err := bn254G2FromSnarkJS(&vk.G2.Beta, v.Beta, q); err != nil {
+			return nil, fmt.Errorf("vk_beta_2: %w", err)
+		}
+		if err := bn254G2FromSnarkJS(&vk.G2.Gamma, v.Gamma, q); err != nil {
+			return nil, fmt.Errorf("vk_gamma_2: %w", err)
+		}
+		if err := bn254G2FromSnarkJS(&vk.G2.Delta, v.Delta, q); err != nil {
+			return nil, fmt.Errorf("vk_delta_2: %w", err)
+		}
+		vk.G1.K = make([]bn254.G1Affine, len(v.IC))
+		for i := range v.IC {
+			if err := bn254G1FromSnarkJS(&vk.G1.K[i], v.IC[i], q); err != nil {
+				return nil, fmt.Errorf("IC[%d]: %w", i, err)
+			}
+		}
+		if err := vk.Precompute(); err != nil {
+			return nil, err
+		}
+		return &vk, nil
+	default:
+		var vk groth16_bls12381.VerifyingKey
+		if err := bls12381G1FromSnarkJS(&vk.G1.Alpha, v.Alpha, q); err != nil {
+			return nil, fmt.Errorf("vk_alpha_1: %w", err)
+		}
+		if err := bls12381G2FromSnarkJS(&vk.G2.Beta, v.Beta, q); err != nil {
+			return nil, fmt.Errorf("vk_beta_2: %w", err)
+		}
+		if err := bls12381G2FromSnarkJS(&vk.G2.Gamma, v.Gamma, q); err != nil {
+			return nil, fmt.Errorf("vk_gamma_2: %w", err)
+		}
+		if err := bls12381G2FromSnarkJS(&vk.G2.Delta, v.Delta, q); err != nil {
+			return nil, fmt.Errorf("vk_delta_2: %w", err)
+		}
+		vk.G1.K = make([]bls12381.G1Affine, len(v.IC))
+		for i := range v.IC {
+			if err := bls12381G1FromSnarkJS(&vk.G1.K[i], v.IC[i], q); err != nil {
+				return nil, fmt.Errorf("IC[%d]: %w", i, err)
+			}
+		}
+		if err := vk.Precompute(); err != nil {
+			return nil, err
+		}
+		return &vk, nil
+	}
+}
+
+// WriteSnarkJSPublicSignals writes a BN254 or BLS12-381 public witness as the
+// list of decimal values of the public.json file of snarkjs
+func WriteSnarkJSPublicSignals(w io.Writer, publicWitness *witness.Witness) error {
+	var signals []string
+	switch v := publicWitness.Vector.(type) {
+	case *witness_bn254.Witness:
+		signals = make([]string, len(*v))
+		for i := range *v {
+			signals[i] = (*v)[i].String()
+		}
+	case *witness_bls12381.Witness:
+		signals = make([]string, len(*v))
+		for i := range *v {
+			signals[i] = (*v)[i].String()
+		}
+	default:
+		return errSnarkJSCurve
+	}
+	return json.NewEncoder(w).Encode(signals)
+}
+
+// ReadSnarkJSPublicSignals reads a public witness on curveID from the list of
+// decimal values of a snarkjs public.json file. The returned witness has no
+// Schema.
+func ReadSnarkJSPublicSignals(r io.Reader, curveID ecc.ID) (*witness.Witness, error) {
+	var signals []string
+	if err := json.NewDecoder(r).Decode(&signals); err != nil {
+		return nil, err
+	}
+	values := make([]big.Int, len(signals))
+	q := curveID.Info().Fr.Modulus()
+	for i := range signals {
+		if err := setDecimal(&values[i], signals[i], q); err != nil {
+			return nil, fmt.Errorf("%w: public signal %d: %s", witness.ErrInvalidWitness, i, err)
+		}
+	}
+
+	switch curveID {
+	case ecc.BN254:
+		v := make(witness_bn254.Witness, len(values))
+		for i := range values {
+			v[i].SetBigInt(&values[i])
+		}
+		return &witness.Witness{CurveID: curveID, Vector: &v}, nil
+	case ecc.BLS12_381:
+		v := make(witness_bls12381.Witness, len(values))
+		for i := range values {
+			v[i].SetBigInt(&values[i])
+		}
+		return &witness.Witness{CurveID: curveID, Vector: &v}, nil
+	default:
+		return nil, errSnarkJSCurve
+	}
+}
+
+func snarkjsCurveID(curve string) (ecc.ID, error) {
+	switch curve {
+	case snarkjsBN254:
+		return ecc.BN254, nil
+	case snarkjsBLS12_381:
+		return ecc.BLS12_381, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("unsupported snarkjs curve %q", curve)
+	}
+}
+
+// g1ToSnarkJS returns the snarkjs coordinates of the affine point (x, y)
+func g1ToSnarkJS(x, y *big.Int, infinity bool) []string {
+	if infinity {
+		return []string{"0", "1", "0"}
+	}
+	return []string{x.String(), y.String(), "1"}
+}
+
+// g2ToSnarkJS returns the snarkjs coordinates of the affine point (x, y)
+func g2ToSnarkJS(x, y [2]big.Int, infinity bool) [][]string {
+	if infinity {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return [][]string{
+		{x[0].String(), x[1].String()},
+		{y[0].String(), y[1].String()},
+		{"1", "0"},
+	}
+}
+
+// g1FromSnarkJS returns the affine coordinates of snarkjs point p, (0, 0) for
+// the point at infinity as in gnark-crypto
+func g1FromSnarkJS(p []string, q *big.Int) (x, y big.Int, err error) {
+	if len(p) != 3 {
+		return x, y, errors.New("a G1 point has 3 coordinates")
+	}
+	switch p[2] {
+	case "0":
+		return x, y, nil
+	case "1":
+	default:
+		return x, y, errors.New("only affine coordinates (z = 1) are supported")
+	}
+	if err = setDecimal(&x, p[0], q); err != nil {
+		return
+	}
+	err = setDecimal(&y, p[1], q)
+	return
+}
+
+// g2FromSnarkJS returns the affine coordinates of snarkjs point p, (0, 0) for
+// the point at infinity as in gnark-crypto
+func g2FromSnarkJS(p [][]string, q *big.Int) (x, y [2]big.Int, err error) {
+	if len(p) != 3 || len(p[0]) != 2 || len(p[1]) != 2 || len(p[2]) != 2 {
+		return x, y, errors.New("a G2 point has 3 coordinates in Fp2")
+	}
+	switch {
+	case p[2][0] == "0" && p[2][1] == "0":
+		return x, y, nil
+	case p[2][0] == "1" && p[2][1] == "0":
+	default:
+		return x, y, errors.New("only affine coordinates (z = 1) are supported")
+	}
+	for i := 0; i < 2; i++ {
+		if err = setDecimal(&x[i], p[0][i], q); err != nil {
+			return
+		}
+		if err = setDecimal(&y[i], p[1][i], q); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// setDecimal sets z to the decimal value s, which must be in [0, q)
+func setDecimal(z *big.Int, s string, q *big.Int) error {
+	if _, ok := z.SetString(s, 10); !ok {
+		return fmt.Errorf("invalid decimal value %q", s)
+	}
+	if z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return fmt.Errorf("value %s out of range", s)
+	}
+	return nil
+}
+
+var errSnarkJSPoint = errors.New("point is not in the correct subgroup")
+
+func bn254G1ToSnarkJS(p *bn254.G1Affine) []string {
+	var x, y big.Int
+	p.X.ToBigIntRegular(&x)
+	p.Y.ToBigIntRegular(&y)
+	return g1ToSnarkJS(&x, &y, p.IsInfinity())
+}
+
+func bn254G2ToSnarkJS(p *bn254.G2Affine) [][]string {
+	var x, y [2]big.Int
+	p.X.A0.ToBigIntRegular(&x[0])
+	p.X.A1.ToBigIntRegular(&x[1])
+	p.Y.A0.ToBigIntRegular(&y[0])
+	p.Y.A1.ToBigIntRegular(&y[1])
+	return g2ToSnarkJS(x, y, p.IsInfinity())
+}
+
+func bn254G1FromSnarkJS(p *bn254.G1Affine, s []string, q *big.Int) error {
+	x, y, err := g1FromSnarkJS(s, q)
+	if err != nil {
+		return err
+	}
+	p.X.SetBigInt(&x)
+	p.Y.SetBigInt(&y)
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return errSnarkJSPoint
+	}
+	return nil
+}
+
+func bn254G2FromSnarkJS(p *bn254.G2Affine, s [][]string, q *big.Int) error {
+	x, y, err := g2FromSnarkJS(s, q)
+	if err != nil {
+		return err
+	}
+	p.X.A0.SetBigInt(&x[0])
+	p.X.A1.SetBigInt(&x[1])
+	p.Y.A0.SetBigInt(&y[0])
+	p.Y.A1.SetBigInt(&y[1])
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return errSnarkJSPoint
+	}
+	return nil
+}
+
+func bls12381G1ToSnarkJS(p *bls12381.G1Affine) []string {
+	var x, y big.Int
+	p.X.ToBigIntRegular(&x)
+	p.Y.ToBigIntRegular(&y)
+	return g1ToSnarkJS(&x, &y, p.IsInfinity())
+}
+
+func bls12381G2ToSnarkJS(p *bls12381.G2Affine) [][]string {
+	var x, y [2]big.Int
+	p.X.A0.ToBigIntRegular(&x[0])
+	p.X.A1.ToBigIntRegular(&x[1])
+	p.Y.A0.ToBigIntRegular(&y[0])
+	p.Y.A1.ToBigIntRegular(&y[1])
+	return g2ToSnarkJS(x, y, p.IsInfinity())
+}
+
+func bls12381G1FromSnarkJS(p *bls12381.G1Affine, s []string, q *big.Int) error {
+	x, y, err := g1FromSnarkJS(s, q)
+	if err != nil {
+		return err
+	}
+	p.X.SetBigInt(&x)
+	p.Y.SetBigInt(&y)
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return errSnarkJSPoint
+	}
+	return nil
+}
+
+func bls12381G2FromSnarkJS(p *bls12381.G2Affine, s [][]string, q *big.Int) error {
+	x, y, err := g2FromSnarkJS(s, q)
+	if err != nil {
+		return err
+	}
+	p.X.A0.SetBigInt(&x[0])
+	p.X.A1.SetBigInt(&x[1])
+	p.Y.A0.SetBigInt(&y[0])
+	p.Y.A1.SetBigInt(&y[1])
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return errSnarkJSPoint
+	}
+	return nil
+}
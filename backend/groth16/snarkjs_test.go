@@ -0,0 +1,88 @@
+package groth16
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type snarkjsCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (circuit *snarkjsCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X), circuit.Y)
+	api.AssertIsEqual(api.Add(circuit.X, circuit.Y), circuit.Z)
+	return nil
+}
+
+func TestSnarkJS(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_381} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &snarkjsCircuit{})
+			assert.NoError(err)
+			pk, vk, err := Setup(ccs)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(&snarkjsCircuit{X: 3, Y: 9, Z: 12}, curve)
+			assert.NoError(err)
+			publicWitness, err := w.Public()
+			assert.NoError(err)
+			proof, err := Prove(ccs, pk, w)
+			assert.NoError(err)
+
+			var proofJSON, vkJSON, publicJSON bytes.Buffer
+			assert.NoError(WriteSnarkJSProof(&proofJSON, proof))
+			assert.NoError(WriteSnarkJSVerifyingKey(&vkJSON, vk))
+			assert.NoError(WriteSnarkJSPublicSignals(&publicJSON, publicWitness))
+			assert.JSONEq(`["9", "12"]`, publicJSON.String())
+
+			decodedProof, err := ReadSnarkJSProof(bytes.NewReader(proofJSON.Bytes()))
+			assert.NoError(err)
+			decodedVK, err := ReadSnarkJSVerifyingKey(bytes.NewReader(vkJSON.Bytes()))
+			assert.NoError(err)
+			decodedPublic, err := ReadSnarkJSPublicSignals(bytes.NewReader(publicJSON.Bytes()), curve)
+			assert.NoError(err)
+
+			assert.NoError(Verify(decodedProof, decodedVK, decodedPublic))
+
+			// encodings are stable
+			var again bytes.Buffer
+			assert.NoError(WriteSnarkJSProof(&again, decodedProof))
+			assert.Equal(proofJSON.String(), again.String())
+			again.Reset()
+			assert.NoError(WriteSnarkJSVerifyingKey(&again, decodedVK))
+			assert.Equal(vkJSON.String(), again.String())
+
+			// a wrong public signal is rejected
+			wrong, err := ReadSnarkJSPublicSignals(bytes.NewReader([]byte(`["9", "13"]`)), curve)
+			assert.NoError(err)
+			assert.Error(Verify(decodedProof, decodedVK, wrong))
+		})
+	}
+}
+
+func TestSnarkJSInvalid(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := ReadSnarkJSProof(bytes.NewReader([]byte(`{"pi_a": ["1", "3", "1"], "pi_b": [["0", "0"], ["1", "0"], ["0", "0"]], "pi_c": ["0", "1", "0"], "protocol": "groth16", "curve": "bn128"}`)))
+	assert.Error(err, "(1, 3) is not on BN254")
+
+	_, err = ReadSnarkJSProof(bytes.NewReader([]byte(`{"pi_a": ["1", "2", "1"], "pi_b": [["0", "0"], ["1", "0"], ["0", "0"]], "pi_c": ["0", "1", "0"], "protocol": "plonk", "curve": "bn128"}`)))
+	assert.Error(err)
+
+	_, err = ReadSnarkJSPublicSignals(bytes.NewReader([]byte(`["-1"]`)), ecc.BN254)
+	assert.ErrorIs(err, witness.ErrInvalidWitness)
+
+	_, err = ReadSnarkJSPublicSignals(bytes.NewReader([]byte(`["1"]`)), ecc.BW6_761)
+	assert.Error(err)
+}
@@ -61,6 +61,39 @@ proving option.
 
 In the init() method of the gadget, call the method Register(hintFn) method on
 the hint function hintFn to register a hint function in the package registry.
+
+Stable hint identifiers
+
+By default, the ID of a hint function is derived from the name the runtime gives
+to it (for example "github.com/consensys/gnark/std/math/bits.NBits"). The ID is
+stored in the compiled constraint system, so renaming the function, moving it
+to another package or wrapping it in a closure changes its ID and already
+serialized constraint systems fail to solve with a missing hint error.
+
+To avoid this, a hint function can be registered with an explicit name and
+version using RegisterNamed(name, version, hintFn). The ID is then derived from
+the name and version only (see NamedID), and the name the runtime gives to the
+function does not matter anymore. The version should be increased when the
+semantics of the hint function change.
+
+For migration, RegisterNamed also keeps the ID the hint function had before
+(derived from its current runtime name) as an alias, so that constraint systems
+compiled before the switch still solve. If the function was renamed or moved
+already, its former runtime names can be declared with RegisterAlias.
+
+Rich hint functions
+
+A RichFunction receives, in addition to the variable inputs, a Context holding
+constant parameters (see Param) embedded in the constraint system at compile
+time, the modulus of the scalar field and a per-proof value given to the prover
+with backend.WithHintContext. It is called with
+frontend.API.NewRichHint(hintFn, nbOutputs, params, vars...) or, if the number of
+outputs is only bounded, with frontend.API.NewVariableHint(hintFn, maxOutputs,
+params, vars...), which also returns a variable holding the number of outputs
+set by the hint function.
+
+Rich hint functions are registered with RegisterRich or RegisterNamedRich and
+given to the prover with backend.WithRichHints.
 */
 package hint
 
@@ -69,6 +102,7 @@ import (
 	"math/big"
 	"reflect"
 	"runtime"
+	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc"
 )
@@ -85,10 +119,57 @@ type ID uint32
 //	b[0] and b[1].
 type Function func(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error
 
-// UUID is a reference function for computing the hint ID based on a function name
+// UUID is a reference function for computing the hint ID based on a function name.
+//
+// If fn was registered with RegisterNamed, UUID returns the ID derived from the
+// explicit name and version, see NamedID. Otherwise, the ID is derived from the
+// name the runtime gives to fn, which changes when fn is renamed or moved.
 func UUID(fn Function) ID {
+	return uuid(fn)
+}
+
+// Name returns the name of the hint function: the explicit name given to
+// RegisterNamed if any, or the name the runtime gives to fn.
+func Name(fn Function) string {
+	return name(fn)
+}
+
+// RichUUID is the counterpart of UUID for a RichFunction.
+func RichUUID(fn RichFunction) ID {
+	return uuid(fn)
+}
+
+// RichName is the counterpart of Name for a RichFunction.
+func RichName(fn RichFunction) string {
+	return name(fn)
+}
+
+// NamedID returns the ID of a hint function registered with
+// RegisterNamed(name, version, fn).
+func NamedID(name string, version uint) ID {
+	hf := fnv.New32a()
+	hf.Write([]byte(name))                              // #nosec G104 -- does not err
+	hf.Write([]byte("@v" + strconv.Itoa(int(version)))) // #nosec G104 -- does not err
+	return ID(hf.Sum32())
+}
+
+func uuid(fn interface{}) ID {
+	if n, ok := lookupNamed(fn); ok {
+		return n.id
+	}
+	return legacyUUID(runtimeName(fn))
+}
+
+func name(fn interface{}) string {
+	if n, ok := lookupNamed(fn); ok {
+		return n.name
+	}
+	return runtimeName(fn)
+}
+
+// legacyUUID computes the ID of a hint function from its runtime name
+func legacyUUID(name string) ID {
 	hf := fnv.New32a()
-	name := Name(fn)
 
 	// TODO relying on name to derive UUID is risky; if fn is an anonymous func, wil be package.glob..funcN
 	// and if new anonymous functions are added in the package, N may change, so will UUID.
@@ -97,7 +178,11 @@ func UUID(fn Function) ID {
 	return ID(hf.Sum32())
 }
 
-func Name(fn Function) string {
-	fnptr := reflect.ValueOf(fn).Pointer()
-	return runtime.FuncForPC(fnptr).Name()
+func runtimeName(fn interface{}) string {
+	return runtime.FuncForPC(funcPC(fn)).Name()
+}
+
+// funcPC returns the code pointer of a Function or a RichFunction
+func funcPC(fn interface{}) uintptr {
+	return reflect.ValueOf(fn).Pointer()
 }
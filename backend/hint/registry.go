@@ -1,20 +1,35 @@
 package hint
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/consensys/gnark/logger"
 )
 
 var registry = make(map[ID]Function)
+var richRegistry = make(map[ID]RichFunction)
 var registryM sync.RWMutex
 
+// named hint functions, indexed by function pointer and by ID
+var namedByPC = make(map[uintptr]namedHint)
+var namedByID = make(map[ID]namedHint)
+
+// aliases maps a legacy ID to the named hint function it now resolves to
+var aliases = make(map[ID]namedHint)
+
+type namedHint struct {
+	id   ID
+	name string
+	pc   uintptr
+}
+
 // Register registers an hint function in the global registry.
 func Register(hintFn Function) {
-	registryM.Lock()
-	defer registryM.Unlock()
 	key := UUID(hintFn)
 	name := Name(hintFn)
+	registryM.Lock()
+	defer registryM.Unlock()
 	if _, ok := registry[key]; ok {
 		log := logger.Logger()
 		log.Warn().Str("name", name).Msg("function registered multiple times")
@@ -23,6 +38,151 @@ func Register(hintFn Function) {
 	registry[key] = hintFn
 }
 
+// RegisterRich registers a rich hint function in the global registry.
+func RegisterRich(hintFn RichFunction) {
+	key := RichUUID(hintFn)
+	name := RichName(hintFn)
+	registryM.Lock()
+	defer registryM.Unlock()
+	if _, ok := richRegistry[key]; ok {
+		log := logger.Logger()
+		log.Warn().Str("name", name).Msg("function registered multiple times")
+		return
+	}
+	richRegistry[key] = hintFn
+}
+
+// RegisterNamed registers an hint function in the global registry with an ID
+// derived from the explicit name and version (see NamedID) rather than from the
+// name the runtime gives to the function. The hint function keeps this ID when
+// it is renamed or moved to another package.
+//
+// The ID the hint function had before (see UUID) is kept as an alias, so that
+// constraint systems compiled before the function was named still solve.
+//
+// Functions are identified by their code pointer: closures created from the
+// same function literal are the same function for RegisterNamed.
+//
+// RegisterNamed panics if the name and version, or the hint function, are
+// already registered with a different hint function, or name.
+func RegisterNamed(name string, version uint, hintFn Function) {
+	registryM.Lock()
+	defer registryM.Unlock()
+	n, legacyID, ok := registerNamed(name, version, hintFn)
+	if !ok {
+		return
+	}
+	registry[n.id] = hintFn
+
+	// migration: the function may already be registered under its legacy ID,
+	// which now resolves to the named function.
+	if f, ok := registry[legacyID]; ok {
+		if funcPC(f) != n.pc {
+			return
+		}
+		delete(registry, legacyID)
+	}
+	if _, ok := aliases[legacyID]; !ok {
+		aliases[legacyID] = n
+	}
+}
+
+// RegisterNamedRich is the counterpart of RegisterNamed for a rich hint function.
+func RegisterNamedRich(name string, version uint, hintFn RichFunction) {
+	registryM.Lock()
+	defer registryM.Unlock()
+	n, legacyID, ok := registerNamed(name, version, hintFn)
+	if !ok {
+		return
+	}
+	richRegistry[n.id] = hintFn
+
+	if f, ok := richRegistry[legacyID]; ok {
+		if funcPC(f) != n.pc {
+			return
+		}
+		delete(richRegistry, legacyID)
+	}
+	if _, ok := aliases[legacyID]; !ok {
+		aliases[legacyID] = n
+	}
+}
+
+// registerNamed checks for collisions and records the named hint function
+// hintFn. It returns false if hintFn is already registered with this name.
+// registryM must be locked.
+func registerNamed(name string, version uint, hintFn interface{}) (namedHint, ID, bool) {
+	if name == "" {
+		panic("hint: RegisterNamed called with an empty name")
+	}
+	n := namedHint{
+		id:   NamedID(name, version),
+		name: fmt.Sprintf("%s@v%d", name, version),
+		pc:   funcPC(hintFn),
+	}
+	legacyID := legacyUUID(runtimeName(hintFn))
+
+	if other, ok := namedByPC[n.pc]; ok {
+		if other.name == n.name {
+			log := logger.Logger()
+			log.Warn().Str("name", n.name).Msg("function registered multiple times")
+			return n, legacyID, false
+		}
+		panic(fmt.Sprintf("hint: %s already registered as %s", n.name, other.name))
+	}
+	if other, ok := namedByID[n.id]; ok {
+		if other.name == n.name {
+			panic(fmt.Sprintf("hint: %s already registered with a different function", n.name))
+		}
+		panic(fmt.Sprintf("hint: ID collision between %s and %s", n.name, other.name))
+	}
+	_, inRegistry := registry[n.id]
+	_, inRichRegistry := richRegistry[n.id]
+	if inRegistry || inRichRegistry {
+		panic(fmt.Sprintf("hint: ID of %s collides with a registered hint function", n.name))
+	}
+
+	namedByPC[n.pc] = n
+	namedByID[n.id] = n
+	return n, legacyID, true
+}
+
+// RegisterAlias declares legacyName, a former runtime name of the named hint
+// function hintFn (for example "github.com/consensys/gnark/std/math/bits.NBits"),
+// so that constraint systems compiled when hintFn had this name still solve.
+//
+// RegisterAlias panics if hintFn was not registered with RegisterNamed, or if
+// the ID derived from legacyName resolves to another hint function.
+func RegisterAlias(legacyName string, hintFn Function) {
+	registerAlias(legacyName, hintFn)
+}
+
+// RegisterRichAlias is the counterpart of RegisterAlias for a rich hint function.
+func RegisterRichAlias(legacyName string, hintFn RichFunction) {
+	registerAlias(legacyName, hintFn)
+}
+
+func registerAlias(legacyName string, hintFn interface{}) {
+	registryM.Lock()
+	defer registryM.Unlock()
+
+	n, ok := namedByPC[funcPC(hintFn)]
+	if !ok {
+		panic(fmt.Sprintf("hint: RegisterAlias(%s) called on a hint function not registered with RegisterNamed", legacyName))
+	}
+	id := legacyUUID(legacyName)
+	if other, ok := aliases[id]; ok {
+		if other.pc != n.pc {
+			panic(fmt.Sprintf("hint: alias %s of %s already resolves to %s", legacyName, n.name, other.name))
+		}
+		return
+	}
+	if other, ok := namedByID[id]; ok {
+		panic(fmt.Sprintf("hint: alias %s of %s collides with %s", legacyName, n.name, other.name))
+	}
+	aliases[id] = n
+}
+
 // GetRegistered returns all registered hint functions.
 func GetRegistered() []Function {
 	registryM.RLock()
@@ -33,3 +193,49 @@ func GetRegistered() []Function {
 	}
 	return ret
 }
+
+// GetRegisteredRich returns all registered rich hint functions.
+func GetRegisteredRich() []RichFunction {
+	registryM.RLock()
+	defer registryM.RUnlock()
+	ret := make([]RichFunction, 0, len(richRegistry))
+	for _, v := range richRegistry {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// Aliases returns the legacy IDs under which the named hint function fn must
+// also be resolved by the solver. It returns nil if fn was not registered with
+// RegisterNamed.
+func Aliases(fn Function) []ID {
+	return aliasesOf(fn)
+}
+
+// RichAliases is the counterpart of Aliases for a rich hint function.
+func RichAliases(fn RichFunction) []ID {
+	return aliasesOf(fn)
+}
+
+func aliasesOf(fn interface{}) []ID {
+	registryM.RLock()
+	defer registryM.RUnlock()
+	pc := funcPC(fn)
+	if _, ok := namedByPC[pc]; !ok {
+		return nil
+	}
+	var ret []ID
+	for id, n := range aliases {
+		if n.pc == pc {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+func lookupNamed(fn interface{}) (namedHint, bool) {
+	registryM.RLock()
+	defer registryM.RUnlock()
+	n, ok := namedByPC[funcPC(fn)]
+	return n, ok
+}
@@ -0,0 +1,58 @@
+package hint
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/require"
+)
+
+func namedHintA(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+func namedHintB(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Neg(inputs[0])
+	return nil
+}
+
+func TestRegisterNamed(t *testing.T) {
+	assert := require.New(t)
+
+	legacyID := UUID(namedHintA)
+	Register(namedHintA)
+
+	RegisterNamed("test/hintA", 1, namedHintA)
+	assert.Equal(NamedID("test/hintA", 1), UUID(namedHintA))
+	assert.NotEqual(NamedID("test/hintA", 1), NamedID("test/hintA", 2))
+	assert.Equal("test/hintA@v1", Name(namedHintA))
+	assert.Equal([]ID{legacyID}, Aliases(namedHintA))
+
+	// the function is registered once, under its named ID
+	nb := 0
+	for _, fn := range GetRegistered() {
+		if funcPC(fn) == funcPC(namedHintA) {
+			nb++
+		}
+	}
+	assert.Equal(1, nb)
+
+	// registering the same function again is a no-op
+	RegisterNamed("test/hintA", 1, namedHintA)
+	Register(namedHintA)
+
+	// collisions
+	assert.Panics(func() { RegisterNamed("test/hintA", 1, namedHintB) }, "name registered with another function")
+	assert.Panics(func() { RegisterNamed("test/hintA", 2, namedHintA) }, "function registered with another name")
+	assert.Panics(func() { RegisterNamed("", 1, namedHintB) }, "empty name")
+
+	// aliases
+	RegisterAlias("github.com/consensys/gnark/old.hintA", namedHintA)
+	assert.ElementsMatch([]ID{legacyID, legacyUUID("github.com/consensys/gnark/old.hintA")}, Aliases(namedHintA))
+	assert.Panics(func() { RegisterAlias("github.com/consensys/gnark/old.hintB", namedHintB) }, "unnamed function")
+
+	RegisterNamed("test/hintB", 1, namedHintB)
+	assert.Panics(func() { RegisterAlias("github.com/consensys/gnark/old.hintA", namedHintB) }, "alias of another function")
+}
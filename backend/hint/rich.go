@@ -0,0 +1,177 @@
+package hint
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// RichFunction defines a hint function which, in addition to the variable
+// inputs, receives a Context holding constant parameters set at compile time,
+// the modulus of the scalar field and a per-proof value.
+//
+// It returns the number of outputs it actually set. For a call with a fixed
+// number of outputs (frontend.API.NewRichHint), it must be len(outputs); for a
+// call with a maximum number of outputs (frontend.API.NewVariableHint), it must
+// be at most len(outputs) and the outputs past it are set to 0 by the solver.
+type RichFunction func(ctx *Context, inputs []*big.Int, outputs []*big.Int) (nbOutputs int, err error)
+
+// Context is given to a RichFunction at solving time.
+type Context struct {
+	CurveID ecc.ID
+	Modulus *big.Int    // modulus of the scalar field of CurveID; must not be modified
+	Params  []Param     // constant parameters given at compile time
+	Value   interface{} // per-proof value, see backend.WithHintContext
+}
+
+// ParamType is the type of a Param.
+type ParamType uint8
+
+const (
+	ParamInt ParamType = iota + 1
+	ParamBool
+	ParamString
+	ParamBytes
+	ParamBigInt
+)
+
+// String returns the string representation of a ParamType
+func (t ParamType) String() string {
+	switch t {
+	case ParamInt:
+		return "int"
+	case ParamBool:
+		return "bool"
+	case ParamString:
+		return "string"
+	case ParamBytes:
+		return "bytes"
+	case ParamBigInt:
+		return "bigint"
+	default:
+		return "unknown"
+	}
+}
+
+// Param is a constant parameter of a RichFunction. Params are embedded in the
+// compiled constraint system and serialized with it; they are built with Int,
+// Bool, String, Bytes and BigInt.
+type Param struct {
+	typ ParamType
+	i   int64  // ParamInt, ParamBool and the sign of ParamBigInt
+	b   []byte // ParamString, ParamBytes and the absolute value of ParamBigInt
+}
+
+// Int returns a Param holding v.
+func Int(v int64) Param {
+	return Param{typ: ParamInt, i: v}
+}
+
+// Bool returns a Param holding v.
+func Bool(v bool) Param {
+	p := Param{typ: ParamBool}
+	if v {
+		p.i = 1
+	}
+	return p
+}
+
+// String returns a Param holding v.
+func String(v string) Param {
+	return Param{typ: ParamString, b: []byte(v)}
+}
+
+// Bytes returns a Param holding a copy of v.
+func Bytes(v []byte) Param {
+	return Param{typ: ParamBytes, b: append([]byte{}, v...)}
+}
+
+// BigInt returns a Param holding a copy of v.
+func BigInt(v *big.Int) Param {
+	return Param{typ: ParamBigInt, i: int64(v.Sign()), b: v.Bytes()}
+}
+
+// Type returns the type of the parameter.
+func (p Param) Type() ParamType {
+	return p.typ
+}
+
+// AsInt returns the value of a ParamInt.
+func (p Param) AsInt() (int64, error) {
+	if err := p.expect(ParamInt); err != nil {
+		return 0, err
+	}
+	return p.i, nil
+}
+
+// AsBool returns the value of a ParamBool.
+func (p Param) AsBool() (bool, error) {
+	if err := p.expect(ParamBool); err != nil {
+		return false, err
+	}
+	return p.i != 0, nil
+}
+
+// AsString returns the value of a ParamString.
+func (p Param) AsString() (string, error) {
+	if err := p.expect(ParamString); err != nil {
+		return "", err
+	}
+	return string(p.b), nil
+}
+
+// AsBytes returns a copy of the value of a ParamBytes.
+func (p Param) AsBytes() ([]byte, error) {
+	if err := p.expect(ParamBytes); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, p.b...), nil
+}
+
+// AsBigInt returns a copy of the value of a ParamBigInt.
+func (p Param) AsBigInt() (*big.Int, error) {
+	if err := p.expect(ParamBigInt); err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetBytes(p.b)
+	if p.i < 0 {
+		v.Neg(v)
+	}
+	return v, nil
+}
+
+func (p Param) expect(t ParamType) error {
+	if p.typ != t {
+		return fmt.Errorf("hint parameter is of type %s, not %s", p.typ, t)
+	}
+	return nil
+}
+
+// cborParam is the serialized form of a Param
+type cborParam struct {
+	_    struct{} `cbor:",toarray"`
+	Type ParamType
+	I    int64
+	B    []byte
+}
+
+// MarshalCBOR implements cbor.Marshaler
+func (p Param) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(cborParam{Type: p.typ, I: p.i, B: p.b})
+}
+
+// UnmarshalCBOR implements cbor.Unmarshaler
+func (p *Param) UnmarshalCBOR(data []byte) error {
+	var v cborParam
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Type < ParamInt || v.Type > ParamBigInt {
+		return errors.New("invalid hint parameter type")
+	}
+	p.typ, p.i, p.b = v.Type, v.I, v.B
+	return nil
+}
@@ -0,0 +1,43 @@
+package hint
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParam(t *testing.T) {
+	assert := require.New(t)
+
+	params := []Param{Int(-3), Bool(true), String("table"), Bytes([]byte{1, 2}), BigInt(big.NewInt(-42))}
+
+	data, err := cbor.Marshal(params)
+	assert.NoError(err)
+	var decoded []Param
+	assert.NoError(cbor.Unmarshal(data, &decoded))
+	assert.Equal(params, decoded)
+
+	i, err := decoded[0].AsInt()
+	assert.NoError(err)
+	assert.Equal(int64(-3), i)
+	b, err := decoded[1].AsBool()
+	assert.NoError(err)
+	assert.True(b)
+	s, err := decoded[2].AsString()
+	assert.NoError(err)
+	assert.Equal("table", s)
+	bb, err := decoded[3].AsBytes()
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2}, bb)
+	v, err := decoded[4].AsBigInt()
+	assert.NoError(err)
+	assert.Equal(big.NewInt(-42), v)
+
+	_, err = decoded[0].AsString()
+	assert.Error(err, "type mismatch")
+
+	var p Param
+	assert.Error(cbor.Unmarshal([]byte{0x83, 0x09, 0x00, 0x40}, &p), "invalid type")
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+	gnarkio "github.com/consensys/gnark/io"
 
 	"github.com/consensys/gnark/backend/witness"
 	cs_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
@@ -71,6 +73,8 @@ type Proof interface {
 type ProvingKey interface {
 	io.WriterTo
 	io.ReaderFrom
+	gnarkio.WriterMappedTo
+	gnarkio.ReaderMappedFrom
 	InitKZG(srs kzg.SRS) error
 	VerifyingKey() interface{}
 }
@@ -86,21 +90,23 @@ type VerifyingKey interface {
 }
 
 // Setup prepares the public data associated to a circuit + public inputs.
-func Setup(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS) (ProvingKey, VerifyingKey, error) {
+//
+// See backend.SetupOption for the options.
+func Setup(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
 
 	switch tccs := ccs.(type) {
 	case *cs_bn254.SparseR1CS:
-		return plonk_bn254.Setup(tccs, kzgSRS.(*kzg_bn254.SRS))
+		return plonk_bn254.Setup(tccs, kzgSRS.(*kzg_bn254.SRS), opts...)
 	case *cs_bls12381.SparseR1CS:
-		return plonk_bls12381.Setup(tccs, kzgSRS.(*kzg_bls12381.SRS))
+		return plonk_bls12381.Setup(tccs, kzgSRS.(*kzg_bls12381.SRS), opts...)
 	case *cs_bls12377.SparseR1CS:
-		return plonk_bls12377.Setup(tccs, kzgSRS.(*kzg_bls12377.SRS))
+		return plonk_bls12377.Setup(tccs, kzgSRS.(*kzg_bls12377.SRS), opts...)
 	case *cs_bw6761.SparseR1CS:
-		return plonk_bw6761.Setup(tccs, kzgSRS.(*kzg_bw6761.SRS))
+		return plonk_bw6761.Setup(tccs, kzgSRS.(*kzg_bw6761.SRS), opts...)
 	case *cs_bls24315.SparseR1CS:
-		return plonk_bls24315.Setup(tccs, kzgSRS.(*kzg_bls24315.SRS))
+		return plonk_bls24315.Setup(tccs, kzgSRS.(*kzg_bls24315.SRS), opts...)
 	case *cs_bw6633.SparseR1CS:
-		return plonk_bw6633.Setup(tccs, kzgSRS.(*kzg_bw6633.SRS))
+		return plonk_bw6633.Setup(tccs, kzgSRS.(*kzg_bw6633.SRS), opts...)
 	default:
 		panic("unrecognized SparseR1CS curve type")
 	}
@@ -112,6 +118,8 @@ func Setup(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS) (ProvingKey, V
 // 	will executes all the prover computations, even if the witness is invalid
 //  will produce an invalid proof
 //	internally, the solution vector to the SparseR1CS will be filled with random values which may impact benchmarking
+//
+// the outputs of the circuit (see schema.Output) are computed by the solver and set in fullWitness
 func Prove(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, error) {
 
 	// apply options
@@ -168,6 +176,21 @@ func Prove(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *wi
 	}
 }
 
+// ProveWithOutputs runs plonk.Prove and returns the proof together with the public witness
+// to verify it, whose outputs (see schema.Output) are the values computed by the solver.
+// fullWitness must have a Schema, as returned by frontend.NewWitness.
+func ProveWithOutputs(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, opts ...backend.ProverOption) (Proof, *witness.Witness, error) {
+	proof, err := Prove(ccs, pk, fullWitness, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, publicWitness, nil
+}
+
 // Verify verifies a PLONK proof, from the proof, preprocessed public data, and public witness.
 func Verify(proof Proof, vk VerifyingKey, publicWitness *witness.Witness) error {
 
@@ -267,6 +290,25 @@ func NewProvingKey(curveID ecc.ID) ProvingKey {
 	return pk
 }
 
+// ReadMappedProvingKey memory-maps the file at path, written by
+// ProvingKey.WriteMappedTo, and returns the ProvingKey of curveID which uses it
+// in place: its pages are loaded when the prover reads them instead of being
+// decoded to the heap. unmap releases the file; the ProvingKey must not be used
+// after.
+// As with ReadFrom, InitKZG must be called before proving.
+func ReadMappedProvingKey(curveID ecc.ID, path string) (pk ProvingKey, unmap func() error, err error) {
+	data, unmap, err := utils.MapFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk = NewProvingKey(curveID)
+	if _, err := pk.ReadMappedFrom(data); err != nil {
+		_ = unmap()
+		return nil, nil, err
+	}
+	return pk, unmap, nil
+}
+
 // NewProof instantiates a curve-typed ProvingKey and returns an interface
 // This function exists for serialization purposes
 func NewProof(curveID ecc.ID) Proof {
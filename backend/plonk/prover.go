@@ -0,0 +1,268 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plonk
+
+import (
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+
+	cs_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	cs_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	cs_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	cs_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	cs_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	cs_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+
+	witness_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/witness"
+	witness_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	witness_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/witness"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+	witness_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/witness"
+	witness_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/witness"
+
+	plonk_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/plonk"
+	plonk_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/plonk"
+	plonk_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/plonk"
+	plonk_bn254 "github.com/consensys/gnark/internal/backend/bn254/plonk"
+	plonk_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/plonk"
+	plonk_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/plonk"
+)
+
+// Prover generates the proofs of a circuit with a ProvingKey for many
+// witnesses, see NewProver.
+type Prover struct {
+	prove      func(fullWitness *witness.Witness) (Proof, error)
+	proveBatch func(fullWitnesses []*witness.Witness) ([]Proof, error)
+}
+
+// NewProver returns a Prover of the SparseR1CS ccs with the ProvingKey pk.
+//
+// Unlike Prove, the Prover keeps the buffers of a proof and the data which only
+// depend on pk for the next proofs, and ProveBatch solves a witness while the
+// proof of the previous one is computed. The options apply to every proof.
+//
+// A Prover is not safe for concurrent use.
+func NewProver(ccs frontend.CompiledConstraintSystem, pk ProvingKey, opts ...backend.ProverOption) (*Prover, error) {
+
+	// apply options
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		p := plonk_bn254.NewProver(tccs, pk.(*plonk_bn254.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bn254.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+
+	case *cs_bls12381.SparseR1CS:
+		p := plonk_bls12381.NewProver(tccs, pk.(*plonk_bls12381.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bls12381.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+
+	case *cs_bls12377.SparseR1CS:
+		p := plonk_bls12377.NewProver(tccs, pk.(*plonk_bls12377.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bls12377.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+
+	case *cs_bw6761.SparseR1CS:
+		p := plonk_bw6761.NewProver(tccs, pk.(*plonk_bw6761.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bw6761.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+
+	case *cs_bw6633.SparseR1CS:
+		p := plonk_bw6633.NewProver(tccs, pk.(*plonk_bw6633.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bw6633.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+
+	case *cs_bls24315.SparseR1CS:
+		p := plonk_bls24315.NewProver(tccs, pk.(*plonk_bls24315.ProvingKey), opt)
+		return &Prover{
+			prove: func(fullWitness *witness.Witness) (Proof, error) {
+				w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+				if !ok {
+					return nil, witness.ErrInvalidWitness
+				}
+				return p.Prove(*w)
+			},
+			proveBatch: func(fullWitnesses []*witness.Witness) ([]Proof, error) {
+				ws := make([]witness_bls24315.Witness, len(fullWitnesses))
+				for i, fullWitness := range fullWitnesses {
+					w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+					if !ok {
+						return nil, witness.ErrInvalidWitness
+					}
+					ws[i] = *w
+				}
+				proofs, err := p.ProveBatch(ws)
+				if err != nil {
+					return nil, err
+				}
+				res := make([]Proof, len(proofs))
+				for i := range proofs {
+					res[i] = proofs[i]
+				}
+				return res, nil
+			},
+		}, nil
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// Prove generates the proof of knowledge of the circuit with the full witness
+// (secret + public part).
+func (p *Prover) Prove(fullWitness *witness.Witness) (Proof, error) {
+	return p.prove(fullWitness)
+}
+
+// ProveBatch generates the proofs of the full witnesses, in order. It stops at
+// the first witness which can't be proved and returns its error, wrapped with
+// the index of the witness.
+func (p *Prover) ProveBatch(fullWitnesses []*witness.Witness) ([]Proof, error) {
+	return p.proveBatch(fullWitnesses)
+}
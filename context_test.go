@@ -0,0 +1,77 @@
+package gnark_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+// cancelProof is called by cancelHint, while the constraint system is solved
+var cancelProof context.CancelFunc
+
+func cancelHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	cancelProof()
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+type cancelCircuit struct {
+	X, Y frontend.Variable
+}
+
+func (circuit *cancelCircuit) Define(api frontend.API) error {
+	r, err := api.Compiler().NewHint(cancelHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(r[0], circuit.X)
+
+	// the following levels depend on the hint
+	x := r[0]
+	for i := 0; i < 10; i++ {
+		x = api.Mul(x, x)
+	}
+	api.AssertIsEqual(x, circuit.Y)
+	return nil
+}
+
+func TestProveContext(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&cancelCircuit{X: 1, Y: 1}, ecc.BN254)
+	assert.NoError(err)
+	systems := newProvingSystems(assert, ecc.BN254, &cancelCircuit{}, nil)
+
+	prove := func(ctx context.Context) error {
+		for _, ps := range systems {
+			if _, err := ps.prove(w, backend.WithHints(cancelHint), backend.WithContext(ctx)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// not cancelled
+	cancelProof = func() {}
+	assert.NoError(prove(context.Background()))
+
+	// already cancelled
+	var ctx context.Context
+	ctx, cancelProof = context.WithCancel(context.Background())
+	cancelProof()
+	assert.ErrorIs(prove(ctx), context.Canceled)
+
+	// cancelled while solving
+	for _, ps := range systems {
+		ctx, cancelProof = context.WithCancel(context.Background())
+		_, err = ps.prove(w, backend.WithHints(cancelHint), backend.WithContext(ctx), backend.IgnoreSolverError())
+		assert.ErrorIs(err, context.Canceled, ps.ID.String())
+	}
+
+	assert.Error(backend.WithContext(nil)(&backend.ProverConfig{}))
+}
@@ -26,43 +26,92 @@ func WriteStack(sbb *strings.Builder, forceClean ...bool) {
 		return
 	}
 	pc = pc[:n] // pass only valid pcs to runtime.CallersFrames
-	frames := runtime.CallersFrames(pc)
-	// Loop to get frames.
-	// A fixed number of pcs can expand to an indefinite number of Frames.
-	for {
-		frame, more := frames.Next()
-		fe := strings.Split(frame.Function, "/")
-		function := fe[len(fe)-1]
-		file := frame.File
 
-		if !Debug || (len(forceClean) > 1 && forceClean[0]) {
-			if strings.Contains(function, "runtime.gopanic") {
-				continue
-			}
-			if strings.Contains(function, "frontend.(*constraintSystem)") {
-				continue
-			}
-			if strings.Contains(frame.File, "test/engine.go") {
-				continue
-			}
-			if strings.Contains(frame.File, "gnark/frontend") {
-				continue
-			}
+	clean := !Debug || (len(forceClean) > 1 && forceClean[0])
+	for _, frame := range Frames(pc, clean) {
+		file := frame.File
+		if clean {
 			file = filepath.Base(file)
 		}
-
-		sbb.WriteString(function)
+		sbb.WriteString(frame.Function)
 		sbb.WriteByte('\n')
 		sbb.WriteByte('\t')
 		sbb.WriteString(file)
 		sbb.WriteByte(':')
 		sbb.WriteString(strconv.Itoa(frame.Line))
 		sbb.WriteByte('\n')
+	}
+}
+
+// Callers returns the program counters of the calling stack, starting skip
+// frames above the caller of Callers. The stack is truncated to maxDepth
+// frames; see Frames to resolve it.
+func Callers(skip, maxDepth int) []uintptr {
+	pc := make([]uintptr, maxDepth)
+	n := runtime.Callers(skip+2, pc)
+	return pc[:n]
+}
+
+// Frames resolves the program counters returned by Callers, up to the Define
+// method of the circuit. The function names are stripped from their package
+// path.
+//
+// If clean is set, the frames of the runtime panics, of the test engine and
+// of the frontend are omitted.
+func Frames(pc []uintptr, clean bool) []runtime.Frame {
+	if len(pc) == 0 {
+		return nil
+	}
+	var res []runtime.Frame
+	frames := runtime.CallersFrames(pc)
+	// Loop to get frames.
+	// A fixed number of pcs can expand to an indefinite number of Frames.
+	for {
+		frame, more := frames.Next()
+		keep := !clean || keepFrame(frame)
+		fe := strings.Split(frame.Function, "/")
+		frame.Function = fe[len(fe)-1]
+
+		if keep {
+			res = append(res, frame)
+		}
 		if !more {
 			break
 		}
-		if strings.HasSuffix(function, "Define") {
+		if strings.HasSuffix(frame.Function, "Define") {
 			break
 		}
 	}
+	return res
+}
+
+// keepFrame returns false for the frames which are not part of the circuit
+// definition. frame.Function is the fully qualified function name.
+func keepFrame(frame runtime.Frame) bool {
+	if strings.Contains(frame.Function, "runtime.gopanic") {
+		return false
+	}
+	if strings.Contains(frame.Function, "frontend.(*constraintSystem)") {
+		return false
+	}
+	if strings.Contains(frame.File, "test/engine.go") {
+		return false
+	}
+	if strings.Contains(frame.File, "gnark/frontend") {
+		return false
+	}
+	const frontend = "github.com/consensys/gnark/frontend"
+	if pkg := packagePath(frame.Function); !strings.HasSuffix(pkg, "_test") && (pkg == frontend || strings.HasPrefix(pkg, frontend+"/")) {
+		return false
+	}
+	return true
+}
+
+// packagePath returns the package path of the fully qualified function name.
+func packagePath(function string) string {
+	i := strings.LastIndexByte(function, '/')
+	if j := strings.IndexByte(function[i+1:], '.'); j != -1 {
+		return function[:i+1+j]
+	}
+	return function
 }
@@ -63,6 +63,66 @@ func TestPrintln(t *testing.T) {
 	}
 }
 
+// -------------------------------------------------------------------------------------------------
+// test printf
+type printfCircuit struct {
+	A, B frontend.Variable
+	C    [3]frontend.Variable
+}
+
+func (circuit *printfCircuit) Define(api frontend.API) error {
+	c := api.Add(circuit.A, circuit.B)
+	api.Printf("a+b = %d (%#x), 100%%", c, c)
+	api.Printf("b = %b, -1 = %d", circuit.B, -1)
+	api.Printf("%s %s", "circuit", circuit)
+	api.Printf("c = %02x", circuit.C)
+	api.AssertIsDifferent(api.Add(circuit.C[0], circuit.C[1], circuit.C[2]), 0)
+	bs := api.ToBinary(circuit.B, 10)
+	nb := api.Mul(bs[1], 2)
+	api.AssertIsBoolean(nb) // this will fail
+	m := api.Mul(circuit.A, circuit.B)
+	api.Printf("m = %x", m) // this may not be resolved
+	return nil
+}
+
+func TestPrintf(t *testing.T) {
+	assert := require.New(t)
+
+	var circuit, witness printfCircuit
+	witness.A = 2
+	witness.B = 11
+	witness.C = [3]frontend.Variable{1, 10, 255}
+
+	minusOne := new(big.Int).Sub(ecc.BN254.Info().Fr.Modulus(), big.NewInt(1)).String()
+
+	var expected bytes.Buffer
+	expected.WriteString("debug_test.go:75 > a\\+b = 13 \\(0xd\\), 100% arg0=13 arg1=13\n")
+	expected.WriteString("debug_test.go:76 > b = 1011, -1 = " + minusOne + " arg0=11 arg1=" + minusOne + "\n")
+	expected.WriteString("debug_test.go:77 > circuit {A: 2, B: 11, C_0: 1, C_1: 10, C_2: 255} arg1_A=2 arg1_B=11 arg1_C_0=1 arg1_C_1=10 arg1_C_2=255\n")
+	expected.WriteString("debug_test.go:78 > c = \\[01 0a ff\\] arg0_0=1 arg0_1=10 arg0_2=255\n")
+
+	// the test engine stops at the failing assertion
+	{
+		var buf bytes.Buffer
+		log := zerolog.New(&zerolog.ConsoleWriter{Out: &buf, NoColor: true, PartsExclude: []string{zerolog.LevelFieldName, zerolog.TimestampFieldName}})
+		err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.GROTH16, backend.WithCircuitLogger(log))
+		assert.Error(err)
+		assert.Regexp("^"+expected.String()+"$", buf.String())
+	}
+
+	expected.WriteString("debug_test.go:84 > m = .* arg0=.*\n")
+
+	{
+		trace, _ := getGroth16Trace(&circuit, &witness)
+		assert.Regexp("^"+expected.String()+"$", trace)
+	}
+
+	{
+		trace, _ := getPlonkTrace(&circuit, &witness)
+		assert.Regexp("^"+expected.String()+"$", trace)
+	}
+}
+
 // -------------------------------------------------------------------------------------------------
 // Div by 0
 type divBy0Trace struct {
@@ -0,0 +1,266 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analysis provides static checks on compiled constraint systems.
+//
+// Underconstrained looks for the internal wires whose value is not pinned down
+// by the inputs of the circuit: a prover could then pick another value for
+// such a wire (typically a hint output which is not fully constrained) and
+// still satisfy all the constraints.
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+	bls12377r1cs "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	bls12381r1cs "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	bls24315r1cs "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+	bw6633r1cs "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	bw6761r1cs "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+)
+
+// Wire is an internal wire of a constraint system whose value is not
+// determined by the inputs.
+type Wire struct {
+	ID         int    // wire ID: public wires, then secret wires, then internal wires
+	Hint       bool   // the wire is an output of a hint
+	Constraint int    // first constraint referencing the wire, -1 if none
+	Debug      string // stack of the call creating the wire, if known
+}
+
+func (w Wire) String() string {
+	var sbb strings.Builder
+	if w.Hint {
+		sbb.WriteString("hint output ")
+	} else {
+		sbb.WriteString("internal variable ")
+	}
+	sbb.WriteString(wireName(w.ID))
+	if w.Constraint == -1 {
+		sbb.WriteString(" is not referenced by any constraint")
+	} else {
+		fmt.Fprintf(&sbb, " (constraint #%d) is not determined by the inputs", w.Constraint)
+	}
+	if w.Debug != "" {
+		sbb.WriteByte('\n')
+		sbb.WriteString(w.Debug)
+	}
+	return sbb.String()
+}
+
+// Underconstrained returns the internal wires of ccs which are not uniquely
+// determined by its public and secret inputs, sorted by ID.
+//
+// The analysis propagates the determined wires through the constraints, in
+// order. A constraint in which the undetermined wires are not multiplied
+// together is a linear relation between them, and the relations are combined
+// to determine the wires they pin down, as the outputs of a hint checked by a
+// multiplication. A linear combination of wires constrained to be boolean is
+// determined if it is a binary decomposition. A decomposition on as many bits
+// as the modulus has two solutions for the small values; the range check
+// bounding it (see api.ToBinary) is not verified and such decompositions are
+// considered determined.
+//
+// The coefficients of the relations are computed from random values of the
+// determined wires: the analysis reports the wires which are free for generic
+// inputs, not those which are free for some values of the inputs only (such
+// as the inverse of a zero in api.IsZero). It is not complete either: a
+// reported wire may be determined by constraints the propagation does not
+// combine, such as several quadratic constraints, or the bits of the
+// comparison to a bound which is not a constant in api.AssertIsLessOrEqual.
+func Underconstrained(ccs frontend.CompiledConstraintSystem) ([]Wire, error) {
+	a, err := newAnalyzerOf(ccs)
+	if err != nil {
+		return nil, err
+	}
+	a.run()
+	return a.report(), nil
+}
+
+// Describe returns the description of the given wires of ccs: whether they are
+// hint outputs, the first constraint referencing them and the debug info of the
+// call creating them.
+//
+// The debug info of the hint calls is only recorded when ccs is compiled with
+// frontend.WithHintDebugInfo; otherwise the debug info of the first constraint
+// referencing a hint output is reported.
+func Describe(ccs frontend.CompiledConstraintSystem, wireIDs ...int) ([]Wire, error) {
+	a, err := newAnalyzerOf(ccs)
+	if err != nil {
+		return nil, err
+	}
+	for cID := 0; cID < a.nbConstraints; cID++ {
+		a.index(cID)
+	}
+	res := make([]Wire, len(wireIDs))
+	for i, wID := range wireIDs {
+		if wID < 0 || wID >= len(a.determined) {
+			return nil, fmt.Errorf("wire %d out of range", wID)
+		}
+		res[i] = a.describe(wID)
+	}
+	return res, nil
+}
+
+// newAnalyzerOf returns an analyzer of a constraint system, if its curve and
+// representation are supported
+func newAnalyzerOf(ccs frontend.CompiledConstraintSystem) (*analyzer, error) {
+	var a *analyzer
+	switch c := ccs.(type) {
+	case *bls12377r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bls12381r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bn254r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bw6761r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bls24315r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bw6633r1cs.R1CS:
+		a = newR1CSAnalyzer(&c.R1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bls12377r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bls12381r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bn254r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bw6761r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bls24315r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	case *bw6633r1cs.SparseR1CS:
+		a = newSparseR1CSAnalyzer(&c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }))
+	default:
+		return nil, errors.New("unsupported constraint system")
+	}
+	return a, nil
+}
+
+// coefficients returns the n coefficients of a constraint system, in regular
+// form
+func coefficients(n int, get func(i int, v *big.Int)) []big.Int {
+	res := make([]big.Int, n)
+	for i := range res {
+		get(i, &res[i])
+	}
+	return res
+}
+
+// report returns the internal wires which are not determined
+func (a *analyzer) report() []Wire {
+	var res []Wire
+	for wID := a.nbInputs; wID < len(a.determined); wID++ {
+		if !a.determined[wID] {
+			res = append(res, a.describe(wID))
+		}
+	}
+	return res
+}
+
+// describe returns the description of a wire, once the occurrences are indexed
+func (a *analyzer) describe(wID int) Wire {
+	w := Wire{ID: wID, Constraint: -1}
+	_, w.Hint = a.cs.MHints[wID]
+
+	// the constraints referencing the wire are recorded in order, before
+	// the linear definitions
+	for _, item := range a.occurrences[wID] {
+		if item >= a.nbConstraints {
+			break
+		}
+		if w.Constraint == -1 {
+			w.Constraint = item
+		}
+		if dID, ok := a.cs.MDebug[item]; ok && w.Debug == "" {
+			w.Debug = a.format(a.cs.DebugInfo[dID])
+		}
+	}
+	if dID, ok := a.cs.MHintsDebug[wID]; ok {
+		w.Debug = a.format(a.cs.DebugInfo[dID])
+	}
+	return w
+}
+
+// format returns the text of a debug info entry, in which the wires are
+// replaced by their names
+func (a *analyzer) format(entry compiled.LogEntry) string {
+	var args []interface{}
+	var eval strings.Builder
+	isEval := false
+	for _, t := range entry.ToResolve {
+		if t == compiled.TermDelimitor {
+			if isEval {
+				args = append(args, eval.String())
+			}
+			eval.Reset()
+			isEval = !isEval
+			continue
+		}
+		cID, vID, visibility := t.Unpack()
+		if isEval {
+			if eval.Len() > 0 {
+				eval.WriteString(" + ")
+			}
+			switch {
+			case visibility == schema.Virtual:
+				eval.WriteString(a.coeffs[cID].String())
+			case cID == compiled.CoeffIdOne:
+				eval.WriteString(a.name(vID, visibility))
+			default:
+				eval.WriteString(a.coeffs[cID].String())
+				eval.WriteByte('*')
+				eval.WriteString(a.name(vID, visibility))
+			}
+			continue
+		}
+		if visibility == schema.Virtual {
+			if cID == compiled.CoeffIdMinusOne {
+				args = append(args, "-1")
+			} else {
+				args = append(args, a.coeffs[cID].String())
+			}
+			continue
+		}
+		if !(cID == compiled.CoeffIdMinusOne || cID == compiled.CoeffIdOne) {
+			args = append(args, a.coeffs[cID].String())
+		}
+		args = append(args, a.name(vID, visibility))
+	}
+	return fmt.Sprintf(entry.Format, args...)
+}
+
+// name returns the name of an input, or the name of an internal wire
+func (a *analyzer) name(wireID int, visibility schema.Visibility) string {
+	switch visibility {
+	case schema.Public:
+		return a.cs.Public[wireID]
+	case schema.Secret:
+		return a.cs.Secret[wireID-a.cs.NbPublicVariables]
+	}
+	return wireName(wireID)
+}
+
+func wireName(wireID int) string {
+	return fmt.Sprintf("v%d", wireID)
+}
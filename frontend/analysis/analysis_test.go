@@ -0,0 +1,100 @@
+package analysis_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/analysis"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+// sqrtHint returns a square root of its input, then zeros
+func sqrtHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	q := curveID.Info().Fr.Modulus()
+	if outputs[0].ModSqrt(inputs[0], q) == nil {
+		outputs[0].SetUint64(0)
+	}
+	for i := 1; i < len(outputs); i++ {
+		outputs[i].SetUint64(0)
+	}
+	return nil
+}
+
+type soundCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *soundCircuit) Define(api frontend.API) error {
+	bits := api.ToBinary(c.X, 8)
+	x := api.FromBinary(bits[:4]...)
+	q := api.Div(x, c.Y)
+	isZero := api.IsZero(c.X)
+	cmp := api.Cmp(c.X, c.Y)
+	s := api.Select(bits[7], api.Inverse(c.Y), api.Mul(c.Y, c.Y))
+	api.AssertIsEqual(api.Add(q, isZero, cmp, s), c.Z)
+	return nil
+}
+
+type underconstrainedCircuit struct {
+	X frontend.Variable
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (c *underconstrainedCircuit) Define(api frontend.API) error {
+	r, err := api.Compiler().NewHint(sqrtHint, 3, c.X)
+	if err != nil {
+		return err
+	}
+	// the sign of the square root is free, the second output is a free
+	// boolean and the third one is not constrained at all
+	api.AssertIsEqual(api.Mul(r[0], r[0]), c.X)
+	api.AssertIsBoolean(r[1])
+	api.AssertIsEqual(api.Add(r[0], r[1], c.X), c.Z)
+	return nil
+}
+
+func TestUnderconstrained(t *testing.T) {
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &soundCircuit{})
+		require.NoError(t, err)
+		wires, err := analysis.Underconstrained(ccs)
+		require.NoError(t, err)
+		require.Empty(t, wires)
+
+		ccs, err = frontend.Compile(ecc.BN254, newBuilder, &underconstrainedCircuit{}, frontend.IgnoreUnconstrainedInputs(), frontend.WithHintDebugInfo())
+		require.NoError(t, err)
+		wires, err = analysis.Underconstrained(ccs)
+		require.NoError(t, err)
+		// the sums of the scs builder depend on the hint outputs as well
+		require.GreaterOrEqual(t, len(wires), 3)
+		for _, w := range wires[3:] {
+			require.False(t, w.Hint)
+		}
+		wires = wires[:3]
+
+		require.NotEqual(t, -1, wires[0].Constraint)
+		require.NotEqual(t, -1, wires[1].Constraint)
+		require.Equal(t, -1, wires[2].Constraint)
+		for _, w := range wires {
+			require.True(t, w.Hint)
+			require.True(t, strings.HasPrefix(w.Debug, "[hint] "), w.Debug)
+			require.Contains(t, w.Debug, "(*underconstrainedCircuit).Define")
+			require.Contains(t, w.Debug, "analysis_test.go")
+		}
+
+		// without WithHintDebugInfo, the hint calls aren't recorded
+		ccs, err = frontend.Compile(ecc.BN254, newBuilder, &underconstrainedCircuit{}, frontend.IgnoreUnconstrainedInputs())
+		require.NoError(t, err)
+		wires, err = analysis.Underconstrained(ccs)
+		require.NoError(t, err)
+		for _, w := range wires {
+			require.False(t, strings.HasPrefix(w.Debug, "[hint] "), w.Debug)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"math/big"
+)
+
+const (
+	// maxBooleans is the maximum number of boolean wires of a constraint whose
+	// values are enumerated to bound the values of another wire.
+	maxBooleans = 4
+
+	// maxInlinedSize is the maximum number of wires of a linear expression in
+	// which the linear definitions are inlined, and maxInlinedDepth the
+	// maximum number of nested definitions.
+	maxInlinedSize  = 16
+	maxInlinedDepth = 4
+)
+
+// affine is a linear expression over the wires, plus a constant
+type affine struct {
+	constant big.Int
+	rel      relation
+}
+
+// addLinearDefinition records the definition of the last wire created by a
+// linear constraint L·R = O, if it is an internal wire which is not a hint
+// output: the builders compute the result of the linear operations this way.
+func (a *analyzer) addLinearDefinition(cID int, l, r, o *expression, created []int) {
+	if len(l.wires) != 0 && len(r.wires) != 0 {
+		return
+	}
+
+	// f·e - o == 0
+	f, e := l, r
+	if len(l.wires) != 0 {
+		f, e = r, l
+	}
+	var factor, c big.Int
+	factor.Mod(&f.constant, a.q)
+	def := &affine{rel: make(relation)}
+	def.constant.Mul(&factor, &e.constant).Sub(&def.constant, &o.constant)
+	for i, wID := range e.wires {
+		def.rel.add(wID, c.Mul(&factor, e.coeffs[i]), a.q)
+	}
+	for i, wID := range o.wires {
+		def.rel.add(wID, c.Neg(o.coeffs[i]), a.q)
+	}
+
+	pivot := -1
+	for _, wID := range created {
+		if _, isHint := a.cs.MHints[wID]; wID >= a.nbInputs && !isHint && def.rel[wID] != nil && wID > pivot {
+			pivot = wID
+		}
+	}
+	if pivot == -1 || len(def.rel) > maxInlinedSize {
+		return
+	}
+
+	// pivot = -(def - c·pivot) / c
+	var inv big.Int
+	inv.ModInverse(def.rel[pivot], a.q).Neg(&inv)
+	delete(def.rel, pivot)
+	for _, c := range def.rel {
+		c.Mul(c, &inv).Mod(c, a.q)
+	}
+	def.constant.Mul(&def.constant, &inv).Mod(&def.constant, a.q)
+	a.linear[pivot] = def
+	a.linearOf[cID] = pivot
+}
+
+// inline returns e in which the wires computed by a linear constraint are
+// replaced by their definition, unless they are boolean. It returns nil if the
+// result grows too large.
+func (a *analyzer) inline(e *expression) *affine {
+	res := &affine{rel: make(relation)}
+	res.constant.Mod(&e.constant, a.q)
+	for i, wID := range e.wires {
+		res.rel.add(wID, e.coeffs[i], a.q)
+	}
+
+	for n := 0; n < maxInlinedSize; n++ {
+		// the last defined wires first, their definitions reference the
+		// previous ones
+		wires := res.rel.wires()
+		pivot := -1
+		for i := len(wires) - 1; i >= 0; i-- {
+			_, isBoolean := a.scales[wires[i]]
+			if _, ok := a.linear[wires[i]]; ok && !isBoolean {
+				pivot = wires[i]
+				break
+			}
+		}
+		if pivot == -1 {
+			break
+		}
+
+		def := a.linear[pivot]
+		c := res.rel[pivot]
+		delete(res.rel, pivot)
+		var v big.Int
+		for wID, d := range def.rel {
+			res.rel.add(wID, v.Mul(c, d), a.q)
+		}
+		res.constant.Add(&res.constant, v.Mul(c, &def.constant)).Mod(&res.constant, a.q)
+		if len(res.rel) > maxInlinedSize {
+			return nil
+		}
+	}
+	return res
+}
+
+// markBooleans records the wires whose values are 0 or a constant scale; a
+// wire whose only value is 0 has a zero scale, and is determined. A
+// constraint is processed again when one of its wires, or a wire of the linear
+// definition of one of its wires, is marked.
+func (a *analyzer) markBooleans() {
+	for cID := 0; cID < a.nbConstraints; cID++ {
+		a.push(cID)
+	}
+	for len(a.queue) != 0 {
+		cID := a.queue[0]
+		a.queue = a.queue[1:]
+		a.queued[cID] = false
+		a.markBoolean(cID)
+	}
+}
+
+// markBoolean records the scale of the only wire w of a constraint which is not
+// boolean, if the constraint bounds its values to 0 and a non-zero scale for
+// all the values of the boolean wires
+func (a *analyzer) markBoolean(cID int) {
+	l, r, o := a.expand(cID)
+	var es [3]*affine
+	for i, e := range []*expression{&l, &r, &o} {
+		if es[i] = a.inline(e); es[i] == nil {
+			return
+		}
+	}
+
+	wID := -1
+	var booleans []int
+	for _, e := range es {
+		for w := range e.rel {
+			if _, ok := a.scales[w]; !ok {
+				if wID != -1 && wID != w {
+					return
+				}
+				wID = w
+				continue
+			}
+			found := false
+			for _, b := range booleans {
+				found = found || b == w
+			}
+			if !found {
+				booleans = append(booleans, w)
+			}
+		}
+	}
+	if wID == -1 || len(booleans) > maxBooleans {
+		return
+	}
+
+	var scale *big.Int
+	satisfiable := false
+	for mask := 0; mask < 1<<len(booleans); mask++ {
+		// each side is e1·w + e0
+		var e0, e1 [3]big.Int
+		var v big.Int
+		for i, e := range es {
+			e0[i].Set(&e.constant)
+			for j, b := range booleans {
+				if c, ok := e.rel[b]; ok && (mask>>j)&1 == 1 {
+					e0[i].Add(&e0[i], v.Mul(c, a.scales[b]))
+				}
+			}
+			if c, ok := e.rel[wID]; ok {
+				e1[i].Set(c)
+			}
+		}
+
+		// A·w² + B·w + C == 0
+		var A, B, C, root big.Int
+		A.Mul(&e1[0], &e1[1]).Mod(&A, a.q)
+		B.Mul(&e1[0], &e0[1]).Add(&B, v.Mul(&e0[0], &e1[1])).Sub(&B, &e1[2]).Mod(&B, a.q)
+		C.Mul(&e0[0], &e0[1]).Sub(&C, &e0[2]).Mod(&C, a.q)
+		switch {
+		case A.Sign() != 0:
+			if C.Sign() != 0 {
+				return
+			}
+			root.ModInverse(&A, a.q).Mul(&root, &B).Neg(&root).Mod(&root, a.q)
+		case B.Sign() != 0:
+			root.ModInverse(&B, a.q).Mul(&root, &C).Neg(&root).Mod(&root, a.q)
+		case C.Sign() == 0:
+			// w is free
+			return
+		default:
+			// the values of the boolean wires are not consistent
+			continue
+		}
+
+		satisfiable = true
+		if root.Sign() == 0 {
+			continue
+		}
+		if scale == nil {
+			scale = new(big.Int).Set(&root)
+		} else if scale.Cmp(&root) != 0 {
+			return
+		}
+	}
+	if !satisfiable {
+		return
+	}
+	if scale == nil {
+		// the only value of the wire is 0
+		scale = new(big.Int)
+		a.determined[wID] = true
+	}
+
+	a.scales[wID] = scale
+	a.pushOccurrences(wID, 0)
+}
+
+// pushOccurrences queues the constraints referencing wID, and the ones
+// referencing the wires defined from it
+func (a *analyzer) pushOccurrences(wID int, depth int) {
+	for _, cID := range a.occurrences[wID] {
+		a.push(cID)
+		if w, ok := a.linearOf[cID]; ok && w != wID && depth < maxInlinedDepth {
+			a.pushOccurrences(w, depth+1)
+		}
+	}
+}
@@ -0,0 +1,504 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// maxRelationSize is the maximum number of wires of the relation of a
+// constraint, once combined with the definitions. Larger relations are not
+// recorded, the constraint is processed again when one of its wires is
+// determined.
+const maxRelationSize = 512
+
+// expression is a linear expression over the wires of a constraint system
+type expression struct {
+	constant big.Int
+	wires    []int
+	coeffs   []*big.Int
+}
+
+// relation is a linear relation between the undetermined wires, which holds
+// for some (unknown) constant value of the sum. The coefficients are reduced
+// mod q and not zero.
+type relation map[int]*big.Int
+
+// definition is a relation in which pivot is not a wire of the other
+// definitions: the relations are combined to eliminate it. The relation is
+// nil once the definition is replaced.
+type definition struct {
+	pivot int
+	rel   relation
+}
+
+// analyzer propagates the determined wires through the constraints, written
+// L·R = O. The items of the work queue are the constraints, then the
+// definitions.
+//
+// The determined wires are given random values (the scale of the boolean
+// ones): a factor L or R of a constraint is evaluated, so that the constraint
+// is a linear relation between its undetermined wires for generic inputs.
+type analyzer struct {
+	cs            *compiled.ConstraintSystem
+	coeffs        []big.Int
+	q             *big.Int
+	bitLen        int
+	nbInputs      int
+	nbConstraints int
+	hasOneWire    bool // the public wire 0 is the constant 1 (R1CS)
+	expand        func(cID int) (l, r, o expression)
+
+	determined  []bool
+	linearized  []bool    // constraints whose relation is held by the definitions
+	values      []big.Int // random values of the determined wires
+	rand        *rand.Rand
+	scales      map[int]*big.Int // wire constrained to be 0 or scale
+	linear      map[int]*affine  // internal wire computed by a linear constraint
+	linearOf    map[int]int      // wire computed by each linear constraint
+	defs        []definition
+	defOf       map[int]int // definition of each pivot
+	occurrences [][]int     // constraints and definitions referencing each wire
+
+	queue  []int
+	queued []bool
+	cursor int // last constraint processed in order
+}
+
+func newAnalyzer(cs *compiled.ConstraintSystem, coeffs []big.Int, nbConstraints int) *analyzer {
+	nbInputs := cs.NbPublicVariables + cs.NbSecretVariables
+	nbWires := nbInputs + cs.NbInternalVariables
+	a := &analyzer{
+		cs:            cs,
+		coeffs:        coeffs,
+		q:             cs.CurveID.Info().Fr.Modulus(),
+		nbInputs:      nbInputs,
+		nbConstraints: nbConstraints,
+		determined:    make([]bool, nbWires),
+		linearized:    make([]bool, nbConstraints),
+		values:        make([]big.Int, nbWires),
+		rand:          rand.New(rand.NewSource(1)), //#nosec G404 the values only need to be generic
+		scales:        make(map[int]*big.Int),
+		linear:        make(map[int]*affine),
+		linearOf:      make(map[int]int),
+		defOf:         make(map[int]int),
+		occurrences:   make([][]int, nbWires),
+		queued:        make([]bool, nbConstraints),
+		cursor:        nbConstraints,
+	}
+	a.bitLen = a.q.BitLen()
+	for i := 0; i < nbInputs; i++ {
+		a.determined[i] = true
+		a.values[i].Rand(a.rand, a.q)
+	}
+	return a
+}
+
+func newR1CSAnalyzer(cs *compiled.R1CS, coeffs []big.Int) *analyzer {
+	a := newAnalyzer(&cs.ConstraintSystem, coeffs, len(cs.Constraints))
+	a.hasOneWire = true
+	a.expand = func(cID int) (l, r, o expression) {
+		c := &cs.Constraints[cID]
+		a.addLinearExpression(&l, c.L, false)
+		a.addLinearExpression(&r, c.R, false)
+		a.addLinearExpression(&o, c.O, false)
+		return
+	}
+	return a
+}
+
+// newSparseR1CSAnalyzer returns an analyzer of the constraints
+// qM·l·r + qL·l + qR·r + qO·o + qK == 0, written (qM·l)·r = -(qL·l + qR·r + qO·o + qK)
+func newSparseR1CSAnalyzer(cs *compiled.SparseR1CS, coeffs []big.Int) *analyzer {
+	a := newAnalyzer(&cs.ConstraintSystem, coeffs, len(cs.Constraints))
+	a.expand = func(cID int) (l, r, o expression) {
+		c := &cs.Constraints[cID]
+		var qM big.Int
+		qM.Mul(&a.coeffs[c.M[0].CoeffID()], &a.coeffs[c.M[1].CoeffID()]).Mod(&qM, a.q)
+		if qM.Sign() != 0 {
+			a.addLinearExpression(&l, compiled.LinearExpression{c.M[0]}, false)
+			a.addLinearExpression(&r, compiled.LinearExpression{c.M[1]}, false)
+		}
+		a.addLinearExpression(&o, compiled.LinearExpression{c.L, c.R, c.O}, true)
+		o.constant.Neg(&a.coeffs[c.K])
+		return
+	}
+	return a
+}
+
+// addLinearExpression adds (or subtracts) the terms of l to e. The one wire of
+// the R1CS is a constant.
+func (a *analyzer) addLinearExpression(e *expression, l compiled.LinearExpression, neg bool) {
+	for _, t := range l {
+		cID, vID, visibility := t.Unpack()
+		if cID == compiled.CoeffIdZero || visibility == schema.Unset {
+			continue
+		}
+		c := new(big.Int).Set(&a.coeffs[cID])
+		if neg {
+			c.Neg(c)
+		}
+		if visibility == schema.Virtual || (visibility == schema.Public && vID == 0 && a.hasOneWire) {
+			e.constant.Add(&e.constant, c)
+			continue
+		}
+		e.wires = append(e.wires, vID)
+		e.coeffs = append(e.coeffs, c)
+	}
+}
+
+// run propagates the determined wires until nothing changes
+func (a *analyzer) run() {
+	// the wires referenced by each constraint, and the internal wires computed
+	// by a linear constraint
+	for cID := 0; cID < a.nbConstraints; cID++ {
+		l, r, o, created := a.index(cID)
+		a.addLinearDefinition(cID, &l, &r, &o, created)
+	}
+	a.markBooleans()
+
+	// the constraints are processed in order, once the consequences of the
+	// previous ones are propagated: the relations of the constraints
+	// following a hint are not combined before its outputs are determined
+	for cID := 0; cID < a.nbConstraints; cID++ {
+		a.cursor = cID
+		a.process(cID)
+		for len(a.queue) != 0 {
+			item := a.queue[0]
+			a.queue = a.queue[1:]
+			a.queued[item] = false
+			a.process(item)
+		}
+	}
+}
+
+// index records the occurrences of the wires of a constraint, and returns its
+// expressions and the wires it references first
+func (a *analyzer) index(cID int) (l, r, o expression, created []int) {
+	l, r, o = a.expand(cID)
+	for _, e := range []*expression{&l, &r, &o} {
+		for _, wID := range e.wires {
+			occ := a.occurrences[wID]
+			if len(occ) == 0 {
+				created = append(created, wID)
+			}
+			if len(occ) == 0 || occ[len(occ)-1] != cID {
+				a.occurrences[wID] = append(occ, cID)
+			}
+		}
+	}
+	return
+}
+
+// push queues an item, unless it is a constraint which is not processed yet
+func (a *analyzer) push(item int) {
+	if item < a.nbConstraints && item > a.cursor {
+		return
+	}
+	if !a.queued[item] {
+		a.queued[item] = true
+		a.queue = append(a.queue, item)
+	}
+}
+
+// determine marks a wire as determined, and queues the constraints and the
+// definitions referencing it
+func (a *analyzer) determine(wID int) {
+	if a.determined[wID] {
+		return
+	}
+	a.determined[wID] = true
+	if scale, ok := a.scales[wID]; ok {
+		a.values[wID].Set(scale)
+	} else {
+		a.values[wID].Rand(a.rand, a.q)
+	}
+	for _, item := range a.occurrences[wID] {
+		a.push(item)
+	}
+}
+
+// process applies a constraint or a definition to the undetermined wires
+func (a *analyzer) process(item int) {
+	var rel relation
+	if item < a.nbConstraints {
+		if a.linearized[item] {
+			return
+		}
+		if rel = a.linearize(item); rel == nil {
+			return
+		}
+	} else {
+		// the definition is replaced by the relation between its remaining
+		// undetermined wires
+		def := &a.defs[item-a.nbConstraints]
+		if def.rel == nil {
+			return
+		}
+		rel = make(relation)
+		for wID, c := range def.rel {
+			if !a.determined[wID] {
+				rel[wID] = new(big.Int).Set(c)
+			}
+		}
+		def.rel = nil
+		delete(a.defOf, def.pivot)
+	}
+
+	rel = a.substitute(rel)
+	if rel == nil {
+		return
+	}
+	wires := rel.wires()
+
+	// the definitions hold the relation once it is applied, the constraint
+	// is not linearized again
+	applied := func() {
+		if item < a.nbConstraints {
+			a.linearized[item] = true
+		}
+	}
+	switch {
+	case len(wires) == 0:
+		applied()
+		return
+	case len(wires) == 1:
+		applied()
+		a.determine(wires[0])
+		return
+	case a.isBinaryDecomposition(rel, wires):
+		applied()
+		for _, wID := range wires {
+			a.determine(wID)
+		}
+		return
+	}
+
+	// the relation defines its last non-boolean wire, which is eliminated
+	// from the other relations
+	pivot := -1
+	for i := len(wires) - 1; i >= 0; i-- {
+		if _, ok := a.scales[wires[i]]; !ok {
+			pivot = wires[i]
+			break
+		}
+	}
+	if pivot == -1 {
+		return
+	}
+	applied()
+	defItem := a.nbConstraints + len(a.defs)
+	for _, item := range a.occurrences[pivot] {
+		if item >= a.nbConstraints {
+			a.eliminate(item, pivot, rel)
+		}
+	}
+	a.defOf[pivot] = len(a.defs)
+	a.defs = append(a.defs, definition{pivot: pivot, rel: rel})
+	a.queued = append(a.queued, false)
+	for _, wID := range wires {
+		a.occurrences[wID] = append(a.occurrences[wID], defItem)
+	}
+}
+
+// eliminate removes pivot from the definition item using rel, so that the
+// pivots of the definitions are not wires of the other ones.
+func (a *analyzer) eliminate(item, pivot int, rel relation) {
+	def := &a.defs[item-a.nbConstraints]
+	c, ok := def.rel[pivot]
+	if !ok {
+		return
+	}
+
+	// def -= def[pivot] / rel[pivot] · rel
+	var factor, v big.Int
+	factor.ModInverse(rel[pivot], a.q)
+	factor.Mul(&factor, c)
+	for wID, c := range rel {
+		if _, ok := def.rel[wID]; !ok {
+			a.occurrences[wID] = append(a.occurrences[wID], item)
+		}
+		def.rel.add(wID, v.Mul(&factor, c).Neg(&v), a.q)
+	}
+}
+
+// linearize returns the relation between the undetermined wires of a
+// constraint, or nil if they are multiplied together
+func (a *analyzer) linearize(cID int) relation {
+	l, r, o := a.expand(cID)
+	lUnknown, rUnknown := a.hasUnknown(&l), a.hasUnknown(&r)
+	if lUnknown && rUnknown {
+		return nil
+	}
+
+	rel := make(relation)
+	var c big.Int
+	addProduct := func(e, factor *expression) {
+		f := a.evaluate(factor)
+		if f.Sign() == 0 {
+			return
+		}
+		for i, wID := range e.wires {
+			if !a.determined[wID] {
+				rel.add(wID, c.Mul(e.coeffs[i], f), a.q)
+			}
+		}
+	}
+	if lUnknown {
+		addProduct(&l, &r)
+	}
+	if rUnknown {
+		addProduct(&r, &l)
+	}
+	for i, wID := range o.wires {
+		if !a.determined[wID] {
+			rel.add(wID, c.Neg(o.coeffs[i]), a.q)
+		}
+	}
+	return rel
+}
+
+// evaluate returns the value of an expression of determined wires
+func (a *analyzer) evaluate(e *expression) *big.Int {
+	res := new(big.Int).Set(&e.constant)
+	var v big.Int
+	for i, wID := range e.wires {
+		res.Add(res, v.Mul(e.coeffs[i], &a.values[wID]))
+	}
+	return res.Mod(res, a.q)
+}
+
+func (a *analyzer) hasUnknown(e *expression) bool {
+	for _, wID := range e.wires {
+		if !a.determined[wID] {
+			return true
+		}
+	}
+	return false
+}
+
+// substitute replaces in rel the wires having a definition, and removes the
+// determined wires. It returns nil if the relation grows too large.
+func (a *analyzer) substitute(rel relation) relation {
+	for wID := range rel {
+		if a.determined[wID] {
+			delete(rel, wID)
+		}
+	}
+
+	// the definitions do not reference the pivots of the other ones
+	for _, pivot := range rel.wires() {
+		if _, ok := a.defOf[pivot]; !ok {
+			continue
+		}
+
+		// rel -= rel[pivot] / def[pivot] · def
+		def := a.defs[a.defOf[pivot]].rel
+		var factor, v big.Int
+		factor.ModInverse(def[pivot], a.q)
+		factor.Mul(&factor, rel[pivot])
+		for wID, c := range def {
+			if !a.determined[wID] {
+				rel.add(wID, v.Mul(&factor, c).Neg(&v), a.q)
+			}
+		}
+		if len(rel) > maxRelationSize {
+			return nil
+		}
+	}
+	return rel
+}
+
+// isBinaryDecomposition returns true if the wires of rel are all booleans
+// (0 or scale), and their coefficients times their scales are a constant
+// times distinct powers of two, spanning at most the number of bits of q.
+func (a *analyzer) isBinaryDecomposition(rel relation, wires []int) bool {
+	var inv big.Int
+	exponents := make(map[int]struct{}, len(wires))
+	min, max := 0, 0
+	for i, wID := range wires {
+		scale, ok := a.scales[wID]
+		if !ok {
+			return false
+		}
+		var v big.Int
+		v.Mul(rel[wID], scale).Mod(&v, a.q)
+		if i == 0 {
+			inv.ModInverse(&v, a.q)
+		}
+		v.Mul(&v, &inv).Mod(&v, a.q)
+		e, ok := a.log2(&v)
+		if !ok {
+			return false
+		}
+		if _, ok := exponents[e]; ok {
+			return false
+		}
+		exponents[e] = struct{}{}
+		if e < min {
+			min = e
+		}
+		if e > max {
+			max = e
+		}
+	}
+	return max-min <= a.bitLen-1
+}
+
+// log2 returns e if v = 2ᵉ mod q, with |e| < bitLen(q)
+func (a *analyzer) log2(v *big.Int) (int, bool) {
+	isPowerOfTwo := func(v *big.Int) bool {
+		return v.Sign() > 0 && int(v.TrailingZeroBits()) == v.BitLen()-1
+	}
+	if isPowerOfTwo(v) {
+		return v.BitLen() - 1, true
+	}
+	var inv big.Int
+	inv.ModInverse(v, a.q)
+	if isPowerOfTwo(&inv) {
+		return 1 - inv.BitLen(), true
+	}
+	return 0, false
+}
+
+// add adds c to the coefficient of wID
+func (rel relation) add(wID int, c *big.Int, q *big.Int) {
+	v, ok := rel[wID]
+	if !ok {
+		v = new(big.Int)
+		rel[wID] = v
+	}
+	v.Add(v, c).Mod(v, q)
+	if v.Sign() == 0 {
+		delete(rel, wID)
+	}
+}
+
+// wires returns the wires of rel, sorted
+func (rel relation) wires() []int {
+	res := make([]int, 0, len(rel))
+	for wID := range rel {
+		res = append(res, wID)
+	}
+	sort.Ints(res)
+	return res
+}
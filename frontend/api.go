@@ -112,6 +112,17 @@ type API interface {
 	// whose value will be resolved at runtime when computed by the solver
 	Println(a ...Variable)
 
+	// Printf behaves like fmt.Printf, the values of the variables being
+	// resolved at runtime when computed by the solver. The supported verbs are
+	// %d, %x, %X, %b (bit decomposition) and %s or %v (decimal), with the
+	// flags and width of package fmt; an argument may be a struct or an array
+	// of variables, in which case each of its variables is formatted with the
+	// verb. When solving, the value of each variable is also logged as a
+	// structured field.
+	//
+	// Printf panics if the format is invalid or doesn't match the arguments.
+	Printf(format string, a ...Variable)
+
 	// Compiler returns the compiler object for advanced circuit development
 	Compiler() Compiler
 
@@ -39,6 +39,27 @@ type Compiler interface {
 	// If nbOutputs is specified, it must be >= 1 and <= f.NbOutputs
 	NewHint(f hint.Function, nbOutputs int, inputs ...Variable) ([]Variable, error)
 
+	// NewRichHint is like NewHint for a rich hint function. The constant
+	// parameters params are embedded in the constraint system and given to f at
+	// solving time in a hint.Context, with the modulus of the scalar field and
+	// the value set with backend.WithHintContext.
+	NewRichHint(f hint.RichFunction, nbOutputs int, params []hint.Param, inputs ...Variable) ([]Variable, error)
+
+	// NewVariableHint is like NewRichHint, but f sets at most maxOutputs
+	// outputs. The outputs f did not set are 0 and nbOutputs holds the number
+	// of outputs f set.
+	//
+	// As for the outputs, no constraint is added to nbOutputs.
+	NewVariableHint(f hint.RichFunction, maxOutputs int, params []hint.Param, inputs ...Variable) (outputs []Variable, nbOutputs Variable, err error)
+
+	// Label names the wire of v, for debugging: the name appears in the
+	// solution dumped with backend.WithSolutionDump. Label is a no-op if v is a
+	// constant or isn't a single wire (for example, a linear combination of
+	// variables in a R1CS). In a Component, the name is prefixed with the type
+	// of the component and the index of the call, as in
+	// "pkg.component#2/name".
+	Label(v Variable, name string)
+
 	// Tag creates a tag at a given place in a circuit. The state of the tag may contain informations needed to
 	// measure constraints, variables and coefficients creations through AddCounter
 	Tag(name string) Tag
@@ -58,6 +79,25 @@ type Compiler interface {
 
 	// Backend returns the backend.ID injected by the compiler
 	Backend() backend.ID
+
+	// Call returns the outputs of c.Define on inputs (see Component).
+	//
+	// The first time a component is called with a given number of inputs, its
+	// constraints are recorded in a template in which the inputs are
+	// parameters. This call and the following ones copy the template into the
+	// constraint system, with fresh internal variables, without running
+	// c.Define again. If an input is a constant, c.Define is called directly
+	// instead, so that the constant can be folded.
+	Call(c Component, inputs ...Variable) []Variable
+
+	// CallConcurrently returns the outputs of the calls, as Call would one
+	// after the other. The templates of the components which were not called
+	// yet are recorded concurrently, each in its own child builder, and then
+	// copied into the constraint system in the order of calls, so that the
+	// result does not depend on the scheduling of the goroutines.
+	//
+	// The Define methods of the components must be safe for concurrent use.
+	CallConcurrently(calls ...ComponentCall) [][]Variable
 }
 
 // Builder represents a constraint system builder
@@ -75,6 +115,11 @@ type Builder interface {
 	// called inside circuit.Define()
 	AddPublicVariable(name string) Variable
 
+	// AddOutputVariable is called by the compiler when parsing the circuit schema. It creates
+	// a public Variable whose value is computed by the solver. It panics if called inside
+	// circuit.Define()
+	AddOutputVariable(name string) Variable
+
 	// AddSecretVariable is called by the compiler when parsing the circuit schema. It panics if
 	// called inside circuit.Define()
 	AddSecretVariable(name string) Variable
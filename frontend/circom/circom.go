@@ -0,0 +1,171 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package circom reads the circuits compiled by circom and their witnesses.
+//
+// ReadR1CS reads a .r1cs file into a BN254 R1CS, and ReadWitness (.wtns) or
+// ReadWitnessJSON (snarkjs wtns export json) read the witness computed by the
+// circom witness calculator. The public outputs, then the public inputs of
+// the circom circuit are the public variables of the R1CS; its private inputs
+// and its other wires are secret variables, all provided by the witness. The
+// constraint system and the witness can be used as is by backend/groth16:
+//
+//	ccs, _ := circom.ReadR1CS(r1csFile)
+//	w, _ := circom.ReadWitness(wtnsFile, ccs)
+//	publicWitness, _ := w.Public()
+//	pk, vk, _ := groth16.Setup(ccs)
+//	proof, _ := groth16.Prove(ccs, pk, w)
+//	err := groth16.Verify(proof, vk, publicWitness)
+package circom
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/csio"
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// .wtns format
+const (
+	wtnsMagic = "wtns"
+
+	sectionHeader  = 1
+	sectionWitness = 2
+)
+
+var errNotBN254 = errors.New("circom circuits are only supported on BN254")
+
+// ReadR1CS reads a circuit in the iden3 .r1cs format (see csio.ReadR1CS),
+// which must be defined on the scalar field of BN254
+func ReadR1CS(r io.Reader) (frontend.CompiledConstraintSystem, error) {
+	ccs, err := csio.ReadR1CS(r)
+	if err != nil {
+		return nil, err
+	}
+	if ccs.CurveID() != ecc.BN254 {
+		return nil, errNotBN254
+	}
+	return ccs, nil
+}
+
+// ReadWitness reads the full witness of ccs from a .wtns file, as written by
+// the circom witness calculator
+func ReadWitness(r io.Reader, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != wtnsMagic {
+		return nil, errors.New("invalid .wtns file: bad magic number")
+	}
+	// data[4:8] is the version, 1 and 2 share the same layout
+	nbSections := binary.LittleEndian.Uint32(data[8:12])
+	data = data[12:]
+
+	sections := make(map[uint32][]byte)
+	for i := uint32(0); i < nbSections; i++ {
+		if len(data) < 12 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		id := binary.LittleEndian.Uint32(data[:4])
+		size := binary.LittleEndian.Uint64(data[4:12])
+		data = data[12:]
+		if size > uint64(len(data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		sections[id] = data[:size]
+		data = data[size:]
+	}
+
+	header, ok := sections[sectionHeader]
+	if !ok || len(header) < 4 {
+		return nil, errors.New("invalid .wtns file: missing header")
+	}
+	n8 := int(binary.LittleEndian.Uint32(header[:4]))
+	if len(header) < 8+n8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if modulus := fromLE(header[4 : 4+n8]); modulus.Cmp(fr.Modulus()) != 0 {
+		return nil, errNotBN254
+	}
+	nbWires := int(binary.LittleEndian.Uint32(header[4+n8 : 8+n8]))
+
+	content, ok := sections[sectionWitness]
+	if !ok || len(content) != nbWires*n8 {
+		return nil, errors.New("invalid .wtns file: missing or truncated witness")
+	}
+	values := make([]*big.Int, nbWires)
+	for i := range values {
+		values[i] = fromLE(content[i*n8 : (i+1)*n8])
+	}
+	return newWitness(values, ccs)
+}
+
+// ReadWitnessJSON reads the full witness of ccs from the JSON list of decimal
+// values written by snarkjs wtns export json
+func ReadWitnessJSON(r io.Reader, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	var s []string
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	values := make([]*big.Int, len(s))
+	for i := range s {
+		var ok bool
+		if values[i], ok = new(big.Int).SetString(s[i], 10); !ok {
+			return nil, fmt.Errorf("invalid witness value %q", s[i])
+		}
+	}
+	return newWitness(values, ccs)
+}
+
+// newWitness returns the witness of ccs whose wires have the given values,
+// the constant wire 0 first
+func newWitness(values []*big.Int, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	if ccs.CurveID() != ecc.BN254 {
+		return nil, errNotBN254
+	}
+	internal, secret, public := ccs.GetNbVariables()
+	if internal != 0 || len(values) != public+secret {
+		return nil, fmt.Errorf("%w: got %d wires, expected %d", witness.ErrInvalidWitness, len(values), public+secret)
+	}
+	if values[0].Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("%w: wire 0 must be 1", witness.ErrInvalidWitness)
+	}
+
+	v := make(bn254witness.Witness, len(values)-1)
+	for i := range v {
+		v[i].SetBigInt(values[i+1])
+	}
+	return &witness.Witness{CurveID: ecc.BN254, Vector: &v, Schema: ccs.GetSchema()}, nil
+}
+
+// fromLE returns the integer of little endian representation b
+func fromLE(b []byte) *big.Int {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(r)
+}
@@ -0,0 +1,119 @@
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+	"github.com/stretchr/testify/require"
+)
+
+// file returns a .r1cs or .wtns file with the given sections
+func file(magic string, version uint32, sections ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	binary.Write(&buf, binary.LittleEndian, version)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(sections)))
+	for i, s := range sections {
+		binary.Write(&buf, binary.LittleEndian, uint32(i+1))
+		binary.Write(&buf, binary.LittleEndian, uint64(len(s)))
+		buf.Write(s)
+	}
+	return buf.Bytes()
+}
+
+// element returns the 32 bytes little endian representation of v
+func element(v *big.Int) []byte {
+	b := make([]byte, 32)
+	v.FillBytes(b)
+	for i := 0; i < 16; i++ {
+		b[i], b[31-i] = b[31-i], b[i]
+	}
+	return b
+}
+
+// multiplier is the circuit out <== in * prv, with an intermediate wire
+// w <== prv + 1 constrained by out * 1 === in * (w - 1)
+func multiplier() []byte {
+	var header, constraints bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(32))
+	header.Write(element(fr.Modulus()))
+	for _, v := range []uint32{5, 1, 1, 1} { // wires, outputs, public inputs, private inputs
+		binary.Write(&header, binary.LittleEndian, v)
+	}
+	binary.Write(&header, binary.LittleEndian, uint64(5))
+	binary.Write(&header, binary.LittleEndian, uint32(3))
+
+	minusOne := new(big.Int).Sub(fr.Modulus(), big.NewInt(1))
+	type term struct {
+		wire  uint32
+		coeff *big.Int
+	}
+	for _, c := range [][3][]term{
+		{{{2, big.NewInt(1)}}, {{3, big.NewInt(1)}}, {{1, big.NewInt(1)}}},                     // in * prv = out
+		{{{0, big.NewInt(1)}}, {{3, big.NewInt(1)}, {0, big.NewInt(1)}}, {{4, big.NewInt(1)}}}, // 1 * (prv + 1) = w
+		{{{2, big.NewInt(1)}}, {{4, big.NewInt(1)}, {0, minusOne}}, {{1, big.NewInt(1)}}},      // in * (w - 1) = out
+	} {
+		for _, l := range c {
+			binary.Write(&constraints, binary.LittleEndian, uint32(len(l)))
+			for _, t := range l {
+				binary.Write(&constraints, binary.LittleEndian, t.wire)
+				constraints.Write(element(t.coeff))
+			}
+		}
+	}
+	return file("r1cs", 1, header.Bytes(), constraints.Bytes())
+}
+
+// wtns returns the .wtns file of the given values
+func wtns(values ...int64) []byte {
+	var header, content bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(32))
+	header.Write(element(fr.Modulus()))
+	binary.Write(&header, binary.LittleEndian, uint32(len(values)))
+	for _, v := range values {
+		content.Write(element(big.NewInt(v)))
+	}
+	return file("wtns", 2, header.Bytes(), content.Bytes())
+}
+
+func TestProve(t *testing.T) {
+	ccs, err := ReadR1CS(bytes.NewReader(multiplier()))
+	require.NoError(t, err)
+	internal, secret, public := ccs.GetNbVariables()
+	require.Equal(t, []int{0, 2, 3}, []int{internal, secret, public})
+
+	// one, out, in, prv, w
+	w, err := ReadWitness(bytes.NewReader(wtns(1, 6, 2, 3, 4)), ccs)
+	require.NoError(t, err)
+	wJSON, err := ReadWitnessJSON(strings.NewReader(`["1", "6", "2", "3", "4"]`), ccs)
+	require.NoError(t, err)
+	require.Equal(t, w, wJSON)
+
+	publicWitness, err := w.Public()
+	require.NoError(t, err)
+	var six, two fr.Element
+	six.SetUint64(6)
+	two.SetUint64(2)
+	require.Equal(t, &bn254witness.Witness{six, two}, publicWitness.Vector)
+
+	pk, vk, err := groth16.Setup(ccs)
+	require.NoError(t, err)
+	proof, err := groth16.Prove(ccs, pk, w)
+	require.NoError(t, err)
+	require.NoError(t, groth16.Verify(proof, vk, publicWitness))
+
+	// wrong intermediate wire
+	w, err = ReadWitness(bytes.NewReader(wtns(1, 6, 2, 3, 5)), ccs)
+	require.NoError(t, err)
+	require.Error(t, ccs.IsSolved(w))
+
+	// wrong number of wires
+	_, err = ReadWitnessJSON(strings.NewReader(`["1", "6", "2", "3"]`), ccs)
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package frontend
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -87,6 +88,8 @@ func parseCircuit(builder Builder, circuit Circuit) (err error) {
 				tInput.Set(reflect.ValueOf(builder.AddSecretVariable(name)))
 			case schema.Public:
 				tInput.Set(reflect.ValueOf(builder.AddPublicVariable(name)))
+			case schema.Output:
+				tInput.Set(reflect.ValueOf(builder.AddOutputVariable(name)))
 			case schema.Unset:
 				return errors.New("can't set val " + name + " visibility is unset")
 			}
@@ -125,6 +128,15 @@ type CompileOption func(opt *CompileConfig) error
 type CompileConfig struct {
 	Capacity                  int
 	IgnoreUnconstrainedInputs bool
+	Optimizations             Optimization
+
+	NoCommonSubexpressionElimination bool
+
+	Profile io.Writer
+
+	HintDebugInfo bool
+
+	NbTasks int // defaults to runtime.NumCPU(), see WithNbTasks
 }
 
 // WithCapacity is a compile option that specifies the estimated capacity needed
@@ -151,6 +163,130 @@ func IgnoreUnconstrainedInputs() CompileOption {
 	}
 }
 
+// WithoutCommonSubexpressionElimination is a compile option which disables the
+// reuse of the wires of identical operations. By default, the builders record
+// the result of the operations emitting constraints (api.Mul, api.IsZero,
+// api.ToBinary, ...) and return it when the same operation is called again on
+// the same operands.
+//
+// This option is mostly useful to compare constraint counts, or to debug the
+// builders.
+func WithoutCommonSubexpressionElimination() CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.NoCommonSubexpressionElimination = true
+		return nil
+	}
+}
+
+// WithProfile is a compile option which records the call stack of every
+// constraint and internal variable added while the circuit is defined, and
+// writes them to w as a pprof profile when the constraint system is built.
+//
+// The profile can be explored with go tool pprof; its samples are the
+// constraints and the variables, for example:
+//
+//	go tool pprof -sample_index=constraints -top circuit.pprof
+//
+// The counts are taken before the optimizer passes (see WithOptimizations).
+// Recording the call stacks makes the compilation noticeably slower.
+func WithProfile(w io.Writer) CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.Profile = w
+		return nil
+	}
+}
+
+// WithHintDebugInfo is a compile option which records the call stack of every
+// hint call in the debug info of the constraint system, for the outputs of the
+// hint. frontend/analysis reports it for the hint outputs it finds
+// underconstrained; without this option, it reports the stack of the first
+// constraint referencing them.
+//
+// Recording the call stacks makes the compilation slower, and the constraint
+// system larger, for circuits calling many hints (such as api.ToBinary).
+func WithHintDebugInfo() CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.HintDebugInfo = true
+		return nil
+	}
+}
+
+// WithNbTasks is a compile option which sets the number of tasks the builders
+// split their post-processing in (checking the unconstrained inputs, building
+// the levels of the solver, defining the components given to
+// CallConcurrently). It defaults to runtime.NumCPU().
+func WithNbTasks(nbTasks int) CompileOption {
+	return func(opt *CompileConfig) error {
+		if nbTasks < 1 {
+			return fmt.Errorf("invalid number of tasks %d", nbTasks)
+		}
+		opt.NbTasks = nbTasks
+		return nil
+	}
+}
+
+// Optimization is a set of passes run by the constraint system optimizer on a
+// compiled circuit, before the backend constraint system is built.
+type Optimization uint8
+
+const (
+	// ConstantPropagation removes the constraints which are satisfied by
+	// constants only and replaces the internal variables they fix to a
+	// constant.
+	ConstantPropagation Optimization = 1 << iota
+
+	// LinearSubstitution replaces the internal variables defined by a linear
+	// constraint by their definition in the rest of the constraint system.
+	LinearSubstitution
+
+	// DuplicateConstraints removes the constraints which appear more than once.
+	DuplicateConstraints
+
+	// DeadConstraintElimination removes the constraints defining internal
+	// variables which are not used anywhere else.
+	DeadConstraintElimination
+
+	// AllOptimizations enables all the passes of the optimizer.
+	AllOptimizations = ConstantPropagation | LinearSubstitution | DuplicateConstraints | DeadConstraintElimination
+)
+
+func (o Optimization) String() string {
+	switch o {
+	case ConstantPropagation:
+		return "constant propagation"
+	case LinearSubstitution:
+		return "linear substitution"
+	case DuplicateConstraints:
+		return "duplicate constraints"
+	case DeadConstraintElimination:
+		return "dead constraint elimination"
+	}
+	return fmt.Sprintf("optimizations(%d)", uint8(o))
+}
+
+// WithOptimizations is a compile option which runs the given passes of the
+// constraint system optimizer on the compiled circuit. If no pass is given,
+// all of them are run.
+//
+// Inputs, hint outputs and variables referenced by api.Println or debug
+// information are never removed; the optimizer only rewrites the internal
+// variables the solver can recompute.
+func WithOptimizations(passes ...Optimization) CompileOption {
+	return func(opt *CompileConfig) error {
+		if len(passes) == 0 {
+			opt.Optimizations = AllOptimizations
+			return nil
+		}
+		for _, p := range passes {
+			if p&^AllOptimizations != 0 {
+				return fmt.Errorf("unknown optimization %d", p)
+			}
+			opt.Optimizations |= p
+		}
+		return nil
+	}
+}
+
 var tVariable reflect.Type
 
 func init() {
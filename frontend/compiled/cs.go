@@ -26,6 +26,9 @@ type ConstraintSystem struct {
 	// input wires names
 	Public, Secret []string
 
+	// public wires computed by the solver (see schema.Output)
+	Outputs []int
+
 	// logs (added with cs.Println, resolved when solver sets a value to a wire)
 	Logs []LogEntry
 
@@ -41,6 +44,10 @@ type ConstraintSystem struct {
 
 	MHints             map[int]*Hint      // maps wireID to hint
 	MHintsDependencies map[hint.ID]string // maps hintID to hint string identifier
+	MHintsDebug        map[int]int        // maps hint output wireID to the debugInfo of the hint call
+
+	// maps wireID to the name given with frontend.Compiler.Label
+	MLabels map[int]string
 
 	// each level contains independent constraints and can be parallelized
 	// it is guaranteed that all dependncies for constraints in a level l are solved
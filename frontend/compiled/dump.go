@@ -0,0 +1,103 @@
+package compiled
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// dumpedWire is a wire of a solution dump (see backend.WithSolutionDump)
+type dumpedWire struct {
+	Wire       int     `json:"wire"`
+	Name       string  `json:"name,omitempty"`
+	Visibility string  `json:"visibility"`
+	Value      *string `json:"value"`      // decimal; nil if the solver didn't reach the wire
+	Constraint *int    `json:"constraint"` // constraint which solved the wire; nil for the inputs
+}
+
+// WireName returns the name of the input wire wID or the label set on wID with
+// frontend.Compiler.Label, if any.
+func (cs *ConstraintSystem) WireName(wID int) string {
+	if name, ok := cs.MLabels[wID]; ok {
+		return name
+	}
+	if wID < cs.NbPublicVariables {
+		return cs.Public[wID]
+	}
+	if wID < cs.NbPublicVariables+cs.NbSecretVariables {
+		return cs.Secret[wID-cs.NbPublicVariables]
+	}
+	return ""
+}
+
+// WriteSolution writes a solution of the constraint system to w in the given
+// format (see backend.WithSolutionDump). values[i] is the value of the wire i,
+// nil if the solver didn't reach it, and solvedBy[i] the constraint which
+// solved it, if any.
+func (cs *ConstraintSystem) WriteSolution(w io.Writer, format backend.DumpFormat, values []*big.Int, solvedBy []int) error {
+	if len(values) != len(solvedBy) {
+		return errors.New("invalid solution size")
+	}
+
+	outputs := make(map[int]bool, len(cs.Outputs))
+	for _, wID := range cs.Outputs {
+		outputs[wID] = true
+	}
+	nbInputs := cs.NbPublicVariables + cs.NbSecretVariables
+
+	wires := make([]dumpedWire, len(values))
+	for i := range wires {
+		wires[i] = dumpedWire{Wire: i, Name: cs.WireName(i), Visibility: schema.Internal.String()}
+		switch {
+		case outputs[i]:
+			wires[i].Visibility = schema.Output.String()
+		case i < cs.NbPublicVariables:
+			wires[i].Visibility = schema.Public.String()
+		case i < nbInputs:
+			wires[i].Visibility = schema.Secret.String()
+		}
+		if values[i] == nil {
+			continue
+		}
+		v := values[i].String()
+		wires[i].Value = &v
+		if i >= nbInputs || outputs[i] {
+			c := solvedBy[i]
+			wires[i].Constraint = &c
+		}
+	}
+
+	switch format {
+	case backend.DumpJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(wires)
+	case backend.DumpCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"wire", "name", "visibility", "value", "constraint"}); err != nil {
+			return err
+		}
+		for _, wire := range wires {
+			record := []string{strconv.Itoa(wire.Wire), wire.Name, wire.Visibility, "", ""}
+			if wire.Value != nil {
+				record[3] = *wire.Value
+			}
+			if wire.Constraint != nil {
+				record[4] = strconv.Itoa(*wire.Constraint)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return errors.New("unknown solution dump format")
+	}
+}
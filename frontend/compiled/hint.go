@@ -1,6 +1,7 @@
 package compiled
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -16,6 +17,19 @@ type Hint struct {
 	ID     hint.ID       // hint function id
 	Inputs []interface{} // terms to inject in the hint function
 	Wires  []int         // IDs of wires the hint outputs map to
+
+	Rich            bool         `cbor:",omitempty"` // ID refers to a hint.RichFunction
+	Params          []hint.Param `cbor:",omitempty"` // constant parameters given to a hint.RichFunction
+	VariableOutputs bool         `cbor:",omitempty"` // the last wire of Wires is set to the actual number of outputs
+}
+
+// Outputs returns the wires the outputs of the hint function map to; if
+// VariableOutputs is set, it excludes the wire holding the number of outputs.
+func (h *Hint) Outputs() []int {
+	if h.VariableOutputs {
+		return h.Wires[:len(h.Wires)-1]
+	}
+	return h.Wires
 }
 
 func (h Hint) inputsCBORTags() (cbor.TagSet, error) {
@@ -65,7 +79,7 @@ func (h Hint) MarshalCBOR() ([]byte, error) {
 			inputs[i] = h.Inputs[i]
 		}
 	}
-	v := vt{ID: h.ID, Inputs: inputs, Wires: h.Wires}
+	v := vt{ID: h.ID, Inputs: inputs, Wires: h.Wires, Rich: h.Rich, Params: h.Params, VariableOutputs: h.VariableOutputs}
 	return enc.Marshal(v)
 }
 
@@ -80,9 +94,12 @@ func (h *Hint) UnmarshalCBOR(b []byte) error {
 	}
 	// v of type vt is Hint but does not implement cbor.Marshaler
 	type vt struct {
-		ID     hint.ID
-		Inputs []cbor.RawTag
-		Wires  []int
+		ID              hint.ID
+		Inputs          []cbor.RawTag
+		Wires           []int
+		Rich            bool
+		Params          []hint.Param
+		VariableOutputs bool
 	}
 	var v vt
 	if err := dec.Unmarshal(b, &v); err != nil {
@@ -126,5 +143,11 @@ func (h *Hint) UnmarshalCBOR(b []byte) error {
 	h.ID = v.ID
 	h.Inputs = inputs
 	h.Wires = v.Wires
+	h.Rich = v.Rich
+	h.Params = v.Params
+	h.VariableOutputs = v.VariableOutputs
+	if h.VariableOutputs && len(h.Wires) == 0 {
+		return errors.New("hint with variable outputs has no wires")
+	}
 	return nil
 }
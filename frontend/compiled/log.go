@@ -27,6 +27,11 @@ type LogEntry struct {
 	Caller    string
 	Format    string
 	ToResolve []Term
+
+	// Fields names the delimited linear expressions of ToResolve of an entry
+	// created with api.Printf; their values are formatted as *big.Int and
+	// logged as structured fields.
+	Fields []string
 }
 
 func (l *LogEntry) WriteVariable(le LinearExpression, sbb *strings.Builder) {
@@ -0,0 +1,154 @@
+package compiled
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// PrintfToken is an element of the format of api.Printf once expanded by
+// ExpandPrintf: either literal text or a variable formatted with a directive.
+type PrintfToken struct {
+	Text      string      // literal text, escaped for fmt, if Directive is empty
+	Directive string      // directive formatting the variable as a *big.Int, such as "%x"
+	Name      string      // name of the variable, used as structured log field
+	Value     interface{} // variable or constant
+}
+
+// printfVerbs are the verbs supported by api.Printf
+const printfVerbs = "dxXbsv"
+
+// ExpandPrintf parses the format of api.Printf and expands the arguments: an
+// argument which is a struct or an array of variables (of type tLeaf) gets a
+// directive for each of its variables, an argument of type string is written
+// as text and any other argument is a variable. The i-th argument is named
+// "arg<i>" and the variables of a struct or an array "arg<i>_<name>", with the
+// names of schema.Parse.
+//
+// The verbs d, x, X, b (bit decomposition), s and v are supported, with the
+// flags and width of package fmt; s and v print the decimal value.
+func ExpandPrintf(format string, args []interface{}, tLeaf reflect.Type) ([]PrintfToken, error) {
+	var tokens []PrintfToken
+	var text strings.Builder
+	flushText := func() {
+		if text.Len() != 0 {
+			tokens = append(tokens, PrintfToken{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	nbArgs := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			text.WriteByte(format[i])
+			continue
+		}
+		// flags, width and verb
+		j := i + 1
+		for j < len(format) && strings.IndexByte("#0+- 123456789", format[j]) != -1 {
+			j++
+		}
+		if j == len(format) {
+			return nil, errors.New("printf: missing verb at the end of the format")
+		}
+		if format[j] == '%' && j == i+1 {
+			text.WriteString("%%")
+			i = j
+			continue
+		}
+		directive, verb := format[i:j+1], format[j]
+		if strings.IndexByte(printfVerbs, verb) == -1 {
+			return nil, fmt.Errorf("printf: unsupported verb %%%c", verb)
+		}
+		i = j
+
+		if nbArgs == len(args) {
+			return nil, fmt.Errorf("printf: missing argument for %s", directive)
+		}
+		arg, name := args[nbArgs], "arg"+strconv.Itoa(nbArgs)
+		nbArgs++
+
+		if arg == nil {
+			return nil, fmt.Errorf("printf: %s is nil", name)
+		}
+		if s, ok := arg.(string); ok {
+			if verb != 's' && verb != 'v' {
+				return nil, fmt.Errorf("printf: %s is a string, formatted with %s", name, directive)
+			}
+			text.WriteString(escapePrintf(fmt.Sprintf(directive, s)))
+			continue
+		}
+
+		leaves, isArray := printfLeaves(arg, tLeaf)
+		if len(leaves) == 0 {
+			flushText()
+			tokens = append(tokens, PrintfToken{Directive: directive, Name: name, Value: arg})
+			continue
+		}
+		if isArray {
+			text.WriteByte('[')
+		} else {
+			text.WriteByte('{')
+		}
+		for k, leaf := range leaves {
+			if k > 0 && isArray {
+				text.WriteByte(' ')
+			} else if k > 0 {
+				text.WriteString(", ")
+			}
+			if !isArray {
+				text.WriteString(escapePrintf(leaf.Name))
+				text.WriteString(": ")
+			}
+			flushText()
+			tokens = append(tokens, PrintfToken{Directive: directive, Name: name + "_" + leaf.Name, Value: leaf.Value})
+		}
+		if isArray {
+			text.WriteByte(']')
+		} else {
+			text.WriteByte('}')
+		}
+	}
+	if nbArgs != len(args) {
+		return nil, fmt.Errorf("printf: %d arguments for %d directives", len(args), nbArgs)
+	}
+	flushText()
+
+	return tokens, nil
+}
+
+// printfLeaves returns the variables of a struct or an array, and whether a is
+// an array or a slice
+func printfLeaves(a interface{}, tLeaf reflect.Type) (leaves []PrintfToken, isArray bool) {
+	v := reflect.ValueOf(a)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array:
+		// schema.Parse skips the values which aren't addressable
+		c := reflect.New(v.Type())
+		c.Elem().Set(v)
+		a = c.Interface()
+	case reflect.Slice:
+	default:
+		return nil, false
+	}
+
+	collect := func(_ schema.Visibility, name string, tValue reflect.Value) error {
+		leaves = append(leaves, PrintfToken{Name: name, Value: tValue.Interface()})
+		return nil
+	}
+	// ignoring error, collect() always return nil
+	_, _ = schema.Parse(a, tLeaf, collect)
+
+	return leaves, v.Kind() != reflect.Struct
+}
+
+func escapePrintf(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frontend
+
+// Component is a sub-circuit which is compiled once and instantiated many
+// times through Compiler.Call. For example:
+//
+//	type pathCheck struct{ depth int }
+//
+//	func (p pathCheck) Define(api frontend.API, inputs ...frontend.Variable) []frontend.Variable {
+//		// inputs[0] is the leaf, inputs[1:] the path...
+//	}
+//
+//	root := api.Compiler().Call(pathCheck{depth: 10}, leaf, path...)[0]
+//
+// Components are map keys: two components share their template if they are
+// equal with ==, so a Component must be of a comparable type, and all the
+// parameters changing the constraints it defines must be part of its value.
+//
+// Define must define the same constraints for any input variables: it must
+// only depend on its inputs and on the value of the component, and must not
+// inspect the inputs with ConstantValue or IsBoolean. The debug information
+// recorded in Define refers to the stack of the first call.
+type Component interface {
+	Define(api API, inputs ...Variable) []Variable
+}
+
+// ComponentCall is a call of a Component on its inputs, see
+// Compiler.CallConcurrently.
+type ComponentCall struct {
+	Component Component
+	Inputs    []Variable
+}
@@ -0,0 +1,152 @@
+package cs_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+// isZeroSum returns (x+y == 0) and x*y, with a hint and a log
+type isZeroSum struct {
+	nbDefine *int
+}
+
+func (c isZeroSum) Define(api frontend.API, inputs ...frontend.Variable) []frontend.Variable {
+	if c.nbDefine != nil {
+		*c.nbDefine++
+	}
+	s := api.Add(inputs[0], inputs[1])
+	api.Println(s, inputs[0])
+	return []frontend.Variable{api.IsZero(s), api.Mul(inputs[0], inputs[1], 3)}
+}
+
+type componentCircuit struct {
+	X      [4]frontend.Variable
+	Y      frontend.Variable `gnark:",public"`
+	inline bool
+	sum    isZeroSum
+}
+
+func (circuit *componentCircuit) Define(api frontend.API) error {
+	call := func(inputs ...frontend.Variable) []frontend.Variable {
+		if circuit.inline {
+			return circuit.sum.Define(api, inputs...)
+		}
+		return api.Compiler().Call(circuit.sum, inputs...)
+	}
+	acc := frontend.Variable(0)
+	for i := 1; i < len(circuit.X); i++ {
+		res := call(api.Sub(circuit.X[i-1], 1), api.Mul(circuit.X[i], 2))
+		api.AssertIsBoolean(res[0])
+		acc = api.Add(acc, res[0], res[1])
+	}
+	// constant inputs are not stamped
+	res := call(2, -2)
+	api.AssertIsEqual(api.Add(acc, res[0]), circuit.Y)
+	return nil
+}
+
+func TestComponent(t *testing.T) {
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		nbDefine := 0
+		inline, err := frontend.Compile(ecc.BN254, newBuilder, &componentCircuit{inline: true})
+		require.NoError(t, err)
+		stamped, err := frontend.Compile(ecc.BN254, newBuilder, &componentCircuit{sum: isZeroSum{&nbDefine}})
+		require.NoError(t, err)
+
+		// the component is defined once for the variable inputs, and once
+		// inline for the constant ones
+		require.Equal(t, 2, nbDefine)
+		require.Equal(t, inline.GetNbConstraints(), stamped.GetNbConstraints())
+		iInternal, iSecret, iPublic := inline.GetNbVariables()
+		sInternal, sSecret, sPublic := stamped.GetNbVariables()
+		require.Equal(t, iInternal, sInternal)
+		require.Equal(t, iSecret, sSecret)
+		require.Equal(t, iPublic, sPublic)
+	}
+
+	// (x0-1) + 2x1 == 0 for the first call only
+	assert := test.NewAssert(t)
+	valid := componentCircuit{
+		X: [4]frontend.Variable{3, -1, 5, 7},
+		Y: 1 + 3*(2*-2) + 3*(-2*10) + 3*(4*14) + 1,
+	}
+	invalid := valid
+	invalid.Y = 0
+	assert.ProverSucceeded(&componentCircuit{}, &valid, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16, backend.PLONK))
+	assert.ProverFailed(&componentCircuit{}, &invalid, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16, backend.PLONK))
+}
+
+// polyEval returns the evaluation of a polynomial with degree+1 coefficients
+type polyEval struct {
+	degree int
+}
+
+func (c polyEval) Define(api frontend.API, inputs ...frontend.Variable) []frontend.Variable {
+	x, coeffs := inputs[0], inputs[1:]
+	res := coeffs[c.degree]
+	for i := c.degree - 1; i >= 0; i-- {
+		res = api.Add(api.Mul(res, x), coeffs[i])
+	}
+	return []frontend.Variable{res, api.IsZero(res)}
+}
+
+type concurrentCircuit struct {
+	X          frontend.Variable
+	Coeffs     [8]frontend.Variable
+	Y          frontend.Variable `gnark:",public"`
+	sequential bool
+}
+
+func (circuit *concurrentCircuit) Define(api frontend.API) error {
+	var calls []frontend.ComponentCall
+	for degree := 1; degree < len(circuit.Coeffs); degree++ {
+		inputs := append([]frontend.Variable{circuit.X}, circuit.Coeffs[:degree+1]...)
+		calls = append(calls, frontend.ComponentCall{Component: polyEval{degree}, Inputs: inputs})
+		// a constant input, and a component called twice
+		inputs = append([]frontend.Variable{degree}, circuit.Coeffs[:degree+1]...)
+		calls = append(calls, frontend.ComponentCall{Component: polyEval{degree}, Inputs: inputs})
+		calls = append(calls, frontend.ComponentCall{Component: polyEval{1}, Inputs: []frontend.Variable{circuit.Coeffs[degree], circuit.X, circuit.Y}})
+	}
+
+	var results [][]frontend.Variable
+	if circuit.sequential {
+		for _, call := range calls {
+			results = append(results, api.Compiler().Call(call.Component, call.Inputs...))
+		}
+	} else {
+		results = api.Compiler().CallConcurrently(calls...)
+	}
+
+	acc := frontend.Variable(0)
+	for _, res := range results {
+		acc = api.Add(acc, res[0], res[1])
+	}
+	api.AssertIsDifferent(acc, circuit.Y)
+	return nil
+}
+
+func TestCallConcurrently(t *testing.T) {
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		sequential, err := frontend.Compile(ecc.BN254, newBuilder, &concurrentCircuit{sequential: true})
+		require.NoError(t, err)
+		concurrent, err := frontend.Compile(ecc.BN254, newBuilder, &concurrentCircuit{})
+		require.NoError(t, err)
+
+		// the templates are stamped in the order of the calls
+		require.Equal(t, sequential, concurrent)
+	}
+
+	assert := test.NewAssert(t)
+	assert.ProverSucceeded(&concurrentCircuit{}, &concurrentCircuit{
+		X:      2,
+		Coeffs: [8]frontend.Variable{1, 2, 3, 4, 5, 6, 7, 8},
+		Y:      42,
+	}, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16, backend.PLONK))
+}
@@ -0,0 +1,147 @@
+package cs_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type labelCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *labelCircuit) Define(api frontend.API) error {
+	square := api.Mul(circuit.X, circuit.X)
+	api.Compiler().Label(square, "square")
+	api.Compiler().Label(3, "constant") // no-op
+	api.AssertIsEqual(api.Mul(square, circuit.X), circuit.Y)
+	return nil
+}
+
+type dumpedWire struct {
+	Wire       int     `json:"wire"`
+	Name       string  `json:"name"`
+	Visibility string  `json:"visibility"`
+	Value      *string `json:"value"`
+	Constraint *int    `json:"constraint"`
+}
+
+func TestSolutionDump(t *testing.T) {
+	assert := require.New(t)
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &labelCircuit{})
+		assert.NoError(err)
+
+		find := func(wires []dumpedWire, name string) dumpedWire {
+			for _, w := range wires {
+				if w.Name == name {
+					return w
+				}
+			}
+			t.Fatal("wire not found", name)
+			return dumpedWire{}
+		}
+
+		// a valid witness: every wire is dumped
+		var buf bytes.Buffer
+		w, err := frontend.NewWitness(&labelCircuit{X: 3, Y: 27}, ecc.BN254)
+		assert.NoError(err)
+		assert.NoError(ccs.IsSolved(w, backend.WithSolutionDump(&buf, backend.DumpJSON)))
+
+		var wires []dumpedWire
+		assert.NoError(json.Unmarshal(buf.Bytes(), &wires))
+		internal, secret, public := ccs.GetNbVariables()
+		assert.Equal(internal+secret+public, len(wires))
+		x, y, square := find(wires, "X"), find(wires, "Y"), find(wires, "square")
+		assert.Equal("secret", x.Visibility)
+		assert.Equal("3", *x.Value)
+		assert.Nil(x.Constraint, "inputs aren't solved by a constraint")
+		assert.Equal("public", y.Visibility)
+		assert.Equal("27", *y.Value)
+		assert.Equal("internal", square.Visibility)
+		assert.Equal("9", *square.Value)
+		assert.NotNil(square.Constraint)
+		for _, w := range wires {
+			assert.NotNil(w.Value, "wire %d", w.Wire)
+		}
+
+		// an invalid witness: the dump is partial but holds the square
+		buf.Reset()
+		w, err = frontend.NewWitness(&labelCircuit{X: 3, Y: 28}, ecc.BN254)
+		assert.NoError(err)
+		assert.Error(ccs.IsSolved(w, backend.WithSolutionDump(&buf, backend.DumpCSV)))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(err)
+		assert.Equal([]string{"wire", "name", "visibility", "value", "constraint"}, records[0])
+		found := false
+		for _, r := range records[1:] {
+			if r[1] == "square" {
+				found = true
+				assert.Equal("9", r[3])
+				assert.NotEmpty(r[4])
+			}
+		}
+		assert.True(found, "the labeled wire is dumped")
+	}
+
+	// unknown format
+	assert.Error(backend.WithSolutionDump(nil, backend.DumpFormat(42))(&backend.ProverConfig{}))
+}
+
+// labeledCube returns the cube of its input, and labels its square
+type labeledCube struct{}
+
+func (labeledCube) Define(api frontend.API, inputs ...frontend.Variable) []frontend.Variable {
+	square := api.Mul(inputs[0], inputs[0])
+	api.Compiler().Label(square, "square")
+	return []frontend.Variable{api.Mul(square, inputs[0])}
+}
+
+type componentLabelCircuit struct {
+	X [2]frontend.Variable
+}
+
+func (circuit *componentLabelCircuit) Define(api frontend.API) error {
+	for _, x := range circuit.X {
+		api.AssertIsDifferent(api.Compiler().Call(labeledCube{}, x)[0], 0)
+	}
+	return nil
+}
+
+func TestComponentLabels(t *testing.T) {
+	assert := require.New(t)
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &componentLabelCircuit{})
+		assert.NoError(err)
+
+		var buf bytes.Buffer
+		w, err := frontend.NewWitness(&componentLabelCircuit{X: [2]frontend.Variable{2, 3}}, ecc.BN254)
+		assert.NoError(err)
+		assert.NoError(ccs.IsSolved(w, backend.WithSolutionDump(&buf, backend.DumpJSON)))
+
+		var wires []dumpedWire
+		assert.NoError(json.Unmarshal(buf.Bytes(), &wires))
+		values := make(map[string]string)
+		for _, w := range wires {
+			if w.Name != "" {
+				values[w.Name] = *w.Value
+			}
+		}
+		// each instance of the component has its own label
+		assert.Equal("4", values["cs_test.labeledCube#0/square"])
+		assert.Equal("9", values["cs_test.labeledCube#1/square"])
+		assert.NotContains(values, "square")
+	}
+}
@@ -0,0 +1,132 @@
+package cs
+
+import (
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+)
+
+// Op identifies an operation of the frontend.API which emits constraints, and
+// whose result can be shared by identical calls.
+type Op uint8
+
+const (
+	OpMul Op = iota
+	OpAdd
+	OpDiv
+	OpDivUnchecked
+	OpInverse
+	OpIsZero
+	OpToBinary
+	OpXor
+	OpOr
+)
+
+// commutative returns true if the result of op doesn't depend on the order of
+// its operands.
+func (op Op) commutative() bool {
+	switch op {
+	case OpMul, OpAdd, OpXor, OpOr:
+		return true
+	}
+	return false
+}
+
+// ExpressionCache records the results of the operations of a builder, keyed on
+// their operands, so that identical operations return the already allocated
+// wires instead of emitting new constraints (hash-consing).
+//
+// The operands must be canonical (reduced linear expressions, sorted by
+// visibility and variable ID); the cache doesn't look into them further, two
+// operands equal up to a reordering of their terms are different keys.
+type ExpressionCache struct {
+	entries map[uint64][]cacheEntry
+}
+
+type cacheEntry struct {
+	op       Op
+	n        int
+	operands []compiled.LinearExpression
+	result   []frontend.Variable
+}
+
+func NewExpressionCache() ExpressionCache {
+	return ExpressionCache{
+		entries: make(map[uint64][]cacheEntry),
+	}
+}
+
+// Lookup returns the result of a previous call to op on the same operands.
+// n is an operation specific parameter (the number of bits for OpToBinary, 0
+// otherwise). The linear expressions in the result are copies.
+func (c *ExpressionCache) Lookup(op Op, n int, operands ...compiled.LinearExpression) ([]frontend.Variable, bool) {
+	operands = c.order(op, operands)
+	for _, e := range c.entries[c.key(op, n, operands)] {
+		if e.op != op || e.n != n || len(e.operands) != len(operands) {
+			continue
+		}
+		found := true
+		for i := range operands {
+			if !e.operands[i].Equal(operands[i]) {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		res := make([]frontend.Variable, len(e.result))
+		for i, v := range e.result {
+			if l, ok := v.(compiled.LinearExpression); ok {
+				v = l.Clone()
+			}
+			res[i] = v
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// Store records result as the result of op on operands (see Lookup).
+func (c *ExpressionCache) Store(result []frontend.Variable, op Op, n int, operands ...compiled.LinearExpression) {
+	operands = c.order(op, operands)
+	e := cacheEntry{
+		op:       op,
+		n:        n,
+		operands: make([]compiled.LinearExpression, len(operands)),
+		result:   make([]frontend.Variable, len(result)),
+	}
+	for i := range operands {
+		e.operands[i] = operands[i].Clone()
+	}
+	for i, v := range result {
+		if l, ok := v.(compiled.LinearExpression); ok {
+			v = l.Clone()
+		}
+		e.result[i] = v
+	}
+	key := c.key(op, n, operands)
+	c.entries[key] = append(c.entries[key], e)
+}
+
+// order returns the operands of a commutative operation sorted by hash code.
+// Operands with the same hash code may end up in any order, which at worst
+// misses a cache hit.
+func (c *ExpressionCache) order(op Op, operands []compiled.LinearExpression) []compiled.LinearExpression {
+	if !op.commutative() || sort.SliceIsSorted(operands, func(i, j int) bool { return operands[i].HashCode() < operands[j].HashCode() }) {
+		return operands
+	}
+	res := make([]compiled.LinearExpression, len(operands))
+	copy(res, operands)
+	sort.Slice(res, func(i, j int) bool { return res[i].HashCode() < res[j].HashCode() })
+	return res
+}
+
+func (c *ExpressionCache) key(op Op, n int, operands []compiled.LinearExpression) uint64 {
+	h := uint64(op)*23 + uint64(n)
+	for _, l := range operands {
+		h = h*23 + l.HashCode()
+	}
+	return h
+}
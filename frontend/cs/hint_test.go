@@ -0,0 +1,91 @@
+package cs_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func migratedHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Mul(inputs[0], inputs[0])
+	return nil
+}
+
+type migratedHintCircuit struct {
+	X, Y frontend.Variable
+}
+
+func (circuit *migratedHintCircuit) Define(api frontend.API) error {
+	res, err := api.NewHint(migratedHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], api.Mul(circuit.X, circuit.X))
+	api.AssertIsEqual(res[0], circuit.Y)
+	return nil
+}
+
+func TestNamedHintMigration(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&migratedHintCircuit{X: 3, Y: 9}, ecc.BN254)
+	assert.NoError(err)
+
+	// compiled while the hint is identified by its runtime name
+	hint.Register(migratedHint)
+	legacyR1CS, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &migratedHintCircuit{})
+	assert.NoError(err)
+	legacySCS, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &migratedHintCircuit{})
+	assert.NoError(err)
+
+	hint.RegisterNamed("gnark/test/migratedHint", 1, migratedHint)
+
+	namedR1CS, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &migratedHintCircuit{})
+	assert.NoError(err)
+	namedSCS, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &migratedHintCircuit{})
+	assert.NoError(err)
+
+	for _, ccs := range []frontend.CompiledConstraintSystem{legacyR1CS, legacySCS, namedR1CS, namedSCS} {
+		assert.NoError(ccs.IsSolved(w))
+	}
+}
+
+// contextHint returns the per-proof value of the hint context
+func contextHint(ctx *hint.Context, inputs []*big.Int, outputs []*big.Int) (int, error) {
+	v, ok := ctx.Value.(*big.Int)
+	if !ok {
+		return 0, fmt.Errorf("unexpected hint context %v", ctx.Value)
+	}
+	outputs[0].Mod(v, ctx.Modulus)
+	return 1, nil
+}
+
+type hintContextCircuit struct {
+	X frontend.Variable `gnark:",public"`
+}
+
+func (circuit *hintContextCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewRichHint(contextHint, 1, nil)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], circuit.X)
+	return nil
+}
+
+func TestHintContext(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	opts := []backend.ProverOption{backend.WithRichHints(contextHint), backend.WithHintContext(big.NewInt(42))}
+	assert.ProverSucceeded(&hintContextCircuit{}, &hintContextCircuit{X: 42}, test.WithCurves(ecc.BN254), test.WithProverOpts(opts...))
+	assert.ProverFailed(&hintContextCircuit{}, &hintContextCircuit{X: 41}, test.WithCurves(ecc.BN254), test.WithProverOpts(opts...))
+}
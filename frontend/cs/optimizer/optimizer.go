@@ -0,0 +1,443 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package optimizer rewrites compiled constraint systems to reduce their number
+// of constraints and internal variables.
+//
+// The passes (see frontend.Optimization) are run by the builders when the
+// frontend.WithOptimizations compile option is set, on the compiled.R1CS or
+// compiled.SparseR1CS, before the levels and the backend constraint system
+// are built.
+//
+// Only internal variables which the solver can recompute are removed; inputs
+// and hint outputs are kept. Logs and debug information referencing a
+// substituted variable evaluate its definition instead, and the dead constraint
+// elimination keeps the variables they reference. The remaining internal
+// variables are then renumbered, and MHints, MHintsDebug, MLabels, MDebug,
+// Logs and DebugInfo are updated accordingly; the label of a removed variable
+// is dropped.
+package optimizer
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// maxSubstitutionSize is the maximum number of terms of a linear expression
+// substituted for an internal variable. Larger definitions would make the
+// constraints referencing the variable grow more than what the removed
+// constraint saves.
+const maxSubstitutionSize = 8
+
+// Stats reports what a pass of the optimizer removed from a constraint system.
+type Stats struct {
+	Pass          frontend.Optimization
+	NbConstraints int // number of constraints removed
+	NbVariables   int // number of internal variables removed
+}
+
+// optimizer holds the state shared by the R1CS and SparseR1CS optimizers.
+type optimizer struct {
+	cs       *compiled.ConstraintSystem
+	st       *cs.CoeffTable
+	q        *big.Int
+	nbInputs int
+	nbWires  int
+
+	refs       []int  // number of references to each wire (dead constraint elimination)
+	logged     []bool // wire referenced by a log or a debug info
+	eliminated []bool // wire removed from the constraint system
+	removed    []bool // constraint removed from the constraint system
+}
+
+func newOptimizer(ccs *compiled.ConstraintSystem, st *cs.CoeffTable, nbConstraints int) optimizer {
+	o := optimizer{
+		cs:       ccs,
+		st:       st,
+		q:        ccs.CurveID.Info().Fr.Modulus(),
+		nbInputs: ccs.NbPublicVariables + ccs.NbSecretVariables,
+		nbWires:  ccs.NbPublicVariables + ccs.NbSecretVariables + ccs.NbInternalVariables,
+		removed:  make([]bool, nbConstraints),
+	}
+	o.eliminated = make([]bool, o.nbWires)
+	o.markLogged()
+
+	return o
+}
+
+// markLogged marks the wires referenced by the logs and the debug info.
+func (o *optimizer) markLogged() {
+	o.logged = make([]bool, o.nbWires)
+	for _, entries := range [][]compiled.LogEntry{o.cs.Logs, o.cs.DebugInfo} {
+		for _, entry := range entries {
+			for _, t := range entry.ToResolve {
+				if t != compiled.TermDelimitor && o.isInternal(t) {
+					o.logged[t.WireID()] = true
+				}
+			}
+		}
+	}
+}
+
+// expandLogs replaces in the logs and the debug info the eliminated wires by
+// their definition. expand returns the terms whose sum is the value of t; the
+// constants are returned as schema.Virtual terms.
+func (o *optimizer) expandLogs(expand func(t compiled.Term) []compiled.Term) {
+	for _, entries := range [][]compiled.LogEntry{o.cs.Logs, o.cs.DebugInfo} {
+		for i := range entries {
+			entries[i].ToResolve = o.expandLogEntry(entries[i].ToResolve, expand)
+		}
+	}
+	o.markLogged()
+}
+
+func (o *optimizer) expandLogEntry(toResolve []compiled.Term, expand func(t compiled.Term) []compiled.Term) []compiled.Term {
+	found := false
+	for _, t := range toResolve {
+		if t != compiled.TermDelimitor && o.isInternal(t) && o.eliminated[t.WireID()] {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return toResolve
+	}
+
+	res := make([]compiled.Term, 0, len(toResolve))
+	isEval := false
+	for _, t := range toResolve {
+		if t == compiled.TermDelimitor {
+			isEval = !isEval
+			res = append(res, t)
+			continue
+		}
+		if !o.isInternal(t) || !o.eliminated[t.WireID()] {
+			res = append(res, t)
+			continue
+		}
+		if isEval {
+			// the solver sums the terms until the next delimitor
+			res = append(res, expand(t)...)
+			continue
+		}
+		// the format string holds a verb for the coefficient (if not ±1) and one
+		// for the value of the wire, which is now evaluated from its definition
+		if cID := t.CoeffID(); cID != compiled.CoeffIdOne && cID != compiled.CoeffIdMinusOne {
+			res = append(res, compiled.Pack(0, cID, schema.Virtual))
+		}
+		t.SetCoeffID(compiled.CoeffIdOne)
+		res = append(res, compiled.TermDelimitor)
+		res = append(res, expand(t)...)
+		res = append(res, compiled.TermDelimitor)
+	}
+	return res
+}
+
+// isInternal returns true if t references an internal variable.
+func (o *optimizer) isInternal(t compiled.Term) bool {
+	return t.VariableVisibility() == schema.Internal && t.WireID() >= o.nbInputs
+}
+
+// isHint returns true if wireID is the output of a hint.
+func (o *optimizer) isHint(wireID int) bool {
+	_, ok := o.cs.MHints[wireID]
+	return ok
+}
+
+// canSubstitute returns true if the optimizer may replace the internal
+// variable wireID by its definition.
+func (o *optimizer) canSubstitute(wireID int) bool {
+	return wireID >= o.nbInputs && !o.eliminated[wireID] && !o.isHint(wireID)
+}
+
+// canEliminate returns true if the optimizer may remove the internal
+// variable wireID without a definition to replace it with in the logs.
+func (o *optimizer) canEliminate(wireID int) bool {
+	return o.canSubstitute(wireID) && !o.logged[wireID]
+}
+
+// coeff returns the value of the coefficient of t.
+func (o *optimizer) coeff(t compiled.Term) *big.Int {
+	return &o.st.Coeffs[t.CoeffID()]
+}
+
+// coeffID returns the id of v mod q in the coefficient table. Values close to
+// q are stored as small negative numbers, as the builders do.
+func (o *optimizer) coeffID(v *big.Int) int {
+	var c big.Int
+	c.Mod(v, o.q)
+	if c.Sign() == 0 {
+		return compiled.CoeffIdZero
+	}
+	var n big.Int
+	n.Sub(&c, o.q)
+	if n.BitLen() < c.BitLen() {
+		return o.st.CoeffID(&n)
+	}
+	return o.st.CoeffID(&c)
+}
+
+// hints returns the hints of the constraint system, sorted by their first
+// output wire.
+func (o *optimizer) hints() []*compiled.Hint {
+	seen := make(map[*compiled.Hint]struct{}, len(o.cs.MHints))
+	res := make([]*compiled.Hint, 0, len(o.cs.MHints))
+	for _, h := range o.cs.MHints {
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		res = append(res, h)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Wires[0] < res[j].Wires[0] })
+	return res
+}
+
+// hintInputs returns the terms referencing internal variables in the inputs
+// of h.
+func (o *optimizer) hintInputs(h *compiled.Hint) []compiled.Term {
+	var res []compiled.Term
+	for _, in := range h.Inputs {
+		switch t := in.(type) {
+		case compiled.LinearExpression:
+			for _, tt := range t {
+				if o.isInternal(tt) {
+					res = append(res, tt)
+				}
+			}
+		case compiled.Term:
+			if o.isInternal(t) {
+				res = append(res, t)
+			}
+		}
+	}
+	return res
+}
+
+// countHintReferences adds to refs the references to the wires in the inputs
+// of the hints which are not eliminated.
+func (o *optimizer) countHintReferences(refs []int) {
+	for _, h := range o.hints() {
+		if o.eliminated[h.Wires[0]] {
+			continue
+		}
+		for _, t := range o.hintInputs(h) {
+			refs[t.WireID()]++
+		}
+	}
+}
+
+// canRelease returns true if removing the references in terms would not
+// remove a hint which has an output referenced by a log. The solver only
+// calls the hints whose outputs are referenced by a constraint or by the
+// inputs of another hint.
+func (o *optimizer) canRelease(terms []compiled.Term) bool {
+	released := make(map[int]int)
+	dead := make(map[*compiled.Hint]bool)
+	var visit func(terms []compiled.Term) bool
+	visit = func(terms []compiled.Term) bool {
+		for _, t := range terms {
+			wID := t.WireID()
+			released[wID]++
+			h, ok := o.cs.MHints[wID]
+			if !ok || dead[h] {
+				continue
+			}
+			nbRefs := 0
+			for _, w := range h.Wires {
+				nbRefs += o.refs[w] - released[w]
+			}
+			if nbRefs != 0 {
+				continue
+			}
+			for _, w := range h.Wires {
+				if o.logged[w] {
+					return false
+				}
+			}
+			dead[h] = true
+			if !visit(o.hintInputs(h)) {
+				return false
+			}
+		}
+		return true
+	}
+	return visit(terms)
+}
+
+// release removes the references in terms, and the hints whose outputs are
+// no longer referenced.
+func (o *optimizer) release(terms []compiled.Term) {
+	for _, t := range terms {
+		wID := t.WireID()
+		o.refs[wID]--
+		h, ok := o.cs.MHints[wID]
+		if !ok || o.eliminated[h.Wires[0]] {
+			continue
+		}
+		nbRefs := 0
+		for _, w := range h.Wires {
+			nbRefs += o.refs[w]
+		}
+		if nbRefs != 0 {
+			continue
+		}
+		for _, w := range h.Wires {
+			o.eliminated[w] = true
+		}
+		o.release(o.hintInputs(h))
+	}
+}
+
+// countRemoved returns the number of removed constraints and eliminated wires.
+func (o *optimizer) countRemoved() (nbConstraints, nbVariables int) {
+	for _, r := range o.removed {
+		if r {
+			nbConstraints++
+		}
+	}
+	for _, e := range o.eliminated {
+		if e {
+			nbVariables++
+		}
+	}
+	return
+}
+
+// renumber computes the new ids of the wires once the eliminated ones are
+// removed, and updates accordingly the hints, the logs, the debug info,
+// MDebug, MHintsDebug, MLabels and the number of internal variables. It returns the new
+// id of each wire and of each constraint (-1 if it was removed).
+func (o *optimizer) renumber() (wireIDs, constraintIDs []int) {
+	wireIDs = make([]int, o.nbWires)
+	next := 0
+	for i := range wireIDs {
+		if o.eliminated[i] {
+			wireIDs[i] = -1
+			continue
+		}
+		wireIDs[i] = next
+		next++
+	}
+
+	constraintIDs = make([]int, len(o.removed))
+	next = 0
+	for i := range constraintIDs {
+		if o.removed[i] {
+			constraintIDs[i] = -1
+			continue
+		}
+		constraintIDs[i] = next
+		next++
+	}
+
+	// hints
+	hints := o.hints()
+	mHints := make(map[int]*compiled.Hint, len(o.cs.MHints))
+	for _, h := range hints {
+		if o.eliminated[h.Wires[0]] {
+			continue
+		}
+		nh := &compiled.Hint{
+			ID:              h.ID,
+			Inputs:          make([]interface{}, len(h.Inputs)),
+			Wires:           make([]int, len(h.Wires)),
+			Rich:            h.Rich,
+			Params:          h.Params,
+			VariableOutputs: h.VariableOutputs,
+		}
+		for i, in := range h.Inputs {
+			switch t := in.(type) {
+			case compiled.LinearExpression:
+				nt := make(compiled.LinearExpression, len(t))
+				for j := range t {
+					nt[j] = o.remap(t[j], wireIDs)
+				}
+				nh.Inputs[i] = nt
+			case compiled.Term:
+				nh.Inputs[i] = o.remap(t, wireIDs)
+			default:
+				nh.Inputs[i] = t
+			}
+		}
+		for i, w := range h.Wires {
+			nh.Wires[i] = wireIDs[w]
+			mHints[nh.Wires[i]] = nh
+		}
+	}
+	o.cs.MHints = mHints
+
+	// logs and debug info
+	for _, entries := range [][]compiled.LogEntry{o.cs.Logs, o.cs.DebugInfo} {
+		for i := range entries {
+			for j, t := range entries[i].ToResolve {
+				if t != compiled.TermDelimitor {
+					entries[i].ToResolve[j] = o.remap(t, wireIDs)
+				}
+			}
+		}
+	}
+
+	// debug info attached to the constraints
+	mDebug := make(map[int]int, len(o.cs.MDebug))
+	for cID, dID := range o.cs.MDebug {
+		if constraintIDs[cID] != -1 {
+			mDebug[constraintIDs[cID]] = dID
+		}
+	}
+	o.cs.MDebug = mDebug
+
+	// debug info attached to the hint outputs
+	mHintsDebug := make(map[int]int, len(o.cs.MHintsDebug))
+	for wID, dID := range o.cs.MHintsDebug {
+		if wireIDs[wID] != -1 {
+			mHintsDebug[wireIDs[wID]] = dID
+		}
+	}
+	o.cs.MHintsDebug = mHintsDebug
+
+	// labels of the remaining wires
+	mLabels := make(map[int]string, len(o.cs.MLabels))
+	for wID, name := range o.cs.MLabels {
+		if wireIDs[wID] != -1 {
+			mLabels[wireIDs[wID]] = name
+		}
+	}
+	o.cs.MLabels = mLabels
+
+	_, nbEliminated := o.countRemoved()
+	o.cs.NbInternalVariables -= nbEliminated
+
+	return
+}
+
+// remap returns t with its wire renumbered.
+func (o *optimizer) remap(t compiled.Term, wireIDs []int) compiled.Term {
+	if !o.isInternal(t) {
+		return t
+	}
+	wID := wireIDs[t.WireID()]
+	if wID == -1 {
+		panic("optimizer: eliminated wire is still referenced")
+	}
+	t.SetWireID(wID)
+	return t
+}
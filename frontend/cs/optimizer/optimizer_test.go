@@ -0,0 +1,147 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/circuits"
+	"github.com/consensys/gnark/test"
+)
+
+func TestCircuits(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	keys := make([]string, 0, len(circuits.Circuits))
+	for k := range circuits.Circuits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		tData := circuits.Circuits[name]
+		opts := []test.TestingOption{
+			test.WithCompileOpts(frontend.WithOptimizations()),
+			test.WithProverOpts(backend.WithHints(tData.HintFunctions...)),
+			test.WithCurves(tData.Curves[0]),
+		}
+		assert.Run(func(assert *test.Assert) {
+			for i := range tData.ValidAssignments {
+				assert.Run(func(assert *test.Assert) {
+					assert.ProverSucceeded(tData.Circuit, tData.ValidAssignments[i], opts...)
+				}, fmt.Sprintf("valid-%d", i))
+			}
+			for i := range tData.InvalidAssignments {
+				assert.Run(func(assert *test.Assert) {
+					assert.ProverFailed(tData.Circuit, tData.InvalidAssignments[i], opts...)
+				}, fmt.Sprintf("invalid-%d", i))
+			}
+		}, name)
+	}
+}
+
+type redundantCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *redundantCircuit) Define(api frontend.API) error {
+	// dead
+	api.Mul(c.X, c.X, c.Y)
+
+	// constant
+	zero := api.Sub(c.Y, c.Y)
+	e := api.Mul(zero, c.X)
+
+	// linear
+	a := api.Add(c.X, 3)
+	b := api.Sub(a, c.Y)
+	d := api.DivUnchecked(b, api.Add(zero, 2))
+	f := api.Add(d, d, d, d, e)
+
+	// duplicates
+	m := api.Mul(f, c.Y)
+	api.AssertIsEqual(m, c.Z)
+	api.AssertIsEqual(m, c.Z)
+
+	api.Println(m)
+
+	return nil
+}
+
+func TestRedundantCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// (2 * (x + 3 - y)) * y == z
+	var valid, invalid redundantCircuit
+	valid.X, valid.Y, valid.Z = 5, 2, 24
+	invalid.X, invalid.Y, invalid.Z = 5, 2, 23
+
+	opts := []test.TestingOption{
+		test.WithCompileOpts(frontend.WithOptimizations()),
+		test.WithCurves(ecc.BN254),
+	}
+	assert.ProverSucceeded(&redundantCircuit{}, &valid, opts...)
+	assert.ProverFailed(&redundantCircuit{}, &invalid, opts...)
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &redundantCircuit{})
+		assert.NoError(err)
+		previous := ccs.GetNbConstraints()
+
+		// each pass alone, then all of them
+		for _, passes := range []frontend.Optimization{
+			frontend.ConstantPropagation,
+			frontend.LinearSubstitution,
+			frontend.DuplicateConstraints,
+			frontend.DeadConstraintElimination,
+		} {
+			optimized, err := frontend.Compile(ecc.BN254, newBuilder, &redundantCircuit{}, frontend.WithOptimizations(passes))
+			assert.NoError(err)
+			assert.Less(optimized.GetNbConstraints(), ccs.GetNbConstraints(), passes.String())
+		}
+
+		optimized, err := frontend.Compile(ecc.BN254, newBuilder, &redundantCircuit{}, frontend.WithOptimizations())
+		assert.NoError(err)
+		assert.Less(optimized.GetNbConstraints(), previous)
+
+		nbInternal, _, _ := optimized.GetNbVariables()
+		nbInternalPrevious, _, _ := ccs.GetNbVariables()
+		assert.Less(nbInternal, nbInternalPrevious)
+	}
+}
+
+func TestWithOptimizations(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var config frontend.CompileConfig
+	assert.NoError(frontend.WithOptimizations()(&config))
+	assert.Equal(frontend.AllOptimizations, config.Optimizations)
+
+	config = frontend.CompileConfig{}
+	assert.NoError(frontend.WithOptimizations(frontend.LinearSubstitution, frontend.DuplicateConstraints)(&config))
+	assert.Equal(frontend.LinearSubstitution|frontend.DuplicateConstraints, config.Optimizations)
+
+	assert.Error(frontend.WithOptimizations(1 << 7)(&config))
+}
@@ -0,0 +1,493 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+type r1csOptimizer struct {
+	optimizer
+	r *compiled.R1CS
+}
+
+// R1CS runs the given passes on r and returns what each of them removed.
+// st is the coefficient table of the builder which compiled r; new
+// coefficients are added to it.
+func R1CS(r *compiled.R1CS, st *cs.CoeffTable, passes frontend.Optimization) []Stats {
+	o := r1csOptimizer{
+		optimizer: newOptimizer(&r.ConstraintSystem, st, len(r.Constraints)),
+		r:         r,
+	}
+
+	var stats []Stats
+	if passes&(frontend.ConstantPropagation|frontend.LinearSubstitution) != 0 {
+		stats = append(stats, o.propagate(passes)...)
+	}
+	if passes&frontend.DuplicateConstraints != 0 {
+		stats = append(stats, o.removeDuplicates())
+	}
+	if passes&frontend.DeadConstraintElimination != 0 {
+		stats = append(stats, o.eliminateDeadConstraints())
+	}
+
+	o.compact()
+
+	return stats
+}
+
+// propagate walks through the constraints in order, removes the ones which are
+// satisfied by constants, and the ones which define an internal variable as a
+// linear expression of previously solved variables. The variable is then
+// replaced by its definition in the following constraints and in the hint
+// inputs.
+func (o *r1csOptimizer) propagate(passes frontend.Optimization) []Stats {
+	constants := Stats{Pass: frontend.ConstantPropagation}
+	linear := Stats{Pass: frontend.LinearSubstitution}
+
+	refs := o.countReferences()
+	solved := make([]bool, o.nbWires)
+	defs := make(map[int]compiled.LinearExpression)
+
+	// zero terms can be dropped, unless they are the only reference to a hint
+	// output, or the first occurrence of a wire (the solver computes it there)
+	drop := func(t compiled.Term) bool {
+		wID := t.WireID()
+		return !o.isInternal(t) || (solved[wID] && !o.isHint(wID))
+	}
+
+	for i := range o.r.Constraints {
+		c := &o.r.Constraints[i]
+		c.L = o.substitute(c.L, defs, drop)
+		c.R = o.substitute(c.R, defs, drop)
+		c.O = o.substitute(c.O, defs, drop)
+		w := o.markSolved(c, solved)
+
+		kL, okL := o.constant(c.L)
+		kR, okR := o.constant(c.R)
+		kO, okO := o.constant(c.O)
+		if okL && okR && okO {
+			if passes&frontend.ConstantPropagation == 0 {
+				continue
+			}
+			var v big.Int
+			v.Mul(kL, kR).Sub(&v, kO).Mod(&v, o.q)
+			if v.Sign() == 0 {
+				o.removed[i] = true
+				constants.NbConstraints++
+			}
+			continue
+		}
+
+		// the constraint must be linear in the wire it solves, and the wire must
+		// be used elsewhere (otherwise the dead constraint elimination removes it
+		// without losing the references to hint outputs it may hold)
+		if w == -1 || !o.canSubstitute(w) || refs[w] <= o.occurrences(c, w) {
+			continue
+		}
+		var e compiled.LinearExpression
+		switch {
+		case okL:
+			e = o.combine(kL, c.R, big.NewInt(-1), c.O, drop)
+		case okR:
+			e = o.combine(kR, c.L, big.NewInt(-1), c.O, drop)
+		default:
+			continue
+		}
+		def, ok := o.solveFor(e, w)
+		if !ok {
+			continue
+		}
+		if _, ok := o.constant(def); ok {
+			if passes&frontend.ConstantPropagation == 0 {
+				continue
+			}
+			constants.NbConstraints++
+			constants.NbVariables++
+		} else {
+			if passes&frontend.LinearSubstitution == 0 || len(def) > maxSubstitutionSize {
+				continue
+			}
+			linear.NbConstraints++
+			linear.NbVariables++
+		}
+		defs[w] = def
+		o.removed[i] = true
+		o.eliminated[w] = true
+	}
+
+	// hint inputs, logs and debug info
+	if len(defs) != 0 {
+		o.expandLogs(func(t compiled.Term) []compiled.Term {
+			var c big.Int
+			def := defs[t.WireID()]
+			res := make([]compiled.Term, len(def))
+			for i, d := range def {
+				c.Mul(o.coeff(t), o.coeff(d))
+				d.SetCoeffID(o.coeffID(&c))
+				if d.VariableVisibility() == schema.Public && d.WireID() == 0 {
+					d.SetVariableVisibility(schema.Virtual)
+				}
+				res[i] = d
+			}
+			return res
+		})
+		for _, h := range o.hints() {
+			for j, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					h.Inputs[j] = o.substitute(t, defs, drop)
+				case compiled.Term:
+					if _, ok := defs[t.WireID()]; ok && o.isInternal(t) {
+						h.Inputs[j] = o.substitute(compiled.LinearExpression{t}, defs, drop)
+					}
+				}
+			}
+		}
+	}
+
+	var stats []Stats
+	if passes&frontend.ConstantPropagation != 0 {
+		stats = append(stats, constants)
+	}
+	if passes&frontend.LinearSubstitution != 0 {
+		stats = append(stats, linear)
+	}
+	return stats
+}
+
+// removeDuplicates removes the constraints which are equal to a previous one,
+// up to the order of L and R.
+func (o *r1csOptimizer) removeDuplicates() Stats {
+	stats := Stats{Pass: frontend.DuplicateConstraints}
+
+	type entry struct {
+		l, r, o compiled.LinearExpression
+	}
+	keep := func(compiled.Term) bool { return false }
+	seen := make(map[uint64][]entry)
+
+	for i := range o.r.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		c := &o.r.Constraints[i]
+		e := entry{
+			l: o.reduce(c.L.Clone(), keep),
+			r: o.reduce(c.R.Clone(), keep),
+			o: o.reduce(c.O.Clone(), keep),
+		}
+		hL, hR := e.l.HashCode(), e.r.HashCode()
+		if hL > hR {
+			hL, hR = hR, hL
+		}
+		key := (hL*23+hR)*23 + e.o.HashCode()
+
+		duplicate := false
+		for _, p := range seen[key] {
+			if e.o.Equal(p.o) && ((e.l.Equal(p.l) && e.r.Equal(p.r)) || (e.l.Equal(p.r) && e.r.Equal(p.l))) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			o.removed[i] = true
+			stats.NbConstraints++
+			continue
+		}
+		seen[key] = append(seen[key], e)
+	}
+
+	return stats
+}
+
+// eliminateDeadConstraints walks through the constraints in reverse order and
+// removes the ones which solve an internal variable used nowhere else, when
+// the variable can always be solved (that is, it appears in O, or in L or R
+// while the other one is a non-zero constant). Hints whose outputs are no
+// longer referenced are removed too.
+func (o *r1csOptimizer) eliminateDeadConstraints() Stats {
+	stats := Stats{Pass: frontend.DeadConstraintElimination}
+
+	o.refs = o.countReferences()
+	solved := make([]bool, o.nbWires)
+	defined := make([]int, len(o.r.Constraints))
+	for i := range o.r.Constraints {
+		defined[i] = -1
+		if !o.removed[i] {
+			defined[i] = o.markSolved(&o.r.Constraints[i], solved)
+		}
+	}
+
+	nbConstraints, nbVariables := o.countRemoved()
+	for i := len(o.r.Constraints) - 1; i >= 0; i-- {
+		w := defined[i]
+		if o.removed[i] || w == -1 || !o.canEliminate(w) || o.refs[w] != 1 {
+			continue
+		}
+		c := &o.r.Constraints[i]
+		if !o.isFree(c, w) {
+			continue
+		}
+		terms := o.terms(c)
+		if !o.canRelease(terms) {
+			continue
+		}
+		o.removed[i] = true
+		o.eliminated[w] = true
+		o.release(terms)
+	}
+	nbConstraintsAfter, nbVariablesAfter := o.countRemoved()
+	stats.NbConstraints = nbConstraintsAfter - nbConstraints
+	stats.NbVariables = nbVariablesAfter - nbVariables
+
+	return stats
+}
+
+// compact removes the eliminated wires and constraints from the constraint
+// system.
+func (o *r1csOptimizer) compact() {
+	wireIDs, _ := o.renumber()
+
+	remap := func(l compiled.LinearExpression) compiled.LinearExpression {
+		res := make(compiled.LinearExpression, len(l))
+		for i := range l {
+			res[i] = o.remap(l[i], wireIDs)
+		}
+		return res
+	}
+
+	constraints := make([]compiled.R1C, 0, len(o.r.Constraints))
+	for i, c := range o.r.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		constraints = append(constraints, compiled.R1C{L: remap(c.L), R: remap(c.R), O: remap(c.O)})
+	}
+	o.r.Constraints = constraints
+}
+
+// countReferences returns the number of terms referencing each wire in the
+// constraints and in the inputs of the hints.
+func (o *r1csOptimizer) countReferences() []int {
+	refs := make([]int, o.nbWires)
+	for i := range o.r.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		for _, t := range o.terms(&o.r.Constraints[i]) {
+			refs[t.WireID()]++
+		}
+	}
+	o.countHintReferences(refs)
+	return refs
+}
+
+// terms returns the terms of c referencing internal variables.
+func (o *r1csOptimizer) terms(c *compiled.R1C) []compiled.Term {
+	res := make([]compiled.Term, 0, len(c.L)+len(c.R)+len(c.O))
+	for _, l := range []compiled.LinearExpression{c.L, c.R, c.O} {
+		for _, t := range l {
+			if o.isInternal(t) {
+				res = append(res, t)
+			}
+		}
+	}
+	return res
+}
+
+// markSolved marks the wires of c as solved, and returns the internal
+// variable (not a hint output) solved by c, or -1.
+func (o *r1csOptimizer) markSolved(c *compiled.R1C, solved []bool) int {
+	w := -1
+	for _, t := range o.terms(c) {
+		wID := t.WireID()
+		if solved[wID] {
+			continue
+		}
+		solved[wID] = true
+		if w == -1 && !o.isHint(wID) {
+			w = wID
+		}
+	}
+	return w
+}
+
+// occurrences returns the number of terms of c referencing wireID.
+func (o *r1csOptimizer) occurrences(c *compiled.R1C, wireID int) int {
+	n := 0
+	for _, t := range o.terms(c) {
+		if t.WireID() == wireID {
+			n++
+		}
+	}
+	return n
+}
+
+// isFree returns true if c can be satisfied for any value of its other
+// wires by setting wireID, which appears once in c.
+func (o *r1csOptimizer) isFree(c *compiled.R1C, wireID int) bool {
+	in := func(l compiled.LinearExpression) bool {
+		for _, t := range l {
+			if o.isInternal(t) && t.WireID() == wireID {
+				return true
+			}
+		}
+		return false
+	}
+	nonZero := func(l compiled.LinearExpression) bool {
+		k, ok := o.constant(l)
+		return ok && k.Sign() != 0
+	}
+	switch {
+	case in(c.O):
+		return true
+	case in(c.L):
+		return nonZero(c.R)
+	case in(c.R):
+		return nonZero(c.L)
+	}
+	return false
+}
+
+// constant returns the value of l if it only references the constant wire.
+func (o *r1csOptimizer) constant(l compiled.LinearExpression) (*big.Int, bool) {
+	res := new(big.Int)
+	for _, t := range l {
+		if t.VariableVisibility() != schema.Public || t.WireID() != 0 {
+			return nil, false
+		}
+		res.Add(res, o.coeff(t))
+	}
+	return res.Mod(res, o.q), true
+}
+
+// substitute replaces in l the wires defined in defs by their definition, and
+// reduces the result. l is returned as is if it has nothing to substitute or
+// to drop.
+func (o *r1csOptimizer) substitute(l compiled.LinearExpression, defs map[int]compiled.LinearExpression, drop func(compiled.Term) bool) compiled.LinearExpression {
+	found := false
+	for _, t := range l {
+		if _, ok := defs[t.WireID()]; (ok && o.isInternal(t)) || (t.CoeffID() == compiled.CoeffIdZero && drop(t)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return l
+	}
+
+	res := make(compiled.LinearExpression, 0, len(l))
+	var c big.Int
+	for _, t := range l {
+		def, ok := defs[t.WireID()]
+		if !ok || !o.isInternal(t) {
+			res = append(res, t)
+			continue
+		}
+		for _, d := range def {
+			c.Mul(o.coeff(t), o.coeff(d))
+			d.SetCoeffID(o.coeffID(&c))
+			res = append(res, d)
+		}
+	}
+	return o.reduce(res, drop)
+}
+
+// combine returns a*l + b*r.
+func (o *r1csOptimizer) combine(a *big.Int, l compiled.LinearExpression, b *big.Int, r compiled.LinearExpression, drop func(compiled.Term) bool) compiled.LinearExpression {
+	res := make(compiled.LinearExpression, 0, len(l)+len(r))
+	var c big.Int
+	for _, t := range l {
+		c.Mul(a, o.coeff(t))
+		t.SetCoeffID(o.coeffID(&c))
+		res = append(res, t)
+	}
+	for _, t := range r {
+		c.Mul(b, o.coeff(t))
+		t.SetCoeffID(o.coeffID(&c))
+		res = append(res, t)
+	}
+	return o.reduce(res, drop)
+}
+
+// solveFor returns the linear expression def such that e == 0 iff wireID == def.
+func (o *r1csOptimizer) solveFor(e compiled.LinearExpression, wireID int) (compiled.LinearExpression, bool) {
+	var cw *big.Int
+	for _, t := range e {
+		if o.isInternal(t) && t.WireID() == wireID {
+			cw = o.coeff(t)
+			break
+		}
+	}
+	if cw == nil || new(big.Int).Mod(cw, o.q).Sign() == 0 {
+		return nil, false
+	}
+
+	// def = -(e - cw*w) / cw
+	var inv big.Int
+	inv.ModInverse(new(big.Int).Mod(cw, o.q), o.q).Neg(&inv)
+
+	def := make(compiled.LinearExpression, 0, len(e)-1)
+	var c big.Int
+	for _, t := range e {
+		if o.isInternal(t) && t.WireID() == wireID {
+			continue
+		}
+		c.Mul(o.coeff(t), &inv)
+		t.SetCoeffID(o.coeffID(&c))
+		def = append(def, t)
+	}
+	if len(def) == 0 {
+		def = append(def, compiled.Pack(0, compiled.CoeffIdZero, schema.Public))
+	}
+	return def, true
+}
+
+// reduce sorts l, merges the terms referencing the same wire, and removes the
+// zero terms for which drop returns true. l is modified.
+func (o *r1csOptimizer) reduce(l compiled.LinearExpression, drop func(compiled.Term) bool) compiled.LinearExpression {
+	sort.Sort(l)
+
+	res := l[:0]
+	var c big.Int
+	for i := 0; i < len(l); {
+		t := l[i]
+		c.Set(o.coeff(t))
+		j := i + 1
+		for ; j < len(l) && l[j].WireID() == t.WireID() && l[j].VariableVisibility() == t.VariableVisibility(); j++ {
+			c.Add(&c, o.coeff(l[j]))
+		}
+		i = j
+		t.SetCoeffID(o.coeffID(&c))
+		if t.CoeffID() == compiled.CoeffIdZero && drop(t) {
+			continue
+		}
+		res = append(res, t)
+	}
+
+	if len(res) == 0 {
+		res = append(res, compiled.Pack(0, compiled.CoeffIdZero, schema.Public))
+	}
+	return res
+}
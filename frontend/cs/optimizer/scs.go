@@ -0,0 +1,526 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+type scsOptimizer struct {
+	optimizer
+	s *compiled.SparseR1CS
+
+	hintInput []bool // wire referenced by the inputs of a hint
+}
+
+// gate is a decoded SparseR1C: qL⋅l + qR⋅r + qM⋅l⋅r + qO⋅o + k == 0.
+// The terms l, r and o have a coefficient one, and are zero when unused.
+type gate struct {
+	l, r, o           compiled.Term
+	qL, qR, qM, qO, k big.Int
+}
+
+func (g *gate) usesL() bool { return g.qL.Sign() != 0 || g.qM.Sign() != 0 }
+func (g *gate) usesR() bool { return g.qR.Sign() != 0 || g.qM.Sign() != 0 }
+func (g *gate) usesO() bool { return g.qO.Sign() != 0 }
+
+// affine is the definition of an eliminated wire in a SparseR1CS: a⋅x + b,
+// where x is unset for constants.
+type affine struct {
+	x    compiled.Term
+	hasX bool
+	a, b big.Int
+}
+
+// SparseR1CS runs the given passes on s and returns what each of them removed.
+// st is the coefficient table of the builder which compiled s; new
+// coefficients are added to it.
+func SparseR1CS(s *compiled.SparseR1CS, st *cs.CoeffTable, passes frontend.Optimization) []Stats {
+	o := scsOptimizer{
+		optimizer: newOptimizer(&s.ConstraintSystem, st, len(s.Constraints)),
+		s:         s,
+	}
+	o.hintInput = make([]bool, o.nbWires)
+	for _, h := range o.hints() {
+		for _, t := range o.hintInputs(h) {
+			o.hintInput[t.WireID()] = true
+		}
+	}
+
+	var stats []Stats
+	if passes&(frontend.ConstantPropagation|frontend.LinearSubstitution) != 0 {
+		stats = append(stats, o.propagate(passes)...)
+	}
+	if passes&frontend.DuplicateConstraints != 0 {
+		stats = append(stats, o.removeDuplicates())
+	}
+	if passes&frontend.DeadConstraintElimination != 0 {
+		stats = append(stats, o.eliminateDeadConstraints())
+	}
+
+	o.compact()
+
+	return stats
+}
+
+// propagate walks through the constraints in order, removes the ones which are
+// satisfied by constants, and the ones which define an internal variable as an
+// affine function of at most one previously solved variable. The variable is
+// then replaced by its definition in the following constraints and in the hint
+// inputs.
+func (o *scsOptimizer) propagate(passes frontend.Optimization) []Stats {
+	constants := Stats{Pass: frontend.ConstantPropagation}
+	linear := Stats{Pass: frontend.LinearSubstitution}
+
+	refs := o.countReferences()
+	solved := make([]bool, o.nbWires)
+	defs := make(map[int]*affine)
+
+	for i := range o.s.Constraints {
+		c := &o.s.Constraints[i]
+		g := o.decode(c)
+		if len(defs) != 0 && o.substitute(&g, defs) {
+			*c = o.encode(&g)
+		}
+		w := o.markSolved(&g, solved)
+
+		if !g.usesL() && !g.usesR() && !g.usesO() {
+			if passes&frontend.ConstantPropagation != 0 && new(big.Int).Mod(&g.k, o.q).Sign() == 0 {
+				o.removed[i] = true
+				constants.NbConstraints++
+			}
+			continue
+		}
+
+		// the wire must be used elsewhere, otherwise the dead constraint
+		// elimination removes it without losing the references to hint outputs
+		// the constraint may hold
+		if w == -1 || !o.canSubstitute(w) || refs[w] <= o.occurrences(&g, w) {
+			continue
+		}
+		d, ok := o.solveFor(&g, w)
+		if !ok {
+			continue
+		}
+		// hint inputs are terms, they can't hold a constant
+		if o.hintInput[w] && (!d.hasX || d.b.Sign() != 0) {
+			continue
+		}
+		if d.hasX {
+			if passes&frontend.LinearSubstitution == 0 {
+				continue
+			}
+			linear.NbConstraints++
+			linear.NbVariables++
+		} else {
+			if passes&frontend.ConstantPropagation == 0 {
+				continue
+			}
+			constants.NbConstraints++
+			constants.NbVariables++
+		}
+		defs[w] = d
+		o.removed[i] = true
+		o.eliminated[w] = true
+	}
+
+	// hint inputs, logs and debug info
+	if len(defs) != 0 {
+		o.expandLogs(func(t compiled.Term) []compiled.Term {
+			var c big.Int
+			d := defs[t.WireID()]
+			res := make([]compiled.Term, 0, 2)
+			if d.hasX {
+				c.Mul(o.coeff(t), &d.a)
+				x := d.x
+				x.SetCoeffID(o.coeffID(&c))
+				res = append(res, x)
+			}
+			if !d.hasX || d.b.Sign() != 0 {
+				c.Mul(o.coeff(t), &d.b)
+				res = append(res, compiled.Pack(0, o.coeffID(&c), schema.Virtual))
+			}
+			return res
+		})
+		substitute := func(t compiled.Term) compiled.Term {
+			d, ok := defs[t.WireID()]
+			if !ok || !o.isInternal(t) {
+				return t
+			}
+			var c big.Int
+			c.Mul(o.coeff(t), &d.a)
+			x := d.x
+			x.SetCoeffID(o.coeffID(&c))
+			return x
+		}
+		for _, h := range o.hints() {
+			for j, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					l := make(compiled.LinearExpression, len(t))
+					for k := range t {
+						l[k] = substitute(t[k])
+					}
+					h.Inputs[j] = l
+				case compiled.Term:
+					h.Inputs[j] = substitute(t)
+				}
+			}
+		}
+	}
+
+	var stats []Stats
+	if passes&frontend.ConstantPropagation != 0 {
+		stats = append(stats, constants)
+	}
+	if passes&frontend.LinearSubstitution != 0 {
+		stats = append(stats, linear)
+	}
+	return stats
+}
+
+// removeDuplicates removes the constraints which are equal to a previous one,
+// up to the order of L and R.
+func (o *scsOptimizer) removeDuplicates() Stats {
+	stats := Stats{Pass: frontend.DuplicateConstraints}
+
+	seen := make(map[compiled.SparseR1C]struct{})
+	for i := range o.s.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		g := o.decode(&o.s.Constraints[i])
+		key := o.encode(&g)
+		g.l, g.r = g.r, g.l
+		g.qL, g.qR = g.qR, g.qL
+		swapped := o.encode(&g)
+
+		_, ok := seen[key]
+		if _, okSwapped := seen[swapped]; ok || okSwapped {
+			o.removed[i] = true
+			stats.NbConstraints++
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+
+	return stats
+}
+
+// eliminateDeadConstraints walks through the constraints in reverse order and
+// removes the ones which solve an internal variable used nowhere else, when
+// the variable appears linearly with a non-zero coefficient. Hints whose
+// outputs are no longer referenced are removed too.
+func (o *scsOptimizer) eliminateDeadConstraints() Stats {
+	stats := Stats{Pass: frontend.DeadConstraintElimination}
+
+	o.refs = o.countReferences()
+	solved := make([]bool, o.nbWires)
+	defined := make([]int, len(o.s.Constraints))
+	for i := range o.s.Constraints {
+		defined[i] = -1
+		if !o.removed[i] {
+			g := o.decode(&o.s.Constraints[i])
+			defined[i] = o.markSolved(&g, solved)
+		}
+	}
+
+	nbConstraints, nbVariables := o.countRemoved()
+	for i := len(o.s.Constraints) - 1; i >= 0; i-- {
+		w := defined[i]
+		if o.removed[i] || w == -1 || !o.canEliminate(w) || o.refs[w] != 1 {
+			continue
+		}
+		g := o.decode(&o.s.Constraints[i])
+		free := (g.usesO() && g.o.WireID() == w) ||
+			(g.qM.Sign() == 0 && g.usesL() && g.l.WireID() == w) ||
+			(g.qM.Sign() == 0 && g.usesR() && g.r.WireID() == w)
+		if !free {
+			continue
+		}
+		terms := o.terms(&g)
+		if !o.canRelease(terms) {
+			continue
+		}
+		o.removed[i] = true
+		o.eliminated[w] = true
+		o.release(terms)
+	}
+	nbConstraintsAfter, nbVariablesAfter := o.countRemoved()
+	stats.NbConstraints = nbConstraintsAfter - nbConstraints
+	stats.NbVariables = nbVariablesAfter - nbVariables
+
+	return stats
+}
+
+// compact removes the eliminated wires and constraints from the constraint
+// system.
+func (o *scsOptimizer) compact() {
+	// unused terms may still reference eliminated wires
+	for i := range o.s.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		c := &o.s.Constraints[i]
+		g := o.decode(c)
+		if !g.usesL() && o.isInternal(c.L) && o.eliminated[c.L.WireID()] {
+			c.L, c.M[0] = 0, 0
+		}
+		if !g.usesR() && o.isInternal(c.R) && o.eliminated[c.R.WireID()] {
+			c.R, c.M[1] = 0, 0
+		}
+		if !g.usesO() && o.isInternal(c.O) && o.eliminated[c.O.WireID()] {
+			c.O = 0
+		}
+	}
+
+	wireIDs, _ := o.renumber()
+
+	constraints := make([]compiled.SparseR1C, 0, len(o.s.Constraints))
+	for i, c := range o.s.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		c.L = o.remap(c.L, wireIDs)
+		c.R = o.remap(c.R, wireIDs)
+		c.O = o.remap(c.O, wireIDs)
+		c.M[0] = o.remap(c.M[0], wireIDs)
+		c.M[1] = o.remap(c.M[1], wireIDs)
+		constraints = append(constraints, c)
+	}
+	o.s.Constraints = constraints
+}
+
+// countReferences returns the number of terms referencing each wire in the
+// constraints and in the inputs of the hints.
+func (o *scsOptimizer) countReferences() []int {
+	refs := make([]int, o.nbWires)
+	for i := range o.s.Constraints {
+		if o.removed[i] {
+			continue
+		}
+		g := o.decode(&o.s.Constraints[i])
+		for _, t := range o.terms(&g) {
+			refs[t.WireID()]++
+		}
+	}
+	o.countHintReferences(refs)
+	return refs
+}
+
+// terms returns the used terms of g referencing internal variables.
+func (o *scsOptimizer) terms(g *gate) []compiled.Term {
+	res := make([]compiled.Term, 0, 3)
+	if g.usesL() && o.isInternal(g.l) {
+		res = append(res, g.l)
+	}
+	if g.usesR() && o.isInternal(g.r) {
+		res = append(res, g.r)
+	}
+	if g.usesO() && o.isInternal(g.o) {
+		res = append(res, g.o)
+	}
+	return res
+}
+
+// markSolved marks the wires of g as solved, and returns the internal
+// variable (not a hint output) solved by g, or -1.
+func (o *scsOptimizer) markSolved(g *gate, solved []bool) int {
+	w := -1
+	for _, t := range o.terms(g) {
+		wID := t.WireID()
+		if solved[wID] {
+			continue
+		}
+		solved[wID] = true
+		if w == -1 && !o.isHint(wID) {
+			w = wID
+		}
+	}
+	return w
+}
+
+// occurrences returns the number of used terms of g referencing wireID.
+func (o *scsOptimizer) occurrences(g *gate, wireID int) int {
+	n := 0
+	for _, t := range o.terms(g) {
+		if t.WireID() == wireID {
+			n++
+		}
+	}
+	return n
+}
+
+// decode returns the gate corresponding to c.
+func (o *scsOptimizer) decode(c *compiled.SparseR1C) gate {
+	var g gate
+	g.qL.Set(o.coeff(c.L))
+	g.qR.Set(o.coeff(c.R))
+	g.qO.Set(o.coeff(c.O))
+	g.qM.Mul(o.coeff(c.M[0]), o.coeff(c.M[1])).Mod(&g.qM, o.q)
+	g.k.Set(&o.st.Coeffs[c.K])
+	for _, q := range []*big.Int{&g.qL, &g.qR, &g.qO, &g.k} {
+		q.Mod(q, o.q)
+	}
+	if g.usesL() {
+		g.l = c.L
+		g.l.SetCoeffID(compiled.CoeffIdOne)
+	}
+	if g.usesR() {
+		g.r = c.R
+		g.r.SetCoeffID(compiled.CoeffIdOne)
+	}
+	if g.usesO() {
+		g.o = c.O
+		g.o.SetCoeffID(compiled.CoeffIdOne)
+	}
+	return g
+}
+
+// encode returns the SparseR1C corresponding to g. The unused terms are set
+// to zero, the solver may otherwise look for the value of their wire.
+func (o *scsOptimizer) encode(g *gate) compiled.SparseR1C {
+	var c compiled.SparseR1C
+	if g.usesL() {
+		c.L = g.l
+		c.L.SetCoeffID(o.coeffID(&g.qL))
+	}
+	if g.usesR() {
+		c.R = g.r
+		c.R.SetCoeffID(o.coeffID(&g.qR))
+	}
+	if g.usesO() {
+		c.O = g.o
+		c.O.SetCoeffID(o.coeffID(&g.qO))
+	}
+	if g.qM.Sign() != 0 {
+		c.M[0] = g.l
+		c.M[0].SetCoeffID(o.coeffID(&g.qM))
+		c.M[1] = g.r
+	}
+	c.K = o.coeffID(&g.k)
+	return c
+}
+
+// substitute replaces in g the wires defined in defs by their definition, and
+// returns true if g was modified.
+func (o *scsOptimizer) substitute(g *gate, defs map[int]*affine) bool {
+	lookup := func(t compiled.Term, used bool) *affine {
+		if !used || !o.isInternal(t) {
+			return nil
+		}
+		return defs[t.WireID()]
+	}
+	var tmp big.Int
+	changed := false
+
+	// qL⋅(a⋅x + b) + qM⋅(a⋅x + b)⋅r = a⋅qL⋅x + a⋅qM⋅x⋅r + b⋅qM⋅r + b⋅qL
+	if d := lookup(g.l, g.usesL()); d != nil {
+		g.k.Add(&g.k, tmp.Mul(&g.qL, &d.b)).Mod(&g.k, o.q)
+		g.qR.Add(&g.qR, tmp.Mul(&g.qM, &d.b)).Mod(&g.qR, o.q)
+		g.qL.Mul(&g.qL, &d.a).Mod(&g.qL, o.q)
+		g.qM.Mul(&g.qM, &d.a).Mod(&g.qM, o.q)
+		g.l = d.x
+		changed = true
+	}
+	if d := lookup(g.r, g.usesR()); d != nil {
+		g.k.Add(&g.k, tmp.Mul(&g.qR, &d.b)).Mod(&g.k, o.q)
+		g.qL.Add(&g.qL, tmp.Mul(&g.qM, &d.b)).Mod(&g.qL, o.q)
+		g.qR.Mul(&g.qR, &d.a).Mod(&g.qR, o.q)
+		g.qM.Mul(&g.qM, &d.a).Mod(&g.qM, o.q)
+		g.r = d.x
+		changed = true
+	}
+	if d := lookup(g.o, g.usesO()); d != nil {
+		g.k.Add(&g.k, tmp.Mul(&g.qO, &d.b)).Mod(&g.k, o.q)
+		g.qO.Mul(&g.qO, &d.a).Mod(&g.qO, o.q)
+		g.o = d.x
+		changed = true
+	}
+	if !changed {
+		return false
+	}
+
+	// a substituted constant may leave a slot unused, or a slot may have
+	// received a coefficient while it was unused
+	if !g.usesL() {
+		g.l = 0
+	}
+	if !g.usesR() {
+		g.r = 0
+	}
+	if !g.usesO() {
+		g.o = 0
+	}
+	return true
+}
+
+// solveFor returns the definition of wireID, if g is linear in wireID and
+// involves at most one other wire.
+func (o *scsOptimizer) solveFor(g *gate, wireID int) (*affine, bool) {
+	if g.qM.Sign() != 0 {
+		return nil, false
+	}
+
+	var cw big.Int
+	var d affine
+	var cx big.Int
+	add := func(t compiled.Term, c *big.Int) bool {
+		if t.WireID() == wireID && o.isInternal(t) {
+			cw.Add(&cw, c)
+			return true
+		}
+		if d.hasX && d.x != t {
+			return false
+		}
+		d.x, d.hasX = t, true
+		cx.Add(&cx, c)
+		return true
+	}
+	if g.usesL() && !add(g.l, &g.qL) {
+		return nil, false
+	}
+	if g.usesR() && !add(g.r, &g.qR) {
+		return nil, false
+	}
+	if g.usesO() && !add(g.o, &g.qO) {
+		return nil, false
+	}
+	cw.Mod(&cw, o.q)
+	if cw.Sign() == 0 {
+		return nil, false
+	}
+	cx.Mod(&cx, o.q)
+	if cx.Sign() == 0 {
+		d.hasX = false
+		d.x = 0
+	}
+
+	// cw⋅w + cx⋅x + k == 0 → w = -cx/cw⋅x - k/cw
+	var inv big.Int
+	inv.ModInverse(&cw, o.q).Neg(&inv)
+	if d.hasX {
+		d.a.Mul(&cx, &inv).Mod(&d.a, o.q)
+	}
+	d.b.Mul(&g.k, &inv).Mod(&d.b, o.q)
+	return &d, true
+}
@@ -0,0 +1,90 @@
+package cs_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+type outputCircuit struct {
+	Hash frontend.Variable `gnark:",output"`
+	X    frontend.Variable
+	Y    frontend.Variable `gnark:",public"`
+	Bit  frontend.Variable `gnark:",output"`
+}
+
+func (circuit *outputCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.Hash, api.Add(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y))
+	api.AssertIsEqual(circuit.Bit, api.IsZero(api.Sub(circuit.X, 3)))
+
+	// the outputs can be used once constrained
+	api.AssertIsBoolean(circuit.Bit)
+	api.AssertIsDifferent(api.Mul(circuit.Hash, circuit.Bit), 1)
+	return nil
+}
+
+func TestOutputs(t *testing.T) {
+	assert := require.New(t)
+
+	for _, b := range []backend.ID{backend.GROTH16, backend.PLONK} {
+		var publicWitness *witness.Witness
+		fullWitness, err := frontend.NewWitness(&outputCircuit{X: 3, Y: 5}, ecc.BN254)
+		assert.NoError(err, "outputs may be unassigned in the full witness")
+
+		switch b {
+		case backend.GROTH16:
+			ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &outputCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			var proof groth16.Proof
+			proof, publicWitness, err = groth16.ProveWithOutputs(ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, publicWitness))
+		case backend.PLONK:
+			ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &outputCircuit{})
+			assert.NoError(err)
+			srs, err := test.NewKZGSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs)
+			assert.NoError(err)
+			var proof plonk.Proof
+			proof, publicWitness, err = plonk.ProveWithOutputs(ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, publicWitness))
+		}
+
+		// the public witness holds the outputs computed by the solver
+		expected, err := frontend.NewWitness(&outputCircuit{Hash: 32, Y: 5, Bit: 1}, ecc.BN254, frontend.PublicOnly())
+		assert.NoError(err)
+		assert.Equal(expected.Vector, publicWitness.Vector, b.String())
+
+		// and so does the full witness
+		completed, err := fullWitness.Public()
+		assert.NoError(err)
+		assert.Equal(expected.Vector, completed.Vector, b.String())
+	}
+
+	// the public witness needs the outputs
+	_, err := frontend.NewWitness(&outputCircuit{Y: 5}, ecc.BN254, frontend.PublicOnly())
+	assert.Error(err)
+
+	test.NewAssert(t).ProverSucceeded(&outputCircuit{}, &outputCircuit{Hash: 32, X: 3, Y: 5, Bit: 1}, test.WithCurves(ecc.BN254))
+
+	// the test engine computes the unassigned outputs as the solver does, and
+	// checks the assigned ones
+	assert.NoError(test.IsSolved(&outputCircuit{}, &outputCircuit{X: 3, Y: 5}, ecc.BN254, backend.UNKNOWN))
+	assert.NoError(test.IsSolved(&outputCircuit{}, &outputCircuit{X: 3, Y: 5, Bit: 1}, ecc.BN254, backend.UNKNOWN))
+	assert.Error(test.IsSolved(&outputCircuit{}, &outputCircuit{Hash: 31, X: 3, Y: 5}, ecc.BN254, backend.UNKNOWN))
+	test.NewAssert(t).SolvingSucceeded(&outputCircuit{}, &outputCircuit{X: 3, Y: 5}, test.WithCurves(ecc.BN254))
+	test.NewAssert(t).Fuzz(&outputCircuit{}, 10, test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,126 @@
+package cs
+
+import (
+	"io"
+	"runtime"
+
+	"github.com/consensys/gnark/debug"
+	"github.com/google/pprof/profile"
+)
+
+// profilerMaxDepth is the maximum number of frames recorded per call stack.
+const profilerMaxDepth = 64
+
+// Profiler records the call stack of every constraint and internal variable
+// added by a builder, and writes them as a pprof profile (see
+// frontend.WithProfile).
+type Profiler struct {
+	samples map[string]*profilerSample
+	keys    []string // samples in insertion order, for a deterministic output
+}
+
+type profilerSample struct {
+	pc                         []uintptr
+	nbConstraints, nbVariables int64
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		samples: make(map[string]*profilerSample),
+	}
+}
+
+// RecordConstraint records the call stack of a new constraint.
+func (p *Profiler) RecordConstraint() {
+	p.sample().nbConstraints++
+}
+
+// RecordVariable records the call stack of a new internal variable.
+func (p *Profiler) RecordVariable() {
+	p.sample().nbVariables++
+}
+
+// sample returns the sample of the calling stack, skipping the frames of the
+// profiler.
+func (p *Profiler) sample() *profilerSample {
+	pc := debug.Callers(2, profilerMaxDepth)
+
+	// the program counters are the key of the sample; they are resolved once,
+	// when the profile is written.
+	key := make([]byte, 0, len(pc)*8)
+	for _, v := range pc {
+		for i := 0; i < 8; i++ {
+			key = append(key, byte(v>>(8*i)))
+		}
+	}
+	s, ok := p.samples[string(key)]
+	if !ok {
+		s = &profilerSample{pc: pc}
+		p.samples[string(key)] = s
+		p.keys = append(p.keys, string(key))
+	}
+	return s
+}
+
+// Write writes the recorded samples to w as a gzipped pprof profile, in which
+// the values of a sample are its number of constraints and of internal
+// variables. The frames of the frontend are omitted, so that the leaves of the
+// call stacks are the lines of the circuit (or of the std gadgets) calling the
+// frontend.API.
+func (p *Profiler) Write(w io.Writer) error {
+	prof := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "constraints", Unit: "count"},
+			{Type: "variables", Unit: "count"},
+		},
+		PeriodType: &profile.ValueType{Type: "constraints", Unit: "count"},
+		Period:     1,
+	}
+
+	type frameKey struct {
+		function, file string
+		line           int
+	}
+	functions := make(map[[2]string]*profile.Function)
+	locations := make(map[frameKey]*profile.Location)
+
+	location := func(frame runtime.Frame) *profile.Location {
+		k := frameKey{frame.Function, frame.File, frame.Line}
+		if l, ok := locations[k]; ok {
+			return l
+		}
+		f, ok := functions[[2]string{frame.Function, frame.File}]
+		if !ok {
+			f = &profile.Function{
+				ID:         uint64(len(prof.Function) + 1),
+				Name:       frame.Function,
+				SystemName: frame.Function,
+				Filename:   frame.File,
+			}
+			functions[[2]string{frame.Function, frame.File}] = f
+			prof.Function = append(prof.Function, f)
+		}
+		l := &profile.Location{
+			ID:   uint64(len(prof.Location) + 1),
+			Line: []profile.Line{{Function: f, Line: int64(frame.Line)}},
+		}
+		locations[k] = l
+		prof.Location = append(prof.Location, l)
+		return l
+	}
+
+	for _, key := range p.keys {
+		s := p.samples[key]
+		frames := debug.Frames(s.pc, true)
+		sample := &profile.Sample{
+			Location: make([]*profile.Location, len(frames)),
+			Value:    []int64{s.nbConstraints, s.nbVariables},
+		}
+		for i, frame := range frames {
+			sample.Location[i] = location(frame)
+		}
+		prof.Sample = append(prof.Sample, sample)
+	}
+
+	return prof.Write(w)
+}
@@ -0,0 +1,66 @@
+package cs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+type profiledCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *profiledCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	bits := api.ToBinary(c.Y, 8)
+	api.AssertIsEqual(api.Add(x3, api.FromBinary(bits...)), c.Z)
+	return nil
+}
+
+func TestProfiler(t *testing.T) {
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		var buf bytes.Buffer
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &profiledCircuit{}, frontend.WithProfile(&buf))
+		require.NoError(t, err)
+
+		p, err := profile.Parse(&buf)
+		require.NoError(t, err)
+		require.NoError(t, p.CheckValid())
+		require.Equal(t, "constraints", p.SampleType[0].Type)
+		require.Equal(t, "variables", p.SampleType[1].Type)
+
+		nbConstraints, nbVariables := 0, 0
+		for _, s := range p.Sample {
+			nbConstraints += int(s.Value[0])
+			nbVariables += int(s.Value[1])
+
+			// the frames of the frontend are omitted, the call stacks go from
+			// the circuit definition (or the std gadgets) up to Define
+			for _, l := range s.Location {
+				require.NotContains(t, l.Line[0].Function.Name, "frontend/cs")
+			}
+			root := s.Location[len(s.Location)-1].Line[0].Function.Name
+			require.True(t, strings.HasSuffix(root, "(*profiledCircuit).Define"), root)
+		}
+		internal, _, _ := ccs.GetNbVariables()
+		require.Equal(t, ccs.GetNbConstraints(), nbConstraints)
+		require.Equal(t, internal, nbVariables)
+
+		// the std gadgets appear in the call stacks
+		found := false
+		for _, f := range p.Function {
+			if strings.Contains(f.Name, "bits.toBinary") {
+				found = true
+			}
+		}
+		require.True(t, found, "missing std/math/bits frames")
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/std/math/bits"
 )
@@ -98,8 +99,12 @@ func (system *r1cs) Mul(i1, i2 frontend.Variable, in ...frontend.Variable) front
 
 		// v1 and v2 are both unknown, this is the only case we add a constraint
 		if !v1Constant && !v2Constant {
+			if res, ok := system.lookup(cs.OpMul, 0, v1, v2); ok {
+				return res[0].(compiled.LinearExpression)
+			}
 			res := system.newInternalVariable()
-			system.Constraints = append(system.Constraints, newR1C(v1, v2, res))
+			system.addConstraint(newR1C(v1, v2, res))
+			system.store([]frontend.Variable{res}, cs.OpMul, 0, v1, v2)
 			return res
 		}
 
@@ -163,10 +168,14 @@ func (system *r1cs) DivUnchecked(i1, i2 frontend.Variable) frontend.Variable {
 	n2, v2Constant := system.ConstantValue(v2)
 
 	if !v2Constant {
+		if res, ok := system.lookup(cs.OpDivUnchecked, 0, v1, v2); ok {
+			return res[0]
+		}
 		res := system.newInternalVariable()
 		debug := system.AddDebugInfo("div", v1, "/", v2, " == ", res)
 		// note that here we don't ensure that divisor is != 0
 		system.addConstraint(newR1C(v2, res, v1), debug)
+		system.store([]frontend.Variable{res}, cs.OpDivUnchecked, 0, v1, v2)
 		return res
 	}
 
@@ -197,12 +206,16 @@ func (system *r1cs) Div(i1, i2 frontend.Variable) frontend.Variable {
 	n2, v2Constant := system.ConstantValue(v2)
 
 	if !v2Constant {
+		if res, ok := system.lookup(cs.OpDiv, 0, v1, v2); ok {
+			return res[0]
+		}
 		res := system.newInternalVariable()
 		debug := system.AddDebugInfo("div", v1, "/", v2, " == ", res)
 		v2Inv := system.newInternalVariable()
 		// note that here we ensure that v2 can't be 0, but it costs us one extra constraint
 		system.addConstraint(newR1C(v2, v2Inv, system.one()), debug)
 		system.addConstraint(newR1C(v1, v2Inv, res), debug)
+		system.store([]frontend.Variable{res}, cs.OpDiv, 0, v1, v2)
 		return res
 	}
 
@@ -235,11 +248,16 @@ func (system *r1cs) Inverse(i1 frontend.Variable) frontend.Variable {
 		return system.toVariable(c)
 	}
 
+	if res, ok := system.lookup(cs.OpInverse, 0, vars[0]); ok {
+		return res[0]
+	}
+
 	// allocate resulting frontend.Variable
 	res := system.newInternalVariable()
 
 	debug := system.AddDebugInfo("inverse", vars[0], "*", res, " == 1")
 	system.addConstraint(newR1C(res, vars[0], system.one()), debug)
+	system.store([]frontend.Variable{res}, cs.OpInverse, 0, vars[0])
 
 	return res
 }
@@ -262,7 +280,17 @@ func (system *r1cs) ToBinary(i1 frontend.Variable, n ...int) []frontend.Variable
 		}
 	}
 
-	return bits.ToBinary(system, i1, bits.WithNbDigits(nbBits))
+	if _, ok := system.ConstantValue(i1); ok {
+		return bits.ToBinary(system, i1, bits.WithNbDigits(nbBits))
+	}
+
+	vars, _ := system.toVariables(i1)
+	if res, ok := system.lookup(cs.OpToBinary, nbBits, vars[0]); ok {
+		return res
+	}
+	res := bits.ToBinary(system, vars[0], bits.WithNbDigits(nbBits))
+	system.store(res, cs.OpToBinary, nbBits, vars[0])
+	return res
 }
 
 // FromBinary packs b, seen as a fr.Element in little endian
@@ -278,6 +306,10 @@ func (system *r1cs) Xor(_a, _b frontend.Variable) frontend.Variable {
 	a := vars[0]
 	b := vars[1]
 
+	if res, ok := system.lookup(cs.OpXor, 0, a, b); ok {
+		return res[0]
+	}
+
 	system.AssertIsBoolean(a)
 	system.AssertIsBoolean(b)
 
@@ -287,7 +319,8 @@ func (system *r1cs) Xor(_a, _b frontend.Variable) frontend.Variable {
 	c := system.Neg(res).(compiled.LinearExpression)
 	c = append(c, a[0], b[0])
 	aa := system.Mul(a, 2)
-	system.Constraints = append(system.Constraints, newR1C(aa, b, c))
+	system.addConstraint(newR1C(aa, b, c))
+	system.store([]frontend.Variable{res}, cs.OpXor, 0, a, b)
 
 	return res
 }
@@ -299,6 +332,10 @@ func (system *r1cs) Or(_a, _b frontend.Variable) frontend.Variable {
 	a := vars[0]
 	b := vars[1]
 
+	if res, ok := system.lookup(cs.OpOr, 0, a, b); ok {
+		return res[0]
+	}
+
 	system.AssertIsBoolean(a)
 	system.AssertIsBoolean(b)
 
@@ -307,7 +344,8 @@ func (system *r1cs) Or(_a, _b frontend.Variable) frontend.Variable {
 	system.MarkBoolean(res)
 	c := system.Neg(res).(compiled.LinearExpression)
 	c = append(c, a[0], b[0])
-	system.Constraints = append(system.Constraints, newR1C(a, b, c))
+	system.addConstraint(newR1C(a, b, c))
+	system.store([]frontend.Variable{res}, cs.OpOr, 0, a, b)
 
 	return res
 }
@@ -434,6 +472,10 @@ func (system *r1cs) IsZero(i1 frontend.Variable) frontend.Variable {
 		return system.toVariable(0)
 	}
 
+	if res, ok := system.lookup(cs.OpIsZero, 0, a); ok {
+		return res[0]
+	}
+
 	debug := system.AddDebugInfo("isZero", a)
 
 	//m * (1 - m) = 0       // constrain m to be 0 or 1
@@ -452,6 +494,7 @@ func (system *r1cs) IsZero(i1 frontend.Variable) frontend.Variable {
 	system.AssertIsBoolean(m)
 	ma := system.Add(m, a)
 	_ = system.Inverse(ma)
+	system.store([]frontend.Variable{m}, cs.OpIsZero, 0, a)
 	return m
 }
 
@@ -520,6 +563,51 @@ func (system *r1cs) Println(a ...frontend.Variable) {
 	system.Logs = append(system.Logs, log)
 }
 
+// Printf behaves like fmt.Printf with the verbs documented in frontend.API,
+// the values of the variables being resolved when the R1CS.Solve() method is
+// executed.
+func (system *r1cs) Printf(format string, a ...frontend.Variable) {
+	args := make([]interface{}, len(a))
+	for i := range a {
+		args[i] = a[i]
+	}
+	tokens, err := compiled.ExpandPrintf(format, args, tVariable)
+	if err != nil {
+		panic(err)
+	}
+
+	var log compiled.LogEntry
+
+	// prefix log line with file.go:line
+	if _, file, line, ok := runtime.Caller(1); ok {
+		log.Caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	var sbb strings.Builder
+	for _, t := range tokens {
+		if t.Directive == "" {
+			sbb.WriteString(t.Text)
+			continue
+		}
+		v, ok := t.Value.(compiled.LinearExpression)
+		if !ok {
+			// constants are resolved by the solver too, so that they are
+			// formatted as the variables
+			v = system.toVariable(t.Value).(compiled.LinearExpression)
+		}
+		assertIsSet(v)
+
+		sbb.WriteString(t.Directive)
+		log.ToResolve = append(log.ToResolve, compiled.TermDelimitor)
+		log.ToResolve = append(log.ToResolve, v...)
+		log.ToResolve = append(log.ToResolve, compiled.TermDelimitor)
+		log.Fields = append(log.Fields, t.Name)
+	}
+	log.Format = sbb.String()
+
+	system.Logs = append(system.Logs, log)
+}
+
 func printArg(log *compiled.LogEntry, sbb *strings.Builder, a frontend.Variable) {
 
 	count := 0
@@ -19,6 +19,7 @@ package r1cs
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"path/filepath"
 	"reflect"
@@ -26,6 +27,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
@@ -33,6 +35,7 @@ import (
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/cs/optimizer"
 	"github.com/consensys/gnark/frontend/schema"
 	bls12377r1cs "github.com/consensys/gnark/internal/backend/bls12-377/cs"
 	bls12381r1cs "github.com/consensys/gnark/internal/backend/bls12-381/cs"
@@ -58,6 +61,18 @@ type r1cs struct {
 
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[uint64][]compiled.LinearExpression
+
+	// results of the operations emitting constraints (to not emit them twice)
+	cache cs.ExpressionCache
+
+	// call stacks of the constraints and variables (nil if not profiling)
+	profiler *cs.Profiler
+
+	// constraint templates of the components (see Call)
+	components map[componentKey]*component
+
+	// number of calls of each component type, to prefix the labels (see stamp)
+	nbComponentCalls map[string]int
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -68,11 +83,16 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *r1cs {
 			MDebug:             make(map[int]int),
 			MHints:             make(map[int]*compiled.Hint),
 			MHintsDependencies: make(map[hint.ID]string),
+			MHintsDebug:        make(map[int]int),
+			MLabels:            make(map[int]string),
 		},
-		Constraints: make([]compiled.R1C, 0, config.Capacity),
-		st:          cs.NewCoeffTable(),
-		mtBooleans:  make(map[uint64][]compiled.LinearExpression),
-		config:      config,
+		Constraints:      make([]compiled.R1C, 0, config.Capacity),
+		st:               cs.NewCoeffTable(),
+		mtBooleans:       make(map[uint64][]compiled.LinearExpression),
+		cache:            cs.NewExpressionCache(),
+		components:       make(map[componentKey]*component),
+		nbComponentCalls: make(map[string]int),
+		config:           config,
 	}
 
 	system.Public = make([]string, 1)
@@ -83,6 +103,10 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *r1cs {
 
 	system.CurveID = curveID
 
+	if config.Profile != nil {
+		system.profiler = cs.NewProfiler()
+	}
+
 	return &system
 }
 
@@ -91,6 +115,9 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *r1cs {
 func (system *r1cs) newInternalVariable() compiled.LinearExpression {
 	idx := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
 	system.NbInternalVariables++
+	if system.profiler != nil {
+		system.profiler.RecordVariable()
+	}
 	return compiled.LinearExpression{
 		compiled.Pack(idx, compiled.CoeffIdOne, schema.Internal),
 	}
@@ -105,6 +132,12 @@ func (system *r1cs) AddPublicVariable(name string) frontend.Variable {
 	}
 }
 
+// AddOutputVariable creates a new public Variable computed by the solver
+func (system *r1cs) AddOutputVariable(name string) frontend.Variable {
+	system.Outputs = append(system.Outputs, len(system.Public))
+	return system.AddPublicVariable(name)
+}
+
 // AddSecretVariable creates a new secret Variable
 func (system *r1cs) AddSecretVariable(name string) frontend.Variable {
 	idx := len(system.Secret) + system.NbPublicVariables
@@ -147,6 +180,32 @@ func (system *r1cs) reduce(l compiled.LinearExpression) compiled.LinearExpressio
 	return l
 }
 
+// lookup returns the result of a previous call to op on the same operands, unless
+// the common subexpression elimination is disabled
+func (system *r1cs) lookup(op cs.Op, n int, operands ...compiled.LinearExpression) ([]frontend.Variable, bool) {
+	if system.config.NoCommonSubexpressionElimination {
+		return nil, false
+	}
+	return system.cache.Lookup(op, n, system.canonical(operands)...)
+}
+
+// store records result as the result of op on operands (see lookup)
+func (system *r1cs) store(result []frontend.Variable, op cs.Op, n int, operands ...compiled.LinearExpression) {
+	if system.config.NoCommonSubexpressionElimination {
+		return
+	}
+	system.cache.Store(result, op, n, system.canonical(operands)...)
+}
+
+// canonical returns reduced copies of the operands
+func (system *r1cs) canonical(operands []compiled.LinearExpression) []compiled.LinearExpression {
+	res := make([]compiled.LinearExpression, len(operands))
+	for i := range operands {
+		res[i] = system.reduce(operands[i].Clone())
+	}
+	return res
+}
+
 // newR1C clones the linear expression associated with the Variables (to avoid offseting the ID multiple time)
 // and return a R1C
 func newR1C(_l, _r, _o frontend.Variable) compiled.R1C {
@@ -169,6 +228,9 @@ func newR1C(_l, _r, _o frontend.Variable) compiled.R1C {
 
 func (system *r1cs) addConstraint(r1c compiled.R1C, debugID ...int) {
 	system.Constraints = append(system.Constraints, r1c)
+	if system.profiler != nil {
+		system.profiler.RecordConstraint()
+	}
 	if len(debugID) > 0 {
 		system.MDebug[len(system.Constraints)-1] = debugID[0]
 	}
@@ -203,6 +265,18 @@ func (system *r1cs) MarkBoolean(v frontend.Variable) {
 	system.mtBooleans[key] = list
 }
 
+// Label names the wire of v, see frontend.Compiler
+func (system *r1cs) Label(v frontend.Variable, name string) {
+	if _, ok := system.ConstantValue(v); ok {
+		return
+	}
+	l := v.(compiled.LinearExpression)
+	if len(l) != 1 || l[0].CoeffID() != compiled.CoeffIdOne {
+		return
+	}
+	system.MLabels[l[0].WireID()] = name
+}
+
 // IsBoolean returns true if given variable was marked as boolean in the compiler (see MarkBoolean)
 // Use with care; variable may not have been **constrained** to be boolean
 // This returns true if the v is a constant and v == 0 || v == 1.
@@ -242,13 +316,15 @@ func (system *r1cs) checkVariables() error {
 	cptPublic := len(system.Public)
 	cptHints := len(system.MHints)
 
-	secretConstrained := make([]bool, cptSecret)
-	publicConstrained := make([]bool, cptPublic)
-	// one wire does not need to be constrained
-	publicConstrained[0] = true
-	cptPublic--
-
-	mHintsConstrained := make(map[int]bool)
+	// the constraints are processed concurrently, the flags are set atomically
+	secretConstrained := make([]uint32, cptSecret)
+	publicConstrained := make([]uint32, cptPublic)
+	internalConstrained := make([]uint32, system.NbInternalVariables)
+	mark := func(flags []uint32, i int) {
+		if atomic.LoadUint32(&flags[i]) == 0 {
+			atomic.StoreUint32(&flags[i], 1)
+		}
+	}
 
 	// for each constraint, we check the linear expressions and mark our inputs / hints as constrained
 	processLinearExpression := func(l compiled.LinearExpression) {
@@ -263,37 +339,35 @@ func (system *r1cs) checkVariables() error {
 
 			switch visibility {
 			case schema.Public:
-				if vID != 0 && !publicConstrained[vID] {
-					publicConstrained[vID] = true
-					cptPublic--
-				}
+				mark(publicConstrained, vID)
 			case schema.Secret:
-				vID -= system.NbPublicVariables
-				if !secretConstrained[vID] {
-					secretConstrained[vID] = true
-					cptSecret--
-				}
+				mark(secretConstrained, vID-system.NbPublicVariables)
 			case schema.Internal:
-
-				if _, ok := system.MHints[vID]; ok {
-					vID -= (system.NbPublicVariables + system.NbSecretVariables)
-					if !mHintsConstrained[vID] {
-						mHintsConstrained[vID] = true
-						cptHints--
-					}
-				}
+				mark(internalConstrained, vID-(system.NbPublicVariables+system.NbSecretVariables))
 			}
 		}
 	}
-	for _, r1c := range system.Constraints {
-		processLinearExpression(r1c.L)
-		processLinearExpression(r1c.R)
-		processLinearExpression(r1c.O)
-
-		if cptHints|cptSecret|cptPublic == 0 {
-			return nil // we can stop.
+	utils.Parallelize(len(system.Constraints), func(start, end int) {
+		for _, r1c := range system.Constraints[start:end] {
+			processLinearExpression(r1c.L)
+			processLinearExpression(r1c.R)
+			processLinearExpression(r1c.O)
 		}
+	}, system.config.NbTasks)
 
+	// one wire does not need to be constrained
+	publicConstrained[0] = 1
+	for _, c := range secretConstrained {
+		cptSecret -= int(c)
+	}
+	for _, c := range publicConstrained {
+		cptPublic -= int(c)
+	}
+	for vID := range system.MHints {
+		cptHints -= int(internalConstrained[vID-(system.NbPublicVariables+system.NbSecretVariables)])
+	}
+	if cptHints|cptSecret|cptPublic == 0 {
+		return nil
 	}
 
 	// something is a miss, we build the error string
@@ -303,7 +377,7 @@ func (system *r1cs) checkVariables() error {
 		sbb.WriteString(" unconstrained secret input(s):")
 		sbb.WriteByte('\n')
 		for i := 0; i < len(secretConstrained) && cptSecret != 0; i++ {
-			if !secretConstrained[i] {
+			if secretConstrained[i] == 0 {
 				sbb.WriteString(system.Secret[i])
 				sbb.WriteByte('\n')
 				cptSecret--
@@ -317,7 +391,7 @@ func (system *r1cs) checkVariables() error {
 		sbb.WriteString(" unconstrained public input(s):")
 		sbb.WriteByte('\n')
 		for i := 0; i < len(publicConstrained) && cptPublic != 0; i++ {
-			if !publicConstrained[i] {
+			if publicConstrained[i] == 0 {
 				sbb.WriteString(system.Public[i])
 				sbb.WriteByte('\n')
 				cptPublic--
@@ -350,6 +424,13 @@ func (cs *r1cs) Compile() (frontend.CompiledConstraintSystem, error) {
 		Int("nbConstraints", len(cs.Constraints)).
 		Msg("building constraint system")
 
+	// write the profile of the circuit definition, if any
+	if cs.profiler != nil {
+		if err := cs.profiler.Write(cs.config.Profile); err != nil {
+			return nil, fmt.Errorf("write profile: %w", err)
+		}
+	}
+
 	// ensure all inputs and hints are constrained
 	err := cs.checkVariables()
 	if err != nil {
@@ -374,8 +455,19 @@ func (cs *r1cs) Compile() (frontend.CompiledConstraintSystem, error) {
 		panic("number of secret variables is inconsitent") // it grew after the schema parsing?
 	}
 
+	// run the optimizer passes, if any
+	if cs.config.Optimizations != 0 {
+		for _, stats := range optimizer.R1CS(&res, &cs.st, cs.config.Optimizations) {
+			log.Info().
+				Str("pass", stats.Pass.String()).
+				Int("nbConstraints", stats.NbConstraints).
+				Int("nbVariables", stats.NbVariables).
+				Msg("optimized constraint system")
+		}
+	}
+
 	// build levels
-	res.Levels = buildLevels(res)
+	res.Levels = buildLevels(res, cs.config.NbTasks)
 
 	switch cs.CurveID {
 	case ecc.BLS12_377:
@@ -404,20 +496,48 @@ func (cs *r1cs) SetSchema(s *schema.Schema) {
 	cs.NbSecretVariables = s.NbSecret
 }
 
-func buildLevels(ccs compiled.R1CS) [][]int {
+// buildLevels groups the constraints by level: a constraint only depends on
+// the wires solved by the constraints of the previous levels, so that the
+// solver can process the constraints of a level concurrently.
+//
+// An internal wire or an output is solved by the first constraint referencing
+// it; the hint outputs are solved together, by the first constraint
+// referencing one of them, which then also depends on the hint inputs. The
+// first references are found concurrently, the levels are then assigned in
+// order.
+func buildLevels(ccs compiled.R1CS, nbTasks int) [][]int {
 
 	b := levelBuilder{
-		mWireToNode: make(map[int]int, ccs.NbInternalVariables), // at which node we resolved which wire
-		nodeLevels:  make([]int, len(ccs.Constraints)),          // level of a node
-		mLevels:     make(map[int]int),                          // level counts
-		ccs:         ccs,
-		nbInputs:    ccs.NbPublicVariables + ccs.NbSecretVariables,
-	}
+		ccs:        ccs,
+		nbInputs:   ccs.NbPublicVariables + ccs.NbSecretVariables,
+		solvedBy:   make([]int64, ccs.NbInternalVariables+len(ccs.Outputs)),
+		outputs:    make(map[int]int, len(ccs.Outputs)),
+		nodeLevels: make([]int, len(ccs.Constraints)),
+		expanded:   make(map[*compiled.Hint]struct{}),
+	}
+	for i := range b.solvedBy {
+		b.solvedBy[i] = math.MaxInt64
+	}
+	for i, wID := range ccs.Outputs {
+		b.outputs[wID] = ccs.NbInternalVariables + i
+	}
+
+	// first constraint referencing each wire
+	utils.Parallelize(len(ccs.Constraints), func(start, end int) {
+		for cID := start; cID < end; cID++ {
+			c := &ccs.Constraints[cID]
+			b.markLE(c.L, cID)
+			b.markLE(c.R, cID)
+			b.markLE(c.O, cID)
+		}
+	}, nbTasks)
+	b.markHints()
 
 	// for each constraint, we're going to find its direct dependencies
 	// that is, wires (solved by previous constraints) on which it depends
 	// each of these dependencies is tagged with a level
 	// current constraint will be tagged with max(level) + 1
+	mLevels := make(map[int]int) // number of constraints per level
 	for cID, c := range ccs.Constraints {
 
 		b.nodeLevel = 0
@@ -426,14 +546,14 @@ func buildLevels(ccs compiled.R1CS) [][]int {
 		b.processLE(c.R, cID)
 		b.processLE(c.O, cID)
 		b.nodeLevels[cID] = b.nodeLevel
-		b.mLevels[b.nodeLevel]++
+		mLevels[b.nodeLevel]++
 
 	}
 
-	levels := make([][]int, len(b.mLevels))
+	levels := make([][]int, len(mLevels))
 	for i := 0; i < len(levels); i++ {
 		// allocate memory
-		levels[i] = make([]int, 0, b.mLevels[i])
+		levels[i] = make([]int, 0, mLevels[i])
 	}
 
 	for n, l := range b.nodeLevels {
@@ -447,36 +567,120 @@ type levelBuilder struct {
 	ccs      compiled.R1CS
 	nbInputs int
 
-	mWireToNode map[int]int // at which node we resolved which wire
-	nodeLevels  []int       // level per node
-	mLevels     map[int]int // number of constraint per level
+	solvedBy   []int64                     // constraint solving each internal wire, then each output
+	outputs    map[int]int                 // index in solvedBy of each output wire
+	nodeLevels []int                       // level per node
+	expanded   map[*compiled.Hint]struct{} // hints whose inputs were processed
 
 	nodeLevel int // current level
 }
 
+// slot returns the index in solvedBy of the wire wID, or -1 if wID is an input
+func (b *levelBuilder) slot(wID int) int {
+	if wID >= b.nbInputs {
+		if wID-b.nbInputs >= b.ccs.NbInternalVariables {
+			return -1
+		}
+		return wID - b.nbInputs
+	}
+	if i, ok := b.outputs[wID]; ok {
+		return i
+	}
+	return -1
+}
+
+// markLE records that the constraint cID references the wires of l
+func (b *levelBuilder) markLE(l compiled.LinearExpression, cID int) {
+	for _, t := range l {
+		wID := b.slot(t.WireID())
+		if wID < 0 {
+			// it's a input, we ignore it
+			continue
+		}
+		for {
+			n := atomic.LoadInt64(&b.solvedBy[wID])
+			if n <= int64(cID) || atomic.CompareAndSwapInt64(&b.solvedBy[wID], n, int64(cID)) {
+				break
+			}
+		}
+	}
+}
+
+// markHints sets the wires of a hint as solved by the first constraint
+// referencing one of them, and propagates it to the hint inputs
+func (b *levelBuilder) markHints() {
+	hints := make([]*compiled.Hint, 0, len(b.ccs.MHints))
+	for wID, h := range b.ccs.MHints {
+		if h.Wires[0] == wID {
+			hints = append(hints, h)
+		}
+	}
+	// the inputs of a hint are usually wires created before its outputs; the
+	// hints are processed from the last one until nothing changes
+	sort.Slice(hints, func(i, j int) bool { return hints[i].Wires[0] > hints[j].Wires[0] })
+
+	lower := func(wID int, n int64) bool {
+		wID = b.slot(wID)
+		if wID < 0 || b.solvedBy[wID] <= n {
+			return false
+		}
+		b.solvedBy[wID] = n
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, h := range hints {
+			n := int64(math.MaxInt64)
+			for _, wID := range h.Wires {
+				if s := b.solvedBy[wID-b.nbInputs]; s < n {
+					n = s
+				}
+			}
+			if n == math.MaxInt64 {
+				continue
+			}
+			for _, wID := range h.Wires {
+				changed = lower(wID, n) || changed
+			}
+			for _, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					for _, tt := range t {
+						changed = lower(tt.WireID(), n) || changed
+					}
+				case compiled.Term:
+					changed = lower(t.WireID(), n) || changed
+				}
+			}
+		}
+	}
+}
+
 func (b *levelBuilder) processLE(l compiled.LinearExpression, cID int) {
 
 	for _, t := range l {
 		wID := t.WireID()
-		if wID < b.nbInputs {
+		i := b.slot(wID)
+		if i < 0 {
 			// it's a input, we ignore it
 			continue
 		}
 
-		// if we know a which constraint solves this wire, then it's a dependency
-		n, ok := b.mWireToNode[wID]
-		if ok {
-			if n != cID { // can happen with hints...
-				// we add a dependency, check if we need to increment our current level
-				if b.nodeLevels[n] >= b.nodeLevel {
-					b.nodeLevel = b.nodeLevels[n] + 1 // we are at the next level at least since we depend on it
-				}
+		// if a previous constraint solves this wire, then it's a dependency
+		if n := int(b.solvedBy[i]); n != cID {
+			// we add a dependency, check if we need to increment our current level
+			if b.nodeLevels[n] >= b.nodeLevel {
+				b.nodeLevel = b.nodeLevels[n] + 1 // we are at the next level at least since we depend on it
 			}
 			continue
 		}
 
-		// check if it's a hint and mark all the output wires
+		// the constraint solves this wire; if it's a hint, it depends on its inputs
 		if h, ok := b.ccs.MHints[wID]; ok {
+			if _, ok := b.expanded[h]; ok {
+				continue
+			}
+			b.expanded[h] = struct{}{}
 
 			for _, in := range h.Inputs {
 				switch t := in.(type) {
@@ -486,15 +690,7 @@ func (b *levelBuilder) processLE(l compiled.LinearExpression, cID int) {
 					b.processLE(compiled.LinearExpression{t}, cID)
 				}
 			}
-
-			for _, hwid := range h.Wires {
-				b.mWireToNode[hwid] = cID
-			}
-			continue
 		}
-
-		// mark this wire solved by current node
-		b.mWireToNode[wID] = cID
 	}
 }
 
@@ -599,9 +795,38 @@ func (system *r1cs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.V
 	if nbOutputs <= 0 {
 		return nil, fmt.Errorf("hint function must return at least one output")
 	}
+	return system.newHint(&compiled.Hint{ID: hint.UUID(f)}, hint.Name(f), nbOutputs, inputs)
+}
 
+// NewRichHint is like NewHint for a rich hint function; params are embedded
+// in the constraint system and given to f at solving time.
+func (system *r1cs) NewRichHint(f hint.RichFunction, nbOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	if nbOutputs <= 0 {
+		return nil, fmt.Errorf("hint function must return at least one output")
+	}
+	ch := &compiled.Hint{ID: hint.RichUUID(f), Rich: true, Params: append([]hint.Param(nil), params...)}
+	return system.newHint(ch, hint.RichName(f), nbOutputs, inputs)
+}
+
+// NewVariableHint is like NewRichHint, but f sets at most maxOutputs outputs;
+// an additional wire holds the number of outputs f set.
+func (system *r1cs) NewVariableHint(f hint.RichFunction, maxOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, frontend.Variable, error) {
+	if maxOutputs <= 0 {
+		return nil, nil, fmt.Errorf("hint function must return at least one output")
+	}
+	ch := &compiled.Hint{ID: hint.RichUUID(f), Rich: true, Params: append([]hint.Param(nil), params...), VariableOutputs: true}
+	res, err := system.newHint(ch, hint.RichName(f), maxOutputs+1, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res[:maxOutputs], res[maxOutputs], nil
+}
+
+// newHint registers the hint ch as a dependency, sets its inputs and creates
+// nbWires internal variables for its outputs
+func (system *r1cs) newHint(ch *compiled.Hint, hintID string, nbWires int, inputs []frontend.Variable) ([]frontend.Variable, error) {
 	// register the hint as dependency
-	hintUUID, hintID := hint.UUID(f), hint.Name(f)
+	hintUUID := ch.ID
 	if id, ok := system.MHintsDependencies[hintUUID]; ok {
 		// hint already registered, let's ensure string id matches
 		if id != hintID {
@@ -627,7 +852,7 @@ func (system *r1cs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.V
 	}
 
 	// prepare wires
-	varIDs := make([]int, nbOutputs)
+	varIDs := make([]int, nbWires)
 	res := make([]frontend.Variable, len(varIDs))
 	for i := range varIDs {
 		r := system.newInternalVariable()
@@ -636,10 +861,16 @@ func (system *r1cs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.V
 		res[i] = r
 	}
 
-	ch := &compiled.Hint{ID: hintUUID, Inputs: hintInputs, Wires: varIDs}
+	ch.Inputs, ch.Wires = hintInputs, varIDs
 	for _, vID := range varIDs {
 		system.MHints[vID] = ch
 	}
+	if system.config.HintDebugInfo {
+		debugID := system.AddDebugInfo("hint", hintID)
+		for _, vID := range varIDs {
+			system.MHintsDebug[vID] = debugID
+		}
+	}
 
 	return res, nil
 }
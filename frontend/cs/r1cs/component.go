@@ -0,0 +1,302 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package r1cs
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// component is the constraint template of a frontend.Component. It is defined
+// once in a child builder, in which the secret variables are the inputs of the
+// component, and copied in the constraint system at each call.
+type component struct {
+	child    *r1cs
+	name     string // type of the frontend.Component, prefix of the labels
+	outputs  []compiled.LinearExpression
+	booleans []bool // outputs marked as boolean in the child builder
+	coeffIDs []int  // ids of the coefficients of the child builder in the parent one
+}
+
+type componentKey struct {
+	c        frontend.Component
+	nbInputs int
+}
+
+// Call returns the outputs of c.Define on inputs (see frontend.Compiler)
+func (system *r1cs) Call(c frontend.Component, inputs ...frontend.Variable) []frontend.Variable {
+	vars, ok := system.toComponentInputs(inputs)
+	if !ok {
+		return c.Define(system, inputs...)
+	}
+
+	key := componentKey{c: c, nbInputs: len(inputs)}
+	t, ok := system.components[key]
+	if !ok {
+		t = system.newComponent(c, len(inputs))
+		system.components[key] = t
+	}
+	return system.stamp(t, vars)
+}
+
+// CallConcurrently returns the outputs of the calls (see frontend.Compiler)
+func (system *r1cs) CallConcurrently(calls ...frontend.ComponentCall) [][]frontend.Variable {
+	// components to define, in the order of their first call
+	var keys []componentKey
+	seen := make(map[componentKey]struct{})
+	for _, call := range calls {
+		if _, ok := system.toComponentInputs(call.Inputs); !ok {
+			continue
+		}
+		key := componentKey{c: call.Component, nbInputs: len(call.Inputs)}
+		if _, ok := system.components[key]; ok {
+			continue
+		}
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+
+	// the child builders only read the configuration of the parent
+	templates := make([]*component, len(keys))
+	panics := make([]interface{}, len(keys))
+	utils.Parallelize(len(keys), func(start, end int) {
+		for i := start; i < end; i++ {
+			func() {
+				defer func() {
+					panics[i] = recover()
+				}()
+				templates[i] = system.newComponent(keys[i].c, keys[i].nbInputs)
+			}()
+		}
+	}, system.config.NbTasks)
+	for i, key := range keys {
+		if panics[i] != nil {
+			panic(panics[i])
+		}
+		system.components[key] = templates[i]
+	}
+
+	res := make([][]frontend.Variable, len(calls))
+	for i, call := range calls {
+		res[i] = system.Call(call.Component, call.Inputs...)
+	}
+	return res
+}
+
+// toComponentInputs returns the linear expressions of inputs, and false if
+// one of them is a constant
+func (system *r1cs) toComponentInputs(inputs []frontend.Variable) ([]compiled.LinearExpression, bool) {
+	vars := make([]compiled.LinearExpression, len(inputs))
+	for i, in := range inputs {
+		v, ok := in.(compiled.LinearExpression)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := system.ConstantValue(v); ok {
+			return nil, false
+		}
+		assertIsSet(v)
+		vars[i] = v
+	}
+	return vars, true
+}
+
+// newComponent defines c in a child builder and returns the resulting template
+func (system *r1cs) newComponent(c frontend.Component, nbInputs int) *component {
+	config := system.config
+	config.Profile = nil
+	child := newBuilder(system.CurveID, config)
+	child.NbPublicVariables = 1
+	child.NbSecretVariables = nbInputs
+
+	inputs := make([]frontend.Variable, nbInputs)
+	for i := range inputs {
+		inputs[i] = compiled.LinearExpression{
+			compiled.Pack(i+1, compiled.CoeffIdOne, schema.Secret),
+		}
+	}
+	outputs, _ := child.toVariables(c.Define(child, inputs...)...)
+
+	t := &component{
+		child:    child,
+		name:     fmt.Sprintf("%T", c),
+		outputs:  outputs,
+		booleans: make([]bool, len(outputs)),
+	}
+	for i := range outputs {
+		t.booleans[i] = child.IsBoolean(outputs[i])
+	}
+	return t
+}
+
+// stamp copies the constraints, hints, logs and debug info of c in the
+// constraint system, with fresh internal variables, and returns the outputs
+func (system *r1cs) stamp(c *component, inputs []compiled.LinearExpression) []frontend.Variable {
+	child := c.child
+
+	// the coefficient table only grows, the ids of the previous stamps are valid
+	for i := len(c.coeffIDs); i < len(child.st.Coeffs); i++ {
+		c.coeffIDs = append(c.coeffIDs, system.st.CoeffID(&child.st.Coeffs[i]))
+	}
+
+	// internal variables of the child, in the parent
+	nbInputs := child.NbSecretVariables
+	offset := child.NbPublicVariables + nbInputs
+	wires := make([]int, child.NbInternalVariables)
+	for i := range wires {
+		_, wires[i], _ = system.newInternalVariable()[0].Unpack()
+	}
+
+	q := system.CurveID.Info().Fr.Modulus()
+	var coeff big.Int
+	// remapTerm returns the terms of the parent equal to the term t of the child
+	remapTerm := func(l compiled.LinearExpression, t compiled.Term) compiled.LinearExpression {
+		cID, vID, visibility := t.Unpack()
+		switch visibility {
+		case schema.Secret:
+			for _, in := range inputs[vID-child.NbPublicVariables] {
+				dID, dvID, dVisibility := in.Unpack()
+				coeff.Mul(&child.st.Coeffs[cID], &system.st.Coeffs[dID])
+				if !coeff.IsInt64() {
+					coeff.Mod(&coeff, q)
+				}
+				l = append(l, compiled.Pack(dvID, system.st.CoeffID(&coeff), dVisibility))
+			}
+			return l
+		case schema.Internal:
+			return append(l, compiled.Pack(wires[vID-offset], c.coeffIDs[cID], visibility))
+		default:
+			return append(l, compiled.Pack(vID, c.coeffIDs[cID], visibility))
+		}
+	}
+	remap := func(l compiled.LinearExpression) compiled.LinearExpression {
+		res := make(compiled.LinearExpression, 0, len(l))
+		for _, t := range l {
+			res = remapTerm(res, t)
+		}
+		return system.reduce(res)
+	}
+	remapLog := func(entry compiled.LogEntry) compiled.LogEntry {
+		res := compiled.LogEntry{Caller: entry.Caller, Format: entry.Format, Fields: entry.Fields}
+		res.ToResolve = make([]compiled.Term, 0, len(entry.ToResolve))
+		isEval := false
+		for _, t := range entry.ToResolve {
+			if t == compiled.TermDelimitor {
+				isEval = !isEval
+				res.ToResolve = append(res.ToResolve, t)
+				continue
+			}
+			if isEval || t.VariableVisibility() != schema.Secret {
+				res.ToResolve = remapTerm(res.ToResolve, t)
+				continue
+			}
+			// the format string holds a verb for the coefficient (if not ±1) and
+			// one for the value of the input, which is evaluated from its
+			// linear expression
+			if cID := t.CoeffID(); cID != compiled.CoeffIdOne && cID != compiled.CoeffIdMinusOne {
+				res.ToResolve = append(res.ToResolve, compiled.Pack(0, c.coeffIDs[cID], schema.Virtual))
+			}
+			t.SetCoeffID(compiled.CoeffIdOne)
+			res.ToResolve = append(res.ToResolve, compiled.TermDelimitor)
+			res.ToResolve = remapTerm(res.ToResolve, t)
+			res.ToResolve = append(res.ToResolve, compiled.TermDelimitor)
+		}
+		return res
+	}
+
+	// hints, sorted by their first output for a deterministic compilation
+	hints := make([]*compiled.Hint, 0, len(child.MHints))
+	for vID, h := range child.MHints {
+		if h.Wires[0] == vID {
+			hints = append(hints, h)
+		}
+	}
+	sort.Slice(hints, func(i, j int) bool { return hints[i].Wires[0] < hints[j].Wires[0] })
+	for _, h := range hints {
+		nh := &compiled.Hint{
+			ID:              h.ID,
+			Inputs:          make([]interface{}, len(h.Inputs)),
+			Wires:           make([]int, len(h.Wires)),
+			Rich:            h.Rich,
+			Params:          h.Params,
+			VariableOutputs: h.VariableOutputs,
+		}
+		for i, in := range h.Inputs {
+			if l, ok := in.(compiled.LinearExpression); ok {
+				nh.Inputs[i] = remap(l)
+			} else {
+				nh.Inputs[i] = in
+			}
+		}
+		for i, vID := range h.Wires {
+			nh.Wires[i] = wires[vID-offset]
+			system.MHints[nh.Wires[i]] = nh
+		}
+	}
+	for id, name := range child.MHintsDependencies {
+		system.MHintsDependencies[id] = name
+	}
+
+	// logs and debug info
+	for _, entry := range child.Logs {
+		system.Logs = append(system.Logs, remapLog(entry))
+	}
+	debugOffset := len(system.DebugInfo)
+	for _, entry := range child.DebugInfo {
+		system.DebugInfo = append(system.DebugInfo, remapLog(entry))
+	}
+	for vID, dID := range child.MHintsDebug {
+		system.MHintsDebug[wires[vID-offset]] = debugOffset + dID
+	}
+	// the labels are prefixed with the component and the index of the call, to
+	// tell apart the instances of a component
+	instance := system.nbComponentCalls[c.name]
+	system.nbComponentCalls[c.name]++
+	for vID, name := range child.MLabels {
+		// the inputs of the component are variables of the caller
+		if vID >= offset {
+			system.MLabels[wires[vID-offset]] = fmt.Sprintf("%s#%d/%s", c.name, instance, name)
+		}
+	}
+
+	// constraints
+	for i, r1c := range child.Constraints {
+		r1c = compiled.R1C{L: remap(r1c.L), R: remap(r1c.R), O: remap(r1c.O)}
+		if dID, ok := child.MDebug[i]; ok {
+			system.addConstraint(r1c, debugOffset+dID)
+		} else {
+			system.addConstraint(r1c)
+		}
+	}
+
+	res := make([]frontend.Variable, len(c.outputs))
+	for i := range c.outputs {
+		res[i] = remap(c.outputs[i])
+		if c.booleans[i] {
+			system.MarkBoolean(res[i])
+		}
+	}
+	return res
+}
@@ -27,7 +27,9 @@ import (
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
 	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/std/math/bits"
 )
 
@@ -45,8 +47,14 @@ func (system *scs) Add(i1, i2 frontend.Variable, in ...frontend.Variable) fronte
 	}
 	cl, _, _ := vars[0].Unpack()
 	kID := system.st.CoeffID(&k)
+	// the constant is recorded in the cache as a virtual term
+	kTerm := compiled.Pack(0, kID, schema.Virtual)
+	if res, ok := system.lookup(cs.OpAdd, 0, vars[0], kTerm); ok {
+		return system.splitSum(res[0].(compiled.Term), vars[1:])
+	}
 	o := system.newInternalVariable()
 	system.addPlonkConstraint(vars[0], system.zero(), o, cl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdMinusOne, kID)
+	system.store([]frontend.Variable{o}, cs.OpAdd, 0, vars[0], kTerm)
 	return system.splitSum(o, vars[1:])
 
 }
@@ -132,12 +140,16 @@ func (system *scs) DivUnchecked(i1, i2 frontend.Variable) frontend.Variable {
 		return system.mulConstant(res.(compiled.Term), c1)
 	}
 
+	if res, ok := system.lookup(cs.OpDivUnchecked, 0, i1.(compiled.Term), i2.(compiled.Term)); ok {
+		return res[0]
+	}
 	res := system.newInternalVariable()
 	r := i2.(compiled.Term)
 	o := system.Neg(i1).(compiled.Term)
 	cr, _, _ := r.Unpack()
 	co, _, _ := o.Unpack()
 	system.addPlonkConstraint(res, r, o, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, cr, co, compiled.CoeffIdZero)
+	system.store([]frontend.Variable{res}, cs.OpDivUnchecked, 0, i1.(compiled.Term), r)
 	return res
 }
 
@@ -157,10 +169,14 @@ func (system *scs) Inverse(i1 frontend.Variable) frontend.Variable {
 		return c
 	}
 	t := i1.(compiled.Term)
+	if res, ok := system.lookup(cs.OpInverse, 0, t); ok {
+		return res[0]
+	}
 	cr, _, _ := t.Unpack()
 	debug := system.AddDebugInfo("inverse", "1/", i1, " < ∞")
 	res := system.newInternalVariable()
 	system.addPlonkConstraint(res, t, system.zero(), compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, cr, compiled.CoeffIdZero, compiled.CoeffIdMinusOne, debug)
+	system.store([]frontend.Variable{res}, cs.OpInverse, 0, t)
 	return res
 }
 
@@ -182,7 +198,17 @@ func (system *scs) ToBinary(i1 frontend.Variable, n ...int) []frontend.Variable
 		}
 	}
 
-	return bits.ToBinary(system, i1, bits.WithNbDigits(nbBits))
+	t, ok := i1.(compiled.Term)
+	if !ok {
+		return bits.ToBinary(system, i1, bits.WithNbDigits(nbBits))
+	}
+
+	if res, ok := system.lookup(cs.OpToBinary, nbBits, t); ok {
+		return res
+	}
+	res := bits.ToBinary(system, t, bits.WithNbDigits(nbBits))
+	system.store(res, cs.OpToBinary, nbBits, t)
+	return res
 }
 
 // FromBinary packs b, seen as a fr.Element in little endian
@@ -200,6 +226,11 @@ func (system *scs) Xor(a, b frontend.Variable) frontend.Variable {
 		_a.Xor(_a, _b)
 		return _a
 	}
+	if !aConstant && !bConstant {
+		if res, ok := system.lookup(cs.OpXor, 0, a.(compiled.Term), b.(compiled.Term)); ok {
+			return res[0]
+		}
+	}
 	res := system.newInternalVariable()
 	if aConstant {
 		a, b = b, a
@@ -218,6 +249,7 @@ func (system *scs) Xor(a, b frontend.Variable) frontend.Variable {
 	l := a.(compiled.Term)
 	r := b.(compiled.Term)
 	system.addPlonkConstraint(l, r, res, compiled.CoeffIdMinusOne, compiled.CoeffIdMinusOne, compiled.CoeffIdTwo, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdZero)
+	system.store([]frontend.Variable{res}, cs.OpXor, 0, l, r)
 	return res
 }
 
@@ -231,6 +263,11 @@ func (system *scs) Or(a, b frontend.Variable) frontend.Variable {
 		_a.Or(_a, _b)
 		return _a
 	}
+	if !aConstant && !bConstant {
+		if res, ok := system.lookup(cs.OpOr, 0, a.(compiled.Term), b.(compiled.Term)); ok {
+			return res[0]
+		}
+	}
 	res := system.newInternalVariable()
 	if aConstant {
 		a, b = b, a
@@ -257,6 +294,7 @@ func (system *scs) Or(a, b frontend.Variable) frontend.Variable {
 	system.AssertIsBoolean(l)
 	system.AssertIsBoolean(r)
 	system.addPlonkConstraint(l, r, res, compiled.CoeffIdMinusOne, compiled.CoeffIdMinusOne, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdZero)
+	system.store([]frontend.Variable{res}, cs.OpOr, 0, l, r)
 	return res
 }
 
@@ -361,6 +399,9 @@ func (system *scs) IsZero(i1 frontend.Variable) frontend.Variable {
 	// a * m = 0            // constrain m to be 0 if a != 0
 	// _ = inverse(m + a) 	// constrain m to be 1 if a == 0
 	a := i1.(compiled.Term)
+	if res, ok := system.lookup(cs.OpIsZero, 0, a); ok {
+		return res[0]
+	}
 	res, err := system.NewHint(hint.IsZero, 1, a)
 	if err != nil {
 		// the function errs only if the number of inputs is invalid.
@@ -371,6 +412,7 @@ func (system *scs) IsZero(i1 frontend.Variable) frontend.Variable {
 	system.addPlonkConstraint(a, m.(compiled.Term), system.zero(), compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, compiled.CoeffIdOne, compiled.CoeffIdZero, compiled.CoeffIdZero)
 	ma := system.Add(m, a)
 	system.Inverse(ma)
+	system.store([]frontend.Variable{m}, cs.OpIsZero, 0, a)
 	return m
 }
 
@@ -440,6 +482,51 @@ func (system *scs) Println(a ...frontend.Variable) {
 	system.Logs = append(system.Logs, log)
 }
 
+// Printf behaves like fmt.Printf with the verbs documented in frontend.API,
+// the values of the variables being resolved when the SparseR1CS.Solve() method is
+// executed.
+func (system *scs) Printf(format string, a ...frontend.Variable) {
+	args := make([]interface{}, len(a))
+	for i := range a {
+		args[i] = a[i]
+	}
+	tokens, err := compiled.ExpandPrintf(format, args, tVariable)
+	if err != nil {
+		panic(err)
+	}
+
+	var log compiled.LogEntry
+
+	// prefix log line with file.go:line
+	if _, file, line, ok := runtime.Caller(1); ok {
+		log.Caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	var sbb strings.Builder
+	for _, t := range tokens {
+		if t.Directive == "" {
+			sbb.WriteString(t.Text)
+			continue
+		}
+		v, ok := t.Value.(compiled.Term)
+		if !ok {
+			// constants are resolved by the solver too, so that they are
+			// formatted as the variables
+			c := utils.FromInterface(t.Value)
+			v = compiled.Pack(0, system.st.CoeffID(&c), schema.Virtual)
+		}
+
+		sbb.WriteString(t.Directive)
+		log.ToResolve = append(log.ToResolve, compiled.TermDelimitor)
+		log.ToResolve = append(log.ToResolve, v)
+		log.ToResolve = append(log.ToResolve, compiled.TermDelimitor)
+		log.Fields = append(log.Fields, t.Name)
+	}
+	log.Format = sbb.String()
+
+	system.Logs = append(system.Logs, log)
+}
+
 func printArg(log *compiled.LogEntry, sbb *strings.Builder, a frontend.Variable) {
 
 	count := 0
@@ -19,6 +19,7 @@ package scs
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"path/filepath"
 	"reflect"
@@ -26,6 +27,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
@@ -33,6 +35,7 @@ import (
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/cs/optimizer"
 	"github.com/consensys/gnark/frontend/schema"
 	bls12377r1cs "github.com/consensys/gnark/internal/backend/bls12-377/cs"
 	bls12381r1cs "github.com/consensys/gnark/internal/backend/bls12-381/cs"
@@ -57,6 +60,18 @@ type scs struct {
 
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[int]struct{}
+
+	// results of the operations emitting constraints (to not emit them twice)
+	cache cs.ExpressionCache
+
+	// call stacks of the constraints and variables (nil if not profiling)
+	profiler *cs.Profiler
+
+	// constraint templates of the components (see Call)
+	components map[componentKey]*component
+
+	// number of calls of each component type, to prefix the labels (see stamp)
+	nbComponentCalls map[string]int
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -67,11 +82,16 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *scs {
 			MDebug:             make(map[int]int),
 			MHints:             make(map[int]*compiled.Hint),
 			MHintsDependencies: make(map[hint.ID]string),
+			MHintsDebug:        make(map[int]int),
+			MLabels:            make(map[int]string),
 		},
-		mtBooleans:  make(map[int]struct{}),
-		Constraints: make([]compiled.SparseR1C, 0, config.Capacity),
-		st:          cs.NewCoeffTable(),
-		config:      config,
+		mtBooleans:       make(map[int]struct{}),
+		cache:            cs.NewExpressionCache(),
+		components:       make(map[componentKey]*component),
+		nbComponentCalls: make(map[string]int),
+		Constraints:      make([]compiled.SparseR1C, 0, config.Capacity),
+		st:               cs.NewCoeffTable(),
+		config:           config,
 	}
 
 	system.Public = make([]string, 0)
@@ -79,6 +99,10 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *scs {
 
 	system.CurveID = curveID
 
+	if config.Profile != nil {
+		system.profiler = cs.NewProfiler()
+	}
+
 	return &system
 }
 
@@ -86,10 +110,6 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *scs {
 //func (system *SparseR1CS) addPlonkConstraint(l, r, o frontend.Variable, cidl, cidr, cidm1, cidm2, cido, k int, debugID ...int) {
 func (system *scs) addPlonkConstraint(l, r, o compiled.Term, cidl, cidr, cidm1, cidm2, cido, k int, debugID ...int) {
 
-	if len(debugID) > 0 {
-		system.MDebug[len(system.Constraints)] = debugID[0]
-	}
-
 	l.SetCoeffID(cidl)
 	r.SetCoeffID(cidr)
 	o.SetCoeffID(cido)
@@ -100,7 +120,17 @@ func (system *scs) addPlonkConstraint(l, r, o compiled.Term, cidl, cidr, cidm1,
 	v.SetCoeffID(cidm2)
 
 	//system.Constraints = append(system.Constraints, compiled.SparseR1C{L: _l, R: _r, O: _o, M: [2]compiled.Term{u, v}, K: k})
-	system.Constraints = append(system.Constraints, compiled.SparseR1C{L: l, R: r, O: o, M: [2]compiled.Term{u, v}, K: k})
+	system.addConstraint(compiled.SparseR1C{L: l, R: r, O: o, M: [2]compiled.Term{u, v}, K: k}, debugID...)
+}
+
+func (system *scs) addConstraint(c compiled.SparseR1C, debugID ...int) {
+	if len(debugID) > 0 {
+		system.MDebug[len(system.Constraints)] = debugID[0]
+	}
+	system.Constraints = append(system.Constraints, c)
+	if system.profiler != nil {
+		system.profiler.RecordConstraint()
+	}
 }
 
 // newInternalVariable creates a new wire, appends it on the list of wires of the circuit, sets
@@ -108,6 +138,9 @@ func (system *scs) addPlonkConstraint(l, r, o compiled.Term, cidl, cidr, cidm1,
 func (system *scs) newInternalVariable() compiled.Term {
 	idx := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
 	system.NbInternalVariables++
+	if system.profiler != nil {
+		system.profiler.RecordVariable()
+	}
 	return compiled.Pack(idx, compiled.CoeffIdOne, schema.Internal)
 }
 
@@ -118,6 +151,12 @@ func (system *scs) AddPublicVariable(name string) frontend.Variable {
 	return compiled.Pack(idx, compiled.CoeffIdOne, schema.Public)
 }
 
+// AddOutputVariable creates a new Public Variable computed by the solver
+func (system *scs) AddOutputVariable(name string) frontend.Variable {
+	system.Outputs = append(system.Outputs, len(system.Public))
+	return system.AddPublicVariable(name)
+}
+
 // AddSecretVariable creates a new Secret Variable
 func (system *scs) AddSecretVariable(name string) frontend.Variable {
 	idx := len(system.Secret) + system.NbPublicVariables
@@ -151,6 +190,32 @@ func (system *scs) reduce(l compiled.LinearExpression) compiled.LinearExpression
 	return l
 }
 
+// lookup returns the result of a previous call to op on the same operands, unless
+// the common subexpression elimination is disabled
+func (system *scs) lookup(op cs.Op, n int, operands ...compiled.Term) ([]frontend.Variable, bool) {
+	if system.config.NoCommonSubexpressionElimination {
+		return nil, false
+	}
+	return system.cache.Lookup(op, n, system.canonical(operands)...)
+}
+
+// store records result as the result of op on operands (see lookup)
+func (system *scs) store(result []frontend.Variable, op cs.Op, n int, operands ...compiled.Term) {
+	if system.config.NoCommonSubexpressionElimination {
+		return
+	}
+	system.cache.Store(result, op, n, system.canonical(operands)...)
+}
+
+// canonical returns the operands as linear expressions
+func (system *scs) canonical(operands []compiled.Term) []compiled.LinearExpression {
+	res := make([]compiled.LinearExpression, len(operands))
+	for i := range operands {
+		res[i] = compiled.LinearExpression{operands[i]}
+	}
+	return res
+}
+
 // to handle wires that don't exist (=coef 0) in a sparse constraint
 func (system *scs) zero() compiled.Term {
 	var a compiled.Term
@@ -180,6 +245,15 @@ func (system *scs) MarkBoolean(v frontend.Variable) {
 	system.mtBooleans[int(v.(compiled.Term))] = struct{}{}
 }
 
+// Label names the wire of v, see frontend.Compiler
+func (system *scs) Label(v frontend.Variable, name string) {
+	t, ok := v.(compiled.Term)
+	if !ok || t.CoeffID() != compiled.CoeffIdOne {
+		return
+	}
+	system.MLabels[t.WireID()] = name
+}
+
 // checkVariables perform post compilation checks on the Variables
 //
 // 1. checks that all user inputs are referenced in at least one constraint
@@ -199,10 +273,15 @@ func (system *scs) checkVariables() error {
 		return nil
 	}
 
-	secretConstrained := make([]bool, cptSecret)
-	publicConstrained := make([]bool, cptPublic)
-
-	mHintsConstrained := make(map[int]bool)
+	// the constraints are processed concurrently, the flags are set atomically
+	secretConstrained := make([]uint32, cptSecret)
+	publicConstrained := make([]uint32, cptPublic)
+	internalConstrained := make([]uint32, system.NbInternalVariables)
+	mark := func(flags []uint32, i int) {
+		if atomic.LoadUint32(&flags[i]) == 0 {
+			atomic.StoreUint32(&flags[i], 1)
+		}
+	}
 
 	// for each constraint, we check the terms and mark our inputs / hints as constrained
 	processTerm := func(t compiled.Term) {
@@ -213,39 +292,36 @@ func (system *scs) checkVariables() error {
 		if t.CoeffID() != compiled.CoeffIdZero {
 			switch visibility {
 			case schema.Public:
-				if !publicConstrained[vID] {
-					publicConstrained[vID] = true
-					cptPublic--
-				}
+				mark(publicConstrained, vID)
 			case schema.Secret:
-				vID -= system.NbPublicVariables
-				if !secretConstrained[vID] {
-					secretConstrained[vID] = true
-					cptSecret--
-				}
+				mark(secretConstrained, vID-system.NbPublicVariables)
 			case schema.Internal:
-				if _, ok := system.MHints[vID]; ok {
-					vID -= (system.NbPublicVariables + system.NbSecretVariables)
-					if !mHintsConstrained[vID] {
-						mHintsConstrained[vID] = true
-						cptHints--
-					}
-
-				}
+				mark(internalConstrained, vID-(system.NbPublicVariables+system.NbSecretVariables))
 			}
 		}
 
 	}
-	for _, c := range system.Constraints {
-		processTerm(c.L)
-		processTerm(c.R)
-		processTerm(c.M[0])
-		processTerm(c.M[1])
-		processTerm(c.O)
-		if cptHints|cptSecret|cptPublic == 0 {
-			return nil // we can stop.
+	utils.Parallelize(len(system.Constraints), func(start, end int) {
+		for _, c := range system.Constraints[start:end] {
+			processTerm(c.L)
+			processTerm(c.R)
+			processTerm(c.M[0])
+			processTerm(c.M[1])
+			processTerm(c.O)
 		}
+	}, system.config.NbTasks)
 
+	for _, c := range secretConstrained {
+		cptSecret -= int(c)
+	}
+	for _, c := range publicConstrained {
+		cptPublic -= int(c)
+	}
+	for vID := range system.MHints {
+		cptHints -= int(internalConstrained[vID-(system.NbPublicVariables+system.NbSecretVariables)])
+	}
+	if cptHints|cptSecret|cptPublic == 0 {
+		return nil
 	}
 
 	// something is a miss, we build the error string
@@ -255,7 +331,7 @@ func (system *scs) checkVariables() error {
 		sbb.WriteString(" unconstrained secret input(s):")
 		sbb.WriteByte('\n')
 		for i := 0; i < len(secretConstrained) && cptSecret != 0; i++ {
-			if !secretConstrained[i] {
+			if secretConstrained[i] == 0 {
 				sbb.WriteString(system.Secret[i])
 				sbb.WriteByte('\n')
 				cptSecret--
@@ -269,7 +345,7 @@ func (system *scs) checkVariables() error {
 		sbb.WriteString(" unconstrained public input(s):")
 		sbb.WriteByte('\n')
 		for i := 0; i < len(publicConstrained) && cptPublic != 0; i++ {
-			if !publicConstrained[i] {
+			if publicConstrained[i] == 0 {
 				sbb.WriteString(system.Public[i])
 				sbb.WriteByte('\n')
 				cptPublic--
@@ -301,6 +377,13 @@ func (cs *scs) Compile() (frontend.CompiledConstraintSystem, error) {
 		Int("nbConstraints", len(cs.Constraints)).
 		Msg("building constraint system")
 
+	// write the profile of the circuit definition, if any
+	if cs.profiler != nil {
+		if err := cs.profiler.Write(cs.config.Profile); err != nil {
+			return nil, fmt.Errorf("write profile: %w", err)
+		}
+	}
+
 	// ensure all inputs and hints are constrained
 	err := cs.checkVariables()
 	if err != nil {
@@ -322,8 +405,19 @@ func (cs *scs) Compile() (frontend.CompiledConstraintSystem, error) {
 		panic("number of secret variables is inconsitent") // it grew after the schema parsing?
 	}
 
+	// run the optimizer passes, if any
+	if cs.config.Optimizations != 0 {
+		for _, stats := range optimizer.SparseR1CS(&res, &cs.st, cs.config.Optimizations) {
+			log.Info().
+				Str("pass", stats.Pass.String()).
+				Int("nbConstraints", stats.NbConstraints).
+				Int("nbVariables", stats.NbVariables).
+				Msg("optimized constraint system")
+		}
+	}
+
 	// build levels
-	res.Levels = buildLevels(res)
+	res.Levels = buildLevels(res, cs.config.NbTasks)
 
 	switch cs.CurveID {
 	case ecc.BLS12_377:
@@ -353,20 +447,48 @@ func (cs *scs) SetSchema(s *schema.Schema) {
 	cs.NbSecretVariables = s.NbSecret
 }
 
-func buildLevels(ccs compiled.SparseR1CS) [][]int {
+// buildLevels groups the constraints by level: a constraint only depends on
+// the wires solved by the constraints of the previous levels, so that the
+// solver can process the constraints of a level concurrently.
+//
+// An internal wire or an output is solved by the first constraint referencing
+// it; the hint outputs are solved together, by the first constraint
+// referencing one of them, which then also depends on the hint inputs. The
+// first references are found concurrently, the levels are then assigned in
+// order.
+func buildLevels(ccs compiled.SparseR1CS, nbTasks int) [][]int {
 
 	b := levelBuilder{
-		mWireToNode: make(map[int]int, ccs.NbInternalVariables), // at which node we resolved which wire
-		nodeLevels:  make([]int, len(ccs.Constraints)),          // level of a node
-		mLevels:     make(map[int]int),                          // level counts
-		ccs:         ccs,
-		nbInputs:    ccs.NbPublicVariables + ccs.NbSecretVariables,
-	}
+		ccs:        ccs,
+		nbInputs:   ccs.NbPublicVariables + ccs.NbSecretVariables,
+		solvedBy:   make([]int64, ccs.NbInternalVariables+len(ccs.Outputs)),
+		outputs:    make(map[int]int, len(ccs.Outputs)),
+		nodeLevels: make([]int, len(ccs.Constraints)),
+		expanded:   make(map[*compiled.Hint]struct{}),
+	}
+	for i := range b.solvedBy {
+		b.solvedBy[i] = math.MaxInt64
+	}
+	for i, wID := range ccs.Outputs {
+		b.outputs[wID] = ccs.NbInternalVariables + i
+	}
+
+	// first constraint referencing each wire
+	utils.Parallelize(len(ccs.Constraints), func(start, end int) {
+		for cID := start; cID < end; cID++ {
+			c := &ccs.Constraints[cID]
+			b.markTerm(c.L, cID)
+			b.markTerm(c.R, cID)
+			b.markTerm(c.O, cID)
+		}
+	}, nbTasks)
+	b.markHints()
 
 	// for each constraint, we're going to find its direct dependencies
 	// that is, wires (solved by previous constraints) on which it depends
 	// each of these dependencies is tagged with a level
 	// current constraint will be tagged with max(level) + 1
+	mLevels := make(map[int]int) // number of constraints per level
 	for cID, c := range ccs.Constraints {
 
 		b.nodeLevel = 0
@@ -376,14 +498,14 @@ func buildLevels(ccs compiled.SparseR1CS) [][]int {
 		b.processTerm(c.O, cID)
 
 		b.nodeLevels[cID] = b.nodeLevel
-		b.mLevels[b.nodeLevel]++
+		mLevels[b.nodeLevel]++
 
 	}
 
-	levels := make([][]int, len(b.mLevels))
+	levels := make([][]int, len(mLevels))
 	for i := 0; i < len(levels); i++ {
 		// allocate memory
-		levels[i] = make([]int, 0, b.mLevels[i])
+		levels[i] = make([]int, 0, mLevels[i])
 	}
 
 	for n, l := range b.nodeLevels {
@@ -397,34 +519,125 @@ type levelBuilder struct {
 	ccs      compiled.SparseR1CS
 	nbInputs int
 
-	mWireToNode map[int]int // at which node we resolved which wire
-	nodeLevels  []int       // level per node
-	mLevels     map[int]int // number of constraint per level
+	solvedBy   []int64                     // constraint solving each internal wire, then each output
+	outputs    map[int]int                 // index in solvedBy of each output wire
+	nodeLevels []int                       // level per node
+	expanded   map[*compiled.Hint]struct{} // hints whose inputs were processed
 
 	nodeLevel int // current level
 }
 
+// slot returns the index in solvedBy of the wire wID, or -1 if wID is an input
+func (b *levelBuilder) slot(wID int) int {
+	if wID >= b.nbInputs {
+		if wID-b.nbInputs >= b.ccs.NbInternalVariables {
+			return -1
+		}
+		return wID - b.nbInputs
+	}
+	if i, ok := b.outputs[wID]; ok {
+		return i
+	}
+	return -1
+}
+
+// termSlot returns the index in solvedBy of the wire of t, or -1 if t
+// references an input or no wire
+func (b *levelBuilder) termSlot(t compiled.Term) int {
+	// the terms which reference no wire have the wire 0, which may be an output
+	if t.VariableVisibility() == schema.Unset {
+		return -1
+	}
+	return b.slot(t.WireID())
+}
+
+// markTerm records that the constraint cID references the wire of t
+func (b *levelBuilder) markTerm(t compiled.Term, cID int) {
+	wID := b.termSlot(t)
+	if wID < 0 {
+		// it's a input, we ignore it
+		return
+	}
+	for {
+		n := atomic.LoadInt64(&b.solvedBy[wID])
+		if n <= int64(cID) || atomic.CompareAndSwapInt64(&b.solvedBy[wID], n, int64(cID)) {
+			return
+		}
+	}
+}
+
+// markHints sets the wires of a hint as solved by the first constraint
+// referencing one of them, and propagates it to the hint inputs
+func (b *levelBuilder) markHints() {
+	hints := make([]*compiled.Hint, 0, len(b.ccs.MHints))
+	for wID, h := range b.ccs.MHints {
+		if h.Wires[0] == wID {
+			hints = append(hints, h)
+		}
+	}
+	// the inputs of a hint are usually wires created before its outputs; the
+	// hints are processed from the last one until nothing changes
+	sort.Slice(hints, func(i, j int) bool { return hints[i].Wires[0] > hints[j].Wires[0] })
+
+	lower := func(i int, n int64) bool {
+		if i < 0 || b.solvedBy[i] <= n {
+			return false
+		}
+		b.solvedBy[i] = n
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, h := range hints {
+			n := int64(math.MaxInt64)
+			for _, wID := range h.Wires {
+				if s := b.solvedBy[wID-b.nbInputs]; s < n {
+					n = s
+				}
+			}
+			if n == math.MaxInt64 {
+				continue
+			}
+			for _, wID := range h.Wires {
+				changed = lower(b.slot(wID), n) || changed
+			}
+			for _, in := range h.Inputs {
+				switch t := in.(type) {
+				case compiled.LinearExpression:
+					for _, tt := range t {
+						changed = lower(b.termSlot(tt), n) || changed
+					}
+				case compiled.Term:
+					changed = lower(b.termSlot(t), n) || changed
+				}
+			}
+		}
+	}
+}
+
 func (b *levelBuilder) processTerm(t compiled.Term, cID int) {
 	wID := t.WireID()
-	if wID < b.nbInputs {
+	i := b.termSlot(t)
+	if i < 0 {
 		// it's a input, we ignore it
 		return
 	}
 
-	// if we know a which constraint solves this wire, then it's a dependency
-	n, ok := b.mWireToNode[wID]
-	if ok {
-		if n != cID { // can happen with hints...
-			// we add a dependency, check if we need to increment our current level
-			if b.nodeLevels[n] >= b.nodeLevel {
-				b.nodeLevel = b.nodeLevels[n] + 1 // we are at the next level at least since we depend on it
-			}
+	// if a previous constraint solves this wire, then it's a dependency
+	if n := int(b.solvedBy[i]); n != cID {
+		// we add a dependency, check if we need to increment our current level
+		if b.nodeLevels[n] >= b.nodeLevel {
+			b.nodeLevel = b.nodeLevels[n] + 1 // we are at the next level at least since we depend on it
 		}
 		return
 	}
 
-	// check if it's a hint and mark all the output wires
+	// the constraint solves this wire; if it's a hint, it depends on its inputs
 	if h, ok := b.ccs.MHints[wID]; ok {
+		if _, ok := b.expanded[h]; ok {
+			return
+		}
+		b.expanded[h] = struct{}{}
 
 		for _, in := range h.Inputs {
 			switch t := in.(type) {
@@ -436,17 +649,8 @@ func (b *levelBuilder) processTerm(t compiled.Term, cID int) {
 				b.processTerm(t, cID)
 			}
 		}
-
-		for _, hwid := range h.Wires {
-			b.mWireToNode[hwid] = cID
-		}
-
-		return
 	}
 
-	// mark this wire solved by current node
-	b.mWireToNode[wID] = cID
-
 }
 
 // ConstantValue returns the big.Int value of v. It
@@ -507,9 +711,38 @@ func (system *scs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.Va
 	if nbOutputs <= 0 {
 		return nil, fmt.Errorf("hint function must return at least one output")
 	}
+	return system.newHint(&compiled.Hint{ID: hint.UUID(f)}, hint.Name(f), nbOutputs, inputs)
+}
 
+// NewRichHint is like NewHint for a rich hint function; params are embedded
+// in the constraint system and given to f at solving time.
+func (system *scs) NewRichHint(f hint.RichFunction, nbOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	if nbOutputs <= 0 {
+		return nil, fmt.Errorf("hint function must return at least one output")
+	}
+	ch := &compiled.Hint{ID: hint.RichUUID(f), Rich: true, Params: append([]hint.Param(nil), params...)}
+	return system.newHint(ch, hint.RichName(f), nbOutputs, inputs)
+}
+
+// NewVariableHint is like NewRichHint, but f sets at most maxOutputs outputs;
+// an additional wire holds the number of outputs f set.
+func (system *scs) NewVariableHint(f hint.RichFunction, maxOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, frontend.Variable, error) {
+	if maxOutputs <= 0 {
+		return nil, nil, fmt.Errorf("hint function must return at least one output")
+	}
+	ch := &compiled.Hint{ID: hint.RichUUID(f), Rich: true, Params: append([]hint.Param(nil), params...), VariableOutputs: true}
+	res, err := system.newHint(ch, hint.RichName(f), maxOutputs+1, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res[:maxOutputs], res[maxOutputs], nil
+}
+
+// newHint registers the hint ch as a dependency, sets its inputs and creates
+// nbWires internal variables for its outputs
+func (system *scs) newHint(ch *compiled.Hint, hintID string, nbWires int, inputs []frontend.Variable) ([]frontend.Variable, error) {
 	// register the hint as dependency
-	hintUUID, hintID := hint.UUID(f), hint.Name(f)
+	hintUUID := ch.ID
 	if id, ok := system.MHintsDependencies[hintUUID]; ok {
 		// hint already registered, let's ensure string id matches
 		if id != hintID {
@@ -532,7 +765,7 @@ func (system *scs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.Va
 	}
 
 	// prepare wires
-	varIDs := make([]int, nbOutputs)
+	varIDs := make([]int, nbWires)
 	res := make([]frontend.Variable, len(varIDs))
 	for i := range varIDs {
 		r := system.newInternalVariable()
@@ -541,10 +774,16 @@ func (system *scs) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.Va
 		res[i] = r
 	}
 
-	ch := &compiled.Hint{ID: hintUUID, Inputs: hintInputs, Wires: varIDs}
+	ch.Inputs, ch.Wires = hintInputs, varIDs
 	for _, vID := range varIDs {
 		system.MHints[vID] = ch
 	}
+	if system.config.HintDebugInfo {
+		debugID := system.AddDebugInfo("hint", hintID)
+		for _, vID := range varIDs {
+			system.MHintsDebug[vID] = debugID
+		}
+	}
 
 	return res, nil
 }
@@ -589,10 +828,15 @@ func (system *scs) splitSum(acc compiled.Term, r compiled.LinearExpression) comp
 		return acc
 	}
 
+	if res, ok := system.lookup(cs.OpAdd, 0, acc, r[0]); ok {
+		return system.splitSum(res[0].(compiled.Term), r[1:])
+	}
+
 	cl, _, _ := acc.Unpack()
 	cr, _, _ := r[0].Unpack()
 	o := system.newInternalVariable()
 	system.addPlonkConstraint(acc, r[0], o, cl, cr, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdMinusOne, compiled.CoeffIdZero)
+	system.store([]frontend.Variable{o}, cs.OpAdd, 0, acc, r[0])
 	return system.splitSum(o, r[1:])
 }
 
@@ -603,9 +847,14 @@ func (system *scs) splitProd(acc compiled.Term, r compiled.LinearExpression) com
 		return acc
 	}
 
+	if res, ok := system.lookup(cs.OpMul, 0, acc, r[0]); ok {
+		return system.splitProd(res[0].(compiled.Term), r[1:])
+	}
+
 	cl, _, _ := acc.Unpack()
 	cr, _, _ := r[0].Unpack()
 	o := system.newInternalVariable()
 	system.addPlonkConstraint(acc, r[0], o, compiled.CoeffIdZero, compiled.CoeffIdZero, cl, cr, compiled.CoeffIdMinusOne, compiled.CoeffIdZero)
+	system.store([]frontend.Variable{o}, cs.OpMul, 0, acc, r[0])
 	return system.splitProd(o, r[1:])
 }
@@ -0,0 +1,182 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package csio exports and imports compiled constraint systems in formats
+// readable by external tools.
+//
+// WriteJSON and ReadJSON use a JSON document describing the wires, their
+// names, the coefficients, the constraints and the hints of an R1CS or a
+// SparseR1CS:
+//
+//	{
+//		"curve": "BN254",
+//		"modulus": "2188824287...",
+//		"system": "r1cs",               // or "sparse_r1cs"
+//		"nbPublic": 2,                  // the R1CS one wire is public wire 0
+//		"nbSecret": 1,
+//		"nbInternal": 3,
+//		"public": ["one", "Y"],
+//		"secret": ["X"],
+//		"outputs": [1],                 // public wires computed by the solver, if any
+//		"schema": {...},                // the circuit schema.Schema, as encoded by encoding/json
+//		"coefficients": ["0", "1", "2", "2188824287...", ...],
+//		"constraints": [
+//			{"l": [[2, 1]], "r": [[2, 1]], "o": [[3, 1]]},
+//			...
+//		],
+//		"hints": [
+//			{"id": 1234, "name": "github.com/.../hint.IsZero", "inputs": [{"terms": [[2, 1]]}], "outputs": [4]},
+//			...
+//		],
+//		"levels": [[0, 2], [1]]
+//	}
+//
+// The wires are numbered public, then secret, then internal. A term is a pair
+// [wire, coefficient] where coefficient is an index in "coefficients"; the
+// wire is -1 if the term references no wire. The coefficients are decimal
+// strings reduced modulo the field modulus, and the first four are always 0,
+// 1, 2 and -1. An R1CS constraint L·R == O lists the terms of each linear
+// expression; a SparseR1CS constraint qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0
+// has a single term in "l", "r" and "o", the two factors of qM⋅(xaxb) in "m"
+// and the index of qC in "k". The inputs of a hint are linear expressions
+// ("terms"), a single term ("term") or a decimal "constant"; its outputs are
+// the wires it computes. A rich hint (see hint.RichFunction) also has "rich":
+// true and its constant "params", each a {"type": ..., "value": ...} pair with
+// the value as a string (hexadecimal for bytes); if "variableOutputs" is set,
+// the last output is the wire holding the number of outputs set by the hint.
+// "levels" lists the constraints the solver processes in parallel, level
+// after level. The logs and the debug info are not exported.
+//
+// WriteR1CS and ReadR1CS use the iden3 binary .r1cs format of circom and
+// snarkjs (https://github.com/iden3/r1csfile/blob/master/doc/r1cs_bin_format.md).
+// The format can't describe the hints: a constraint system read from a .r1cs
+// file has no internal wires, the wires which are not public are secret
+// inputs, and a witness must provide the values of all of them, as circom's
+// witness calculator does.
+package csio
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	bls12377r1cs "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	bls12381r1cs "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	bls24315r1cs "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+	bw6633r1cs "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	bw6761r1cs "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+)
+
+var errUnsupportedSystem = errors.New("unsupported constraint system")
+
+// unwrap returns the R1CS or the SparseR1CS of ccs, and its coefficients in
+// regular form
+func unwrap(ccs frontend.CompiledConstraintSystem) (*compiled.R1CS, *compiled.SparseR1CS, []big.Int, error) {
+	switch c := ccs.(type) {
+	case *bls12377r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bls12381r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bn254r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bw6761r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bls24315r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bw6633r1cs.R1CS:
+		return &c.R1CS, nil, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bls12377r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bls12381r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bn254r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bw6761r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bls24315r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	case *bw6633r1cs.SparseR1CS:
+		return nil, &c.SparseR1CS, coefficients(len(c.Coefficients), func(i int, v *big.Int) { c.Coefficients[i].ToBigIntRegular(v) }), nil
+	default:
+		return nil, nil, nil, errUnsupportedSystem
+	}
+}
+
+// coefficients returns the n coefficients of a constraint system, in regular
+// form
+func coefficients(n int, get func(i int, v *big.Int)) []big.Int {
+	res := make([]big.Int, n)
+	for i := range res {
+		get(i, &res[i])
+	}
+	return res
+}
+
+// newR1CS returns the R1CS of the curve with the given coefficients
+func newR1CS(cs compiled.R1CS, coefficients []big.Int) (frontend.CompiledConstraintSystem, error) {
+	switch cs.CurveID {
+	case ecc.BLS12_377:
+		return bls12377r1cs.NewR1CS(cs, coefficients), nil
+	case ecc.BLS12_381:
+		return bls12381r1cs.NewR1CS(cs, coefficients), nil
+	case ecc.BN254:
+		return bn254r1cs.NewR1CS(cs, coefficients), nil
+	case ecc.BW6_761:
+		return bw6761r1cs.NewR1CS(cs, coefficients), nil
+	case ecc.BLS24_315:
+		return bls24315r1cs.NewR1CS(cs, coefficients), nil
+	case ecc.BW6_633:
+		return bw6633r1cs.NewR1CS(cs, coefficients), nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", cs.CurveID)
+	}
+}
+
+// newSparseR1CS returns the SparseR1CS of the curve with the given
+// coefficients
+func newSparseR1CS(cs compiled.SparseR1CS, coefficients []big.Int) (frontend.CompiledConstraintSystem, error) {
+	switch cs.CurveID {
+	case ecc.BLS12_377:
+		return bls12377r1cs.NewSparseR1CS(cs, coefficients), nil
+	case ecc.BLS12_381:
+		return bls12381r1cs.NewSparseR1CS(cs, coefficients), nil
+	case ecc.BN254:
+		return bn254r1cs.NewSparseR1CS(cs, coefficients), nil
+	case ecc.BW6_761:
+		return bw6761r1cs.NewSparseR1CS(cs, coefficients), nil
+	case ecc.BLS24_315:
+		return bls24315r1cs.NewSparseR1CS(cs, coefficients), nil
+	case ecc.BW6_633:
+		return bw6633r1cs.NewSparseR1CS(cs, coefficients), nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", cs.CurveID)
+	}
+}
+
+// curveOf returns the curve whose scalar field has the given modulus
+func curveOf(modulus *big.Int) (ecc.ID, error) {
+	for _, curve := range gnark.Curves() {
+		if curve.Info().Fr.Modulus().Cmp(modulus) == 0 {
+			return curve, nil
+		}
+	}
+	return ecc.UNKNOWN, fmt.Errorf("no supported curve has a scalar field of modulus %s", modulus)
+}
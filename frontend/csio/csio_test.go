@@ -0,0 +1,197 @@
+package csio
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+	"github.com/stretchr/testify/require"
+)
+
+type circuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *circuit) Define(api frontend.API) error {
+	bits := api.ToBinary(c.X, 8)
+	x := api.Add(api.FromBinary(bits[:4]...), api.IsZero(c.X), api.Mul(c.X, 3))
+	api.AssertIsEqual(api.Div(x, bits[0]), c.Y)
+	return nil
+}
+
+// withoutDebug returns the constraint system without the logs and the debug
+// info, which are not exported
+func withoutDebug(cs compiled.ConstraintSystem) compiled.ConstraintSystem {
+	cs.Logs, cs.DebugInfo, cs.Counters = nil, nil, nil
+	cs.MDebug, cs.MHintsDebug = make(map[int]int), make(map[int]int)
+	return cs
+}
+
+func TestJSON(t *testing.T) {
+	assignment := &circuit{X: 0b10011, Y: 3 + 0 + 19*3}
+	w, err := frontend.NewWitness(assignment, ecc.BN254)
+	require.NoError(t, err)
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254, newBuilder, &circuit{})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, WriteJSON(&buf, ccs))
+		decoded, err := ReadJSON(&buf)
+		require.NoError(t, err)
+
+		switch c := ccs.(type) {
+		case *bn254r1cs.R1CS:
+			d := decoded.(*bn254r1cs.R1CS)
+			c.ConstraintSystem = withoutDebug(c.ConstraintSystem)
+			require.True(t, reflect.DeepEqual(c, d))
+		case *bn254r1cs.SparseR1CS:
+			d := decoded.(*bn254r1cs.SparseR1CS)
+			c.ConstraintSystem = withoutDebug(c.ConstraintSystem)
+			require.True(t, reflect.DeepEqual(c, d))
+		}
+		require.NoError(t, decoded.IsSolved(w))
+	}
+}
+
+func TestR1CS(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &circuit{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteR1CS(&buf, ccs))
+	data := buf.Bytes()
+	decoded, err := ReadR1CS(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// the internal wires are secret inputs of the decoded R1CS
+	nbInternal, nbSecret, nbPublic := ccs.GetNbVariables()
+	dInternal, dSecret, dPublic := decoded.GetNbVariables()
+	require.Equal(t, 0, dInternal)
+	require.Equal(t, nbSecret+nbInternal, dSecret)
+	require.Equal(t, nbPublic, dPublic)
+	require.Equal(t, ccs.GetNbConstraints(), decoded.GetNbConstraints())
+
+	// writing it again gives the same file
+	buf.Reset()
+	require.NoError(t, WriteR1CS(&buf, decoded))
+	require.Equal(t, data, buf.Bytes())
+
+	// the full assignment is the solution of the original R1CS
+	w, err := frontend.NewWitness(&circuit{X: 0b10011, Y: 3 + 0 + 19*3}, ecc.BN254)
+	require.NoError(t, err)
+	c := ccs.(*bn254r1cs.R1CS)
+	n := len(c.Constraints)
+	opt, err := backend.NewProverConfig()
+	require.NoError(t, err)
+	solution, err := c.Solve(*w.Vector.(*bn254witness.Witness), make([]fr.Element, n), make([]fr.Element, n), make([]fr.Element, n), opt)
+	require.NoError(t, err)
+	full := bn254witness.Witness(solution[1:])
+	fullWitness := &witness.Witness{CurveID: ecc.BN254, Vector: &full, Schema: decoded.GetSchema()}
+	publicWitness, err := fullWitness.Public()
+	require.NoError(t, err)
+
+	pk, vk, err := groth16.Setup(decoded)
+	require.NoError(t, err)
+	proof, err := groth16.Prove(decoded, pk, fullWitness)
+	require.NoError(t, err)
+	require.NoError(t, groth16.Verify(proof, vk, publicWitness))
+
+	// a witness breaking a constraint is rejected
+	full[len(full)-1].SetUint64(42)
+	require.Error(t, decoded.IsSolved(fullWitness))
+}
+
+func TestR1CSInvalid(t *testing.T) {
+	q := ecc.BN254.Info().Fr.Modulus()
+
+	// file returns a .r1cs file with the given header, constraints and
+	// wire2label sections
+	file := func(header, constraints, labels []byte) []byte {
+		var buf bytes.Buffer
+		buf.WriteString(r1csMagic)
+		writeUint32(&buf, r1csVersion)
+		writeUint32(&buf, 3)
+		for _, section := range []struct {
+			id      uint32
+			content []byte
+		}{{sectionHeader, header}, {sectionConstraints, constraints}, {sectionWire2Label, labels}} {
+			writeUint32(&buf, section.id)
+			writeUint64(&buf, uint64(len(section.content)))
+			buf.Write(section.content)
+		}
+		return buf.Bytes()
+	}
+	header := func(n8, nbWires, nbConstraints uint32) []byte {
+		var buf bytes.Buffer
+		writeUint32(&buf, n8)
+		switch {
+		case n8 == 32:
+			buf.Write(toLE(q, 32)) // modulus
+		case n8 <= 64:
+			buf.Write(make([]byte, n8))
+		}
+		for _, v := range []uint32{nbWires, 0, 1, 0} { // wires, outputs, public and private inputs
+			writeUint32(&buf, v)
+		}
+		writeUint64(&buf, uint64(nbWires))
+		writeUint32(&buf, nbConstraints)
+		return buf.Bytes()
+	}
+	labels := make([]byte, 2*8)
+
+	// the field size is checked before reading the modulus
+	_, err := ReadR1CS(bytes.NewReader(file(header(0xFFFFFFFF, 2, 1), nil, labels)))
+	require.Error(t, err)
+	_, err = ReadR1CS(bytes.NewReader(file(header(0, 2, 1), nil, labels)))
+	require.Error(t, err)
+
+	// the number of constraints is bounded by the size of the section
+	_, err = ReadR1CS(bytes.NewReader(file(header(32, 2, 0xFFFFFFFF), make([]byte, 12), labels)))
+	require.Error(t, err)
+
+	// the number of wires is bounded by the wire2label section, or the file
+	_, err = ReadR1CS(bytes.NewReader(file(header(32, 0xFFFFFFFF, 0), nil, labels)))
+	require.Error(t, err)
+	_, err = ReadR1CS(bytes.NewReader(file(header(32, 0xFFFFFFFF, 0), nil, nil)))
+	require.Error(t, err)
+	_, err = ReadR1CS(bytes.NewReader(file(header(32, 2, 0), nil, labels)))
+	require.NoError(t, err)
+
+	// truncated terms
+	var constraints bytes.Buffer
+	writeUint32(&constraints, 1)
+	writeUint32(&constraints, 0)
+	_, err = ReadR1CS(bytes.NewReader(file(header(32, 2, 1), append(constraints.Bytes(), make([]byte, 8)...), labels)))
+	require.Error(t, err)
+
+	// coefficients are reduced modulo the prime
+	for _, coeff := range []*big.Int{big.NewInt(1), q} {
+		constraints.Reset()
+		writeUint32(&constraints, 1)
+		writeUint32(&constraints, 1)
+		constraints.Write(toLE(coeff, 32))
+		writeUint32(&constraints, 0)
+		writeUint32(&constraints, 0)
+		_, err = ReadR1CS(bytes.NewReader(file(header(32, 2, 1), constraints.Bytes(), labels)))
+		if coeff == q {
+			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,494 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csio
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+const (
+	systemR1CS       = "r1cs"
+	systemSparseR1CS = "sparse_r1cs"
+)
+
+type jsonSystem struct {
+	Curve        string           `json:"curve"`
+	Modulus      string           `json:"modulus"`
+	System       string           `json:"system"`
+	NbPublic     int              `json:"nbPublic"`
+	NbSecret     int              `json:"nbSecret"`
+	NbInternal   int              `json:"nbInternal"`
+	Public       []string         `json:"public"`
+	Secret       []string         `json:"secret"`
+	Outputs      []int            `json:"outputs,omitempty"`
+	Schema       *schema.Schema   `json:"schema,omitempty"`
+	Coefficients []string         `json:"coefficients"`
+	Constraints  []jsonConstraint `json:"constraints"`
+	Hints        []jsonHint       `json:"hints"`
+	Levels       [][]int          `json:"levels"`
+}
+
+// jsonConstraint is L·R == O for an R1CS, and
+// L + R + O + M[0]·M[1] + K == 0 for a SparseR1CS
+type jsonConstraint struct {
+	L []jsonTerm `json:"l"`
+	R []jsonTerm `json:"r"`
+	O []jsonTerm `json:"o"`
+	M []jsonTerm `json:"m,omitempty"`
+	K *int       `json:"k,omitempty"`
+}
+
+// jsonTerm is a wire (-1 if none) and a coefficient ID
+type jsonTerm [2]int
+
+type jsonHint struct {
+	ID      hint.ID         `json:"id"`
+	Name    string          `json:"name"`
+	Inputs  []jsonHintInput `json:"inputs"`
+	Outputs []int           `json:"outputs"`
+
+	Rich            bool            `json:"rich,omitempty"`
+	Params          []jsonHintParam `json:"params,omitempty"`
+	VariableOutputs bool            `json:"variableOutputs,omitempty"`
+}
+
+// jsonHintParam is a constant parameter of a rich hint
+type jsonHintParam struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// jsonHintInput is one of a linear expression, a term or a constant
+type jsonHintInput struct {
+	Terms    []jsonTerm `json:"terms,omitempty"`
+	Term     *jsonTerm  `json:"term,omitempty"`
+	Constant *string    `json:"constant,omitempty"`
+}
+
+// WriteJSON writes the JSON description of an R1CS or a SparseR1CS (see the
+// package documentation)
+func WriteJSON(w io.Writer, ccs frontend.CompiledConstraintSystem) error {
+	r1cs, sparse, coeffs, err := unwrap(ccs)
+	if err != nil {
+		return err
+	}
+	var cs *compiled.ConstraintSystem
+	if r1cs != nil {
+		cs = &r1cs.ConstraintSystem
+	} else {
+		cs = &sparse.ConstraintSystem
+	}
+
+	q := cs.CurveID.Info().Fr.Modulus()
+	s := jsonSystem{
+		Curve:        cs.CurveID.String(),
+		Modulus:      q.String(),
+		NbPublic:     cs.NbPublicVariables,
+		NbSecret:     cs.NbSecretVariables,
+		NbInternal:   cs.NbInternalVariables,
+		Public:       cs.Public,
+		Secret:       cs.Secret,
+		Outputs:      cs.Outputs,
+		Schema:       cs.Schema,
+		Coefficients: make([]string, len(coeffs)),
+		Levels:       cs.Levels,
+	}
+	for i := range coeffs {
+		var c big.Int
+		s.Coefficients[i] = c.Mod(&coeffs[i], q).String()
+	}
+
+	if r1cs != nil {
+		s.System = systemR1CS
+		s.Constraints = make([]jsonConstraint, len(r1cs.Constraints))
+		for i, c := range r1cs.Constraints {
+			if s.Constraints[i].L, err = encodeTerms(c.L); err != nil {
+				return err
+			}
+			if s.Constraints[i].R, err = encodeTerms(c.R); err != nil {
+				return err
+			}
+			if s.Constraints[i].O, err = encodeTerms(c.O); err != nil {
+				return err
+			}
+		}
+	} else {
+		s.System = systemSparseR1CS
+		s.Constraints = make([]jsonConstraint, len(sparse.Constraints))
+		for i, c := range sparse.Constraints {
+			terms, err := encodeTerms(compiled.LinearExpression{c.L, c.R, c.O, c.M[0], c.M[1]})
+			if err != nil {
+				return err
+			}
+			k := c.K
+			s.Constraints[i] = jsonConstraint{L: terms[0:1], R: terms[1:2], O: terms[2:3], M: terms[3:5], K: &k}
+		}
+	}
+
+	if s.Hints, err = encodeHints(cs); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	return enc.Encode(&s)
+}
+
+// encodeTerms returns the JSON terms of a linear expression
+func encodeTerms(l compiled.LinearExpression) ([]jsonTerm, error) {
+	res := make([]jsonTerm, len(l))
+	for i, t := range l {
+		cID, vID, visibility := t.Unpack()
+		switch visibility {
+		case schema.Unset:
+			vID = -1
+		case schema.Virtual:
+			return nil, errors.New("virtual terms can't be exported")
+		}
+		res[i] = jsonTerm{vID, cID}
+	}
+	return res, nil
+}
+
+// encodeHints returns the hints of a constraint system, sorted by their first
+// output wire
+func encodeHints(cs *compiled.ConstraintSystem) ([]jsonHint, error) {
+	wires := make([]int, 0, len(cs.MHints))
+	for wID := range cs.MHints {
+		wires = append(wires, wID)
+	}
+	sort.Ints(wires)
+
+	res := make([]jsonHint, 0)
+	seen := make(map[*compiled.Hint]bool)
+	for _, wID := range wires {
+		h := cs.MHints[wID]
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		jh := jsonHint{ID: h.ID, Name: cs.MHintsDependencies[h.ID], Inputs: make([]jsonHintInput, len(h.Inputs)), Outputs: h.Wires}
+		jh.Rich, jh.VariableOutputs = h.Rich, h.VariableOutputs
+		for _, p := range h.Params {
+			jp, err := encodeHintParam(p)
+			if err != nil {
+				return nil, err
+			}
+			jh.Params = append(jh.Params, jp)
+		}
+		for i, in := range h.Inputs {
+			switch t := in.(type) {
+			case compiled.LinearExpression:
+				terms, err := encodeTerms(t)
+				if err != nil {
+					return nil, err
+				}
+				jh.Inputs[i].Terms = terms
+			case compiled.Term:
+				terms, err := encodeTerms(compiled.LinearExpression{t})
+				if err != nil {
+					return nil, err
+				}
+				jh.Inputs[i].Term = &terms[0]
+			case big.Int:
+				c := t.String()
+				jh.Inputs[i].Constant = &c
+			case *big.Int:
+				c := t.String()
+				jh.Inputs[i].Constant = &c
+			default:
+				return nil, fmt.Errorf("unsupported hint input type %T", in)
+			}
+		}
+		res = append(res, jh)
+	}
+	return res, nil
+}
+
+// ReadJSON reads the JSON description of an R1CS or a SparseR1CS written by
+// WriteJSON. The returned constraint system has no logs nor debug info.
+func ReadJSON(r io.Reader) (frontend.CompiledConstraintSystem, error) {
+	var s jsonSystem
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	curve := ecc.UNKNOWN
+	for _, c := range gnark.Curves() {
+		if c.String() == s.Curve {
+			curve = c
+		}
+	}
+	if curve == ecc.UNKNOWN {
+		return nil, fmt.Errorf("unsupported curve %q", s.Curve)
+	}
+	q := curve.Info().Fr.Modulus()
+	if s.Modulus != "" && s.Modulus != q.String() {
+		return nil, fmt.Errorf("modulus %s doesn't match curve %s", s.Modulus, curve)
+	}
+	if s.NbPublic < 0 || s.NbSecret < 0 || s.NbInternal < 0 || len(s.Public) != s.NbPublic || len(s.Secret) != s.NbSecret {
+		return nil, errors.New("invalid number of wires")
+	}
+
+	for _, wID := range s.Outputs {
+		if wID < 0 || wID >= s.NbPublic {
+			return nil, fmt.Errorf("output %d is not a public wire", wID)
+		}
+	}
+
+	d := decoder{
+		nbPublic: s.NbPublic,
+		nbInputs: s.NbPublic + s.NbSecret,
+		nbWires:  s.NbPublic + s.NbSecret + s.NbInternal,
+	}
+	coeffs := make([]big.Int, len(s.Coefficients))
+	for i, c := range s.Coefficients {
+		if _, ok := coeffs[i].SetString(c, 10); !ok {
+			return nil, fmt.Errorf("invalid coefficient %q", c)
+		}
+	}
+	if err := checkCoefficients(coeffs, q); err != nil {
+		return nil, err
+	}
+	d.nbCoeffs = len(coeffs)
+
+	cs := compiled.ConstraintSystem{
+		Schema:              s.Schema,
+		NbInternalVariables: s.NbInternal,
+		NbPublicVariables:   s.NbPublic,
+		NbSecretVariables:   s.NbSecret,
+		Public:              s.Public,
+		Secret:              s.Secret,
+		Outputs:             s.Outputs,
+		MDebug:              make(map[int]int),
+		MHints:              make(map[int]*compiled.Hint),
+		MHintsDependencies:  make(map[hint.ID]string),
+		MHintsDebug:         make(map[int]int),
+		MLabels:             make(map[int]string),
+		Levels:              s.Levels,
+		CurveID:             curve,
+	}
+	for _, level := range s.Levels {
+		for _, cID := range level {
+			if cID < 0 || cID >= len(s.Constraints) {
+				return nil, fmt.Errorf("invalid constraint %d in levels", cID)
+			}
+		}
+	}
+	if err := d.decodeHints(&cs, s.Hints); err != nil {
+		return nil, err
+	}
+
+	switch s.System {
+	case systemR1CS:
+		res := compiled.R1CS{ConstraintSystem: cs, Constraints: make([]compiled.R1C, len(s.Constraints))}
+		for i, c := range s.Constraints {
+			var err error
+			if res.Constraints[i].L, err = d.decodeTerms(c.L); err != nil {
+				return nil, err
+			}
+			if res.Constraints[i].R, err = d.decodeTerms(c.R); err != nil {
+				return nil, err
+			}
+			if res.Constraints[i].O, err = d.decodeTerms(c.O); err != nil {
+				return nil, err
+			}
+		}
+		return newR1CS(res, coeffs)
+	case systemSparseR1CS:
+		res := compiled.SparseR1CS{ConstraintSystem: cs, Constraints: make([]compiled.SparseR1C, len(s.Constraints))}
+		for i, c := range s.Constraints {
+			if len(c.L) != 1 || len(c.R) != 1 || len(c.O) != 1 || len(c.M) != 2 || c.K == nil || *c.K < 0 || *c.K >= d.nbCoeffs {
+				return nil, fmt.Errorf("invalid sparse constraint %d", i)
+			}
+			terms, err := d.decodeTerms([]jsonTerm{c.L[0], c.R[0], c.O[0], c.M[0], c.M[1]})
+			if err != nil {
+				return nil, err
+			}
+			res.Constraints[i] = compiled.SparseR1C{L: terms[0], R: terms[1], O: terms[2], M: [2]compiled.Term{terms[3], terms[4]}, K: *c.K}
+		}
+		return newSparseR1CS(res, coeffs)
+	default:
+		return nil, fmt.Errorf("unsupported system %q", s.System)
+	}
+}
+
+// checkCoefficients ensures the first coefficients have the values the solver
+// expects for their IDs
+func checkCoefficients(coeffs []big.Int, q *big.Int) error {
+	expected := []int64{compiled.CoeffIdZero: 0, compiled.CoeffIdOne: 1, compiled.CoeffIdTwo: 2, compiled.CoeffIdMinusOne: -1}
+	if len(coeffs) < len(expected) {
+		return errors.New("missing coefficients")
+	}
+	for i, e := range expected {
+		var a, b big.Int
+		a.Mod(&coeffs[i], q)
+		b.SetInt64(e).Mod(&b, q)
+		if a.Cmp(&b) != 0 {
+			return fmt.Errorf("coefficient %d must be %d", i, e)
+		}
+	}
+	return nil
+}
+
+// decoder checks and decodes the JSON terms
+type decoder struct {
+	nbPublic, nbInputs, nbWires int
+	nbCoeffs                    int
+}
+
+func (d *decoder) decodeTerms(terms []jsonTerm) (compiled.LinearExpression, error) {
+	res := make(compiled.LinearExpression, len(terms))
+	for i, t := range terms {
+		vID, cID := t[0], t[1]
+		if cID < 0 || cID >= d.nbCoeffs {
+			return nil, fmt.Errorf("invalid coefficient ID %d", cID)
+		}
+		switch {
+		case vID == -1:
+			res[i].SetCoeffID(cID)
+		case vID < 0 || vID >= d.nbWires:
+			return nil, fmt.Errorf("invalid wire ID %d", vID)
+		case vID < d.nbPublic:
+			res[i] = compiled.Pack(vID, cID, schema.Public)
+		case vID < d.nbInputs:
+			res[i] = compiled.Pack(vID, cID, schema.Secret)
+		default:
+			res[i] = compiled.Pack(vID, cID, schema.Internal)
+		}
+	}
+	return res, nil
+}
+
+func encodeHintParam(p hint.Param) (jsonHintParam, error) {
+	res := jsonHintParam{Type: p.Type().String()}
+	switch p.Type() {
+	case hint.ParamInt:
+		v, _ := p.AsInt()
+		res.Value = strconv.FormatInt(v, 10)
+	case hint.ParamBool:
+		v, _ := p.AsBool()
+		res.Value = strconv.FormatBool(v)
+	case hint.ParamString:
+		res.Value, _ = p.AsString()
+	case hint.ParamBytes:
+		v, _ := p.AsBytes()
+		res.Value = hex.EncodeToString(v)
+	case hint.ParamBigInt:
+		v, _ := p.AsBigInt()
+		res.Value = v.String()
+	default:
+		return res, fmt.Errorf("unsupported hint parameter type %s", p.Type())
+	}
+	return res, nil
+}
+
+func decodeHintParam(jp jsonHintParam) (hint.Param, error) {
+	switch jp.Type {
+	case hint.ParamInt.String():
+		v, err := strconv.ParseInt(jp.Value, 10, 64)
+		if err != nil {
+			return hint.Param{}, fmt.Errorf("invalid hint parameter: %w", err)
+		}
+		return hint.Int(v), nil
+	case hint.ParamBool.String():
+		v, err := strconv.ParseBool(jp.Value)
+		if err != nil {
+			return hint.Param{}, fmt.Errorf("invalid hint parameter: %w", err)
+		}
+		return hint.Bool(v), nil
+	case hint.ParamString.String():
+		return hint.String(jp.Value), nil
+	case hint.ParamBytes.String():
+		v, err := hex.DecodeString(jp.Value)
+		if err != nil {
+			return hint.Param{}, fmt.Errorf("invalid hint parameter: %w", err)
+		}
+		return hint.Bytes(v), nil
+	case hint.ParamBigInt.String():
+		var v big.Int
+		if _, ok := v.SetString(jp.Value, 10); !ok {
+			return hint.Param{}, fmt.Errorf("invalid hint parameter %q", jp.Value)
+		}
+		return hint.BigInt(&v), nil
+	default:
+		return hint.Param{}, fmt.Errorf("unknown hint parameter type %q", jp.Type)
+	}
+}
+
+func (d *decoder) decodeHints(cs *compiled.ConstraintSystem, hints []jsonHint) error {
+	for _, jh := range hints {
+		h := &compiled.Hint{ID: jh.ID, Inputs: make([]interface{}, len(jh.Inputs)), Wires: jh.Outputs}
+		if jh.VariableOutputs && len(jh.Outputs) == 0 {
+			return errors.New("hint with variable outputs has no outputs")
+		}
+		h.Rich, h.VariableOutputs = jh.Rich, jh.VariableOutputs
+		for _, jp := range jh.Params {
+			p, err := decodeHintParam(jp)
+			if err != nil {
+				return err
+			}
+			h.Params = append(h.Params, p)
+		}
+		for i, in := range jh.Inputs {
+			switch {
+			case in.Terms != nil:
+				l, err := d.decodeTerms(in.Terms)
+				if err != nil {
+					return err
+				}
+				h.Inputs[i] = l
+			case in.Term != nil:
+				l, err := d.decodeTerms([]jsonTerm{*in.Term})
+				if err != nil {
+					return err
+				}
+				h.Inputs[i] = l[0]
+			case in.Constant != nil:
+				var c big.Int
+				if _, ok := c.SetString(*in.Constant, 10); !ok {
+					return fmt.Errorf("invalid hint constant %q", *in.Constant)
+				}
+				h.Inputs[i] = c
+			default:
+				return errors.New("empty hint input")
+			}
+		}
+		for _, wID := range jh.Outputs {
+			if wID < d.nbInputs || wID >= d.nbWires {
+				return fmt.Errorf("invalid hint output %d", wID)
+			}
+			cs.MHints[wID] = h
+		}
+		cs.MHintsDependencies[jh.ID] = jh.Name
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// iden3 .r1cs format
+const (
+	r1csMagic   = "r1cs"
+	r1csVersion = 1
+
+	sectionHeader      = 1
+	sectionConstraints = 2
+	sectionWire2Label  = 3
+)
+
+// r1csHeader is the header of a .r1cs file
+type r1csHeader struct {
+	Modulus       *big.Int
+	NbWires       int // including the constant wire 0
+	NbPubOut      int // public outputs, then public inputs, then private inputs
+	NbPubIn       int
+	NbPrvIn       int
+	NbLabels      int
+	NbConstraints int
+}
+
+// WriteR1CS writes an R1CS in the iden3 .r1cs format. The public inputs of
+// the R1CS (but the one wire) are written as public inputs, its secret inputs
+// as private inputs, and its internal wires as the other wires; the label of
+// each wire is its ID.
+func WriteR1CS(w io.Writer, ccs frontend.CompiledConstraintSystem) error {
+	r1cs, _, coeffs, err := unwrap(ccs)
+	if err != nil {
+		return err
+	}
+	if r1cs == nil {
+		return errors.New("the .r1cs format only describes R1CS")
+	}
+
+	q := r1cs.CurveID.Info().Fr.Modulus()
+	n8 := r1cs.CurveID.Info().Fr.Bytes
+	nbWires := r1cs.NbPublicVariables + r1cs.NbSecretVariables + r1cs.NbInternalVariables
+
+	// header
+	var header bytes.Buffer
+	writeUint32(&header, uint32(n8))
+	header.Write(toLE(q, n8))
+	writeUint32(&header, uint32(nbWires))
+	writeUint32(&header, 0)
+	writeUint32(&header, uint32(r1cs.NbPublicVariables-1))
+	writeUint32(&header, uint32(r1cs.NbSecretVariables))
+	writeUint64(&header, uint64(nbWires))
+	writeUint32(&header, uint32(len(r1cs.Constraints)))
+
+	// constraints
+	values := make([][]byte, len(coeffs))
+	for i := range coeffs {
+		var c big.Int
+		values[i] = toLE(c.Mod(&coeffs[i], q), n8)
+	}
+	var constraints bytes.Buffer
+	for _, c := range r1cs.Constraints {
+		for _, l := range []compiled.LinearExpression{c.L, c.R, c.O} {
+			writeUint32(&constraints, uint32(len(l)))
+			for _, t := range l {
+				writeUint32(&constraints, uint32(t.WireID()))
+				constraints.Write(values[t.CoeffID()])
+			}
+		}
+	}
+
+	// labels
+	var labels bytes.Buffer
+	for i := 0; i < nbWires; i++ {
+		writeUint64(&labels, uint64(i))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(r1csMagic)
+	writeUint32(&buf, r1csVersion)
+	writeUint32(&buf, 3)
+	for _, section := range []struct {
+		id      uint32
+		content *bytes.Buffer
+	}{{sectionHeader, &header}, {sectionConstraints, &constraints}, {sectionWire2Label, &labels}} {
+		writeUint32(&buf, section.id)
+		writeUint64(&buf, uint64(section.content.Len()))
+		if _, err := section.content.WriteTo(&buf); err != nil {
+			return err
+		}
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// ReadR1CS reads an R1CS in the iden3 .r1cs format, on the curve whose
+// scalar field is the prime of the file.
+//
+// The public outputs then the public inputs of the file are the public
+// variables following the one wire, named out_i and in_i; the private inputs
+// then the other wires are the secret variables, named prv_i and w_i. The
+// full assignment of a witness is thus in the order of the wires of the
+// file, without the constant wire 0.
+func ReadR1CS(r io.Reader) (frontend.CompiledConstraintSystem, error) {
+	header, constraints, err := readR1CSFile(r)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := curveOf(header.Modulus)
+	if err != nil {
+		return nil, err
+	}
+
+	nbPublic := 1 + header.NbPubOut + header.NbPubIn
+	res := compiled.R1CS{
+		ConstraintSystem: compiled.ConstraintSystem{
+			NbPublicVariables:  nbPublic,
+			NbSecretVariables:  header.NbWires - nbPublic,
+			Public:             make([]string, 0, nbPublic),
+			Secret:             make([]string, 0, header.NbWires-nbPublic),
+			MDebug:             make(map[int]int),
+			MHints:             make(map[int]*compiled.Hint),
+			MHintsDependencies: make(map[hint.ID]string),
+			MHintsDebug:        make(map[int]int),
+			MLabels:            make(map[int]string),
+			CurveID:            curve,
+		},
+		Constraints: make([]compiled.R1C, len(constraints)),
+	}
+
+	// names and schema
+	res.Public = append(res.Public, "one")
+	s := &schema.Schema{NbPublic: nbPublic - 1, NbSecret: res.NbSecretVariables}
+	for _, group := range []struct {
+		field, name string
+		n           int
+		visibility  schema.Visibility
+	}{
+		{"Out", "out", header.NbPubOut, schema.Public},
+		{"In", "in", header.NbPubIn, schema.Public},
+		{"Prv", "prv", header.NbPrvIn, schema.Secret},
+		{"W", "w", header.NbWires - nbPublic - header.NbPrvIn, schema.Secret},
+	} {
+		if group.n == 0 {
+			continue
+		}
+		s.Fields = append(s.Fields, schema.Field{
+			Name:       group.field,
+			NameTag:    group.name,
+			Visibility: group.visibility,
+			Type:       schema.Array,
+			ArraySize:  group.n,
+		})
+		for i := 0; i < group.n; i++ {
+			name := group.name + "_" + strconv.Itoa(i)
+			if group.visibility == schema.Public {
+				res.Public = append(res.Public, name)
+			} else {
+				res.Secret = append(res.Secret, name)
+			}
+		}
+	}
+	res.Schema = s
+
+	// constraints, the solver only checks them
+	coeffs := cs.NewCoeffTable()
+	level := make([]int, len(constraints))
+	half := new(big.Int).Rsh(header.Modulus, 1)
+	for i, c := range constraints {
+		level[i] = i
+		for j, l := range []*compiled.LinearExpression{&res.Constraints[i].L, &res.Constraints[i].R, &res.Constraints[i].O} {
+			*l = make(compiled.LinearExpression, len(c[j]))
+			for k := range c[j] {
+				t := &c[j][k]
+				// small negative values are stored as such
+				if t.coeff.Cmp(half) > 0 {
+					t.coeff.Sub(&t.coeff, header.Modulus)
+				}
+				visibility := schema.Secret
+				if t.wireID < nbPublic {
+					visibility = schema.Public
+				}
+				(*l)[k] = compiled.Pack(t.wireID, coeffs.CoeffID(&t.coeff), visibility)
+			}
+		}
+	}
+	if len(level) != 0 {
+		res.Levels = [][]int{level}
+	}
+
+	return newR1CS(res, coeffs.Coeffs)
+}
+
+// r1csTerm is a term of a .r1cs constraint
+type r1csTerm struct {
+	wireID int
+	coeff  big.Int
+}
+
+// readR1CSFile returns the header of a .r1cs file and its constraints, as
+// their L, R and O linear expressions
+func readR1CSFile(r io.Reader) (*r1csHeader, [][3][]r1csTerm, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	rd := &reader{data: data}
+	if string(rd.next(4)) != r1csMagic {
+		return nil, nil, errors.New("invalid .r1cs file: bad magic number")
+	}
+	if version := rd.uint32(); version != r1csVersion {
+		return nil, nil, fmt.Errorf("unsupported .r1cs version %d", version)
+	}
+
+	// the sections may be in any order
+	sections := make(map[uint32][]byte)
+	nbSections := rd.uint32()
+	for i := uint32(0); i < nbSections && rd.err == nil; i++ {
+		id := rd.uint32()
+		size := rd.uint64()
+		if size > uint64(len(rd.data)) {
+			return nil, nil, errors.New("invalid .r1cs file: section too large")
+		}
+		sections[id] = rd.next(int(size))
+	}
+	if rd.err != nil {
+		return nil, nil, rd.err
+	}
+
+	content, ok := sections[sectionHeader]
+	if !ok {
+		return nil, nil, errors.New("invalid .r1cs file: missing header")
+	}
+	rd = &reader{data: content}
+	n8 := int(rd.uint32())
+	if !isFieldSize(n8) {
+		return nil, nil, fmt.Errorf("invalid .r1cs file: unsupported field size of %d bytes", n8)
+	}
+	header := &r1csHeader{Modulus: fromLE(rd.next(n8))}
+	header.NbWires = int(rd.uint32())
+	header.NbPubOut = int(rd.uint32())
+	header.NbPubIn = int(rd.uint32())
+	header.NbPrvIn = int(rd.uint32())
+	header.NbLabels = int(rd.uint64())
+	header.NbConstraints = int(rd.uint32())
+	if rd.err != nil {
+		return nil, nil, rd.err
+	}
+	if header.NbWires < 1+header.NbPubOut+header.NbPubIn+header.NbPrvIn {
+		return nil, nil, errors.New("invalid .r1cs file: inconsistent number of wires")
+	}
+	// the wire2label section holds a label of 8 bytes per wire, and the reader
+	// allocates a name per wire
+	maxWires := len(data)
+	if content, ok := sections[sectionWire2Label]; ok {
+		maxWires = len(content) / 8
+	}
+	if header.NbWires > maxWires {
+		return nil, nil, errors.New("invalid .r1cs file: too many wires")
+	}
+
+	if content, ok = sections[sectionConstraints]; !ok {
+		return nil, nil, errors.New("invalid .r1cs file: missing constraints")
+	}
+	// a constraint holds at least the numbers of terms of L, R and O
+	if header.NbConstraints > len(content)/12 {
+		return nil, nil, errors.New("invalid .r1cs file: too many constraints")
+	}
+	rd = &reader{data: content}
+	constraints := make([][3][]r1csTerm, 0, header.NbConstraints)
+	for i := 0; i < header.NbConstraints && rd.err == nil; i++ {
+		var c [3][]r1csTerm
+		for j := range c {
+			n := int(rd.uint32())
+			if n > len(rd.data) {
+				return nil, nil, errors.New("invalid .r1cs file: too many terms")
+			}
+			c[j] = make([]r1csTerm, n)
+			for k := range c[j] {
+				c[j][k].wireID = int(rd.uint32())
+				c[j][k].coeff.SetBytes(reverse(rd.next(n8)))
+				if c[j][k].wireID >= header.NbWires {
+					return nil, nil, fmt.Errorf("invalid .r1cs file: wire %d out of range", c[j][k].wireID)
+				}
+				if c[j][k].coeff.Cmp(header.Modulus) >= 0 {
+					return nil, nil, errors.New("invalid .r1cs file: coefficient not reduced modulo the prime")
+				}
+			}
+		}
+		constraints = append(constraints, c)
+	}
+	if rd.err != nil {
+		return nil, nil, rd.err
+	}
+	return header, constraints, nil
+}
+
+// reader reads the little endian values of a .r1cs file
+type reader struct {
+	data []byte
+	err  error
+}
+
+// next returns the next n bytes, or nil after an error
+func (rd *reader) next(n int) []byte {
+	if rd.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(rd.data) {
+		rd.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	res := rd.data[:n]
+	rd.data = rd.data[n:]
+	return res
+}
+
+func (rd *reader) uint32() uint32 {
+	b := rd.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (rd *reader) uint64() uint64 {
+	b := rd.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// isFieldSize returns true if n8 is the size in bytes of the scalar field of a
+// supported curve
+func isFieldSize(n8 int) bool {
+	for _, curve := range gnark.Curves() {
+		if curve.Info().Fr.Bytes == n8 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
+
+// toLE returns the n bytes little endian representation of v
+func toLE(v *big.Int, n int) []byte {
+	b := make([]byte, n)
+	v.FillBytes(b)
+	return reverse(b)
+}
+
+// fromLE returns the integer of little endian representation b
+func fromLE(b []byte) *big.Int {
+	return new(big.Int).SetBytes(reverse(b))
+}
+
+// reverse returns a reversed copy of b
+func reverse(b []byte) []byte {
+	res := make([]byte, len(b))
+	for i := range b {
+		res[len(b)-1-i] = b[i]
+	}
+	return res
+}
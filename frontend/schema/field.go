@@ -36,7 +36,11 @@ const (
 )
 
 // Visibility encodes a Variable (or wire) visibility
-// Possible values are Unset, Internal, Secret or Public
+// Possible values are Unset, Internal, Secret, Public or Output
+//
+// An Output is a public variable whose value is computed by the solver from
+// the constraints: it is not needed in the full witness, but is part of the
+// public witness.
 type Visibility uint8
 
 const (
@@ -45,6 +49,7 @@ const (
 	Secret
 	Public
 	Virtual
+	Output
 )
 
 func (v Visibility) String() string {
@@ -57,7 +62,14 @@ func (v Visibility) String() string {
 		return "public"
 	case Virtual:
 		return "virtual"
+	case Output:
+		return "output"
 	}
 
 	return "unset"
 }
+
+// IsPublic returns true if v is Public or Output
+func (v Visibility) IsPublic() bool {
+	return v == Public || v == Output
+}
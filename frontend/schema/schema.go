@@ -87,7 +87,7 @@ func (s Schema) WriteSequence(w io.Writer) error {
 	instance := s.Instantiate(reflect.TypeOf(a), false)
 
 	collectHandler := func(visibility Visibility, name string, _ reflect.Value) error {
-		if visibility == Public {
+		if visibility.IsPublic() {
 			public = append(public, name)
 		} else if visibility == Secret {
 			secret = append(secret, name)
@@ -206,7 +206,7 @@ func parse(r []Field, input interface{}, target reflect.Type, parentFullName, pa
 		}
 		if v == Secret {
 			(*nbSecret)++
-		} else if v == Public {
+		} else if v.IsPublic() {
 			(*nbPublic)++
 		}
 
@@ -257,13 +257,14 @@ func parse(r []Field, input interface{}, target reflect.Type, parentFullName, pa
 					visibility = Secret
 				} else if opts.contains(string(optPublic)) {
 					visibility = Public
+				} else if opts.contains(string(optOutput)) {
+					visibility = Output
 				} else {
-					return r, fmt.Errorf("invalid gnark struct tag option on %s. must be \"public\", \"secret\" or \"-\"", getFullName(parentGoName, name, nameTag))
+					return r, fmt.Errorf("invalid gnark struct tag option on %s. must be \"public\", \"secret\", \"output\" or \"-\"", getFullName(parentGoName, name, nameTag))
 				}
 			}
 
-			if ((parentVisibility == Public) && (visibility == Secret)) ||
-				((parentVisibility == Secret) && (visibility == Public)) {
+			if parentVisibility != Unset && visibility != Unset && visibility != parentVisibility {
 				// TODO @gbotrel maybe we should just force it to take the parent value.
 				return r, fmt.Errorf("conflicting visibility. %s (%s) has a parent with different visibility attribute", getFullName(parentGoName, name, nameTag), visibility.String())
 			}
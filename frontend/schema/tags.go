@@ -14,7 +14,10 @@ import (
 // 		}
 // if empty, default resolves to variable name (here "Y") and secret visibility
 // similarly to json or xml struct tags, these are valid:
-// 		`gnark:",public"` or `gnark:"-"`
+// 		`gnark:",public"`, `gnark:",output"` or `gnark:"-"`
+// an output is a public variable computed by the solver: it must be constrained to
+// its value in the Define() method, for example with api.AssertIsEqual, before
+// any other use, and may be left unassigned in the full witness.
 // using "-" marks the variable as ignored by the Compile method. This can be useful when you need to
 // declare variables as aliases that are already allocated. For example
 // 		type MyCircuit struct {
@@ -28,6 +31,7 @@ const (
 	tagKey    Tag = "gnark"
 	optPublic Tag = "public"
 	optSecret Tag = "secret"
+	optOutput Tag = "output"
 	optOmit   Tag = "-"
 )
 
@@ -160,4 +160,16 @@ func TestStructTags(t *testing.T) {
 		t.Run("slice", func(t *testing.T) { testParseTags(t, &s, expected) })
 	}
 
+	// output
+	{
+		s := struct {
+			A variable `gnark:",output"`
+			B variable `gnark:",public"`
+		}{}
+		expected := make(map[string]Visibility)
+		expected["A"] = Output
+		expected["B"] = Public
+		t.Run("output", func(t *testing.T) { testParseTags(t, &s, expected) })
+	}
+
 }
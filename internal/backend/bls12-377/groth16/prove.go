@@ -17,6 +17,7 @@
 package groth16
 
 import (
+	"context"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bls12-377"
@@ -33,6 +34,7 @@ import (
 	"github.com/consensys/gnark/logger"
 	"math/big"
 	"runtime"
+	"sync"
 	"time"
 )
 
@@ -56,21 +58,175 @@ func (proof *Proof) CurveID() ecc.ID {
 
 // Prove generates the proof of knoweldge of a r1cs with full witness (secret + public part).
 func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt backend.ProverConfig) (*Proof, error) {
+	return NewProver(r1cs, pk, opt).Prove(witness)
+}
+
+// Prover generates the proofs of a r1cs with a ProvingKey for many witnesses.
+// It keeps the buffers of a proof for the next ones, and the indexes of the
+// wires whose points in the ProvingKey aren't at infinity. ProveBatch also
+// solves a witness while the proof of the previous one is computed.
+//
+// A Prover is not safe for concurrent use.
+type Prover struct {
+	r1cs    *cs.R1CS
+	pk      *ProvingKey
+	opt     backend.ProverConfig
+	ctx     context.Context
+	nbTasks int
+
+	// wires whose points in pk.G1.A (pk.G1.B and pk.G2.B) aren't at infinity
+	wiresA, wiresB []int
+
+	// ProveBatch solves a witness with one while the proof of the previous
+	// witness is computed with the other
+	buffers [2]proverBuffers
+}
+
+// proverBuffers holds the vectors of a proof which depend on the witness
+type proverBuffers struct {
+	a, b, c                  []fr.Element // capacity of pk.Domain, a is then reused for h
+	wireValues               []fr.Element // in regular form
+	wireValuesA, wireValuesB []fr.Element // wireValues filtered with the points at infinity
+}
+
+// NewProver returns a Prover of the r1cs with the ProvingKey pk and the
+// options opt.
+func NewProver(r1cs *cs.R1CS, pk *ProvingKey, opt backend.ProverConfig) *Prover {
+	p := &Prover{
+		r1cs:    r1cs,
+		pk:      pk,
+		opt:     opt,
+		ctx:     opt.Context,
+		nbTasks: opt.NbTasks,
+	}
+	if p.ctx == nil {
+		p.ctx = context.Background()
+	}
+	if p.nbTasks <= 0 {
+		p.nbTasks = runtime.NumCPU()
+	}
+
+	p.wiresA = make([]int, 0, len(pk.InfinityA)-int(pk.NbInfinityA))
+	for i, infinity := range pk.InfinityA {
+		if !infinity {
+			p.wiresA = append(p.wiresA, i)
+		}
+	}
+	p.wiresB = make([]int, 0, len(pk.InfinityB)-int(pk.NbInfinityB))
+	for i, infinity := range pk.InfinityB {
+		if !infinity {
+			p.wiresB = append(p.wiresB, i)
+		}
+	}
+
+	return p
+}
+
+// Prove generates the proof of knoweldge of the r1cs with full witness (secret + public part).
+func (p *Prover) Prove(witness bls12_377witness.Witness) (proof *Proof, err error) {
+	end := p.opt.StartPhase(backend.PhaseProve, "groth16", len(p.r1cs.Constraints))
+	defer func() { end(err) }()
+
+	if err = p.solve(witness, &p.buffers[0]); err != nil {
+		return nil, err
+	}
+	return p.prove(&p.buffers[0])
+}
+
+// ProveBatch generates the proofs of the witnesses, in order: the witness i+1
+// is solved while the proof of the witness i is computed, so the events of
+// these steps (see backend.WithProgress) overlap. It stops at the first witness
+// which can't be proved and returns its error.
+func (p *Prover) ProveBatch(witnesses []bls12_377witness.Witness) ([]*Proof, error) {
+	type solved struct {
+		buf *proverBuffers
+		err error
+	}
+	chSolved := make(chan solved, 1)
+	chFree := make(chan *proverBuffers, len(p.buffers))
+	for i := range p.buffers {
+		chFree <- &p.buffers[i]
+	}
+	// the solver stops once we return, and we wait for it: it mustn't use the
+	// buffers after ProveBatch returns
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, witness := range witnesses {
+			var buf *proverBuffers
+			select {
+			case buf = <-chFree:
+			case <-done:
+				return
+			}
+			err := p.solve(witness, buf)
+			select {
+			case chSolved <- solved{buf: buf, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	proofs := make([]*Proof, len(witnesses))
+	for i := range witnesses {
+		s := <-chSolved
+		if s.err != nil {
+			return nil, fmt.Errorf("witness %d: %w", i, s.err)
+		}
+		proof, err := p.prove(s.buf)
+		if err != nil {
+			return nil, fmt.Errorf("witness %d: %w", i, err)
+		}
+		proofs[i] = proof
+		chFree <- s.buf
+	}
+
+	return proofs, nil
+}
+
+// solve solves the r1cs with the witness and sets the vectors of buf
+func (p *Prover) solve(witness bls12_377witness.Witness, buf *proverBuffers) error {
+	r1cs, pk, opt := p.r1cs, p.pk, p.opt
 	if len(witness) != int(r1cs.NbPublicVariables-1+r1cs.NbSecretVariables) {
-		return nil, fmt.Errorf("invalid witness size, got %d, expected %d = %d (public) + %d (secret)", len(witness), int(r1cs.NbPublicVariables-1+r1cs.NbSecretVariables), r1cs.NbPublicVariables, r1cs.NbSecretVariables)
+		return fmt.Errorf("invalid witness size, got %d, expected %d = %d (public) + %d (secret)", len(witness), int(r1cs.NbPublicVariables-1+r1cs.NbSecretVariables), r1cs.NbPublicVariables, r1cs.NbSecretVariables)
 	}
 
-	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Int("nbConstraints", len(r1cs.Constraints)).Str("backend", "groth16").Logger()
+	// the solver accumulates the a, b, c vectors, which must be zero
+	nbConstraints := len(r1cs.Constraints)
+	if buf.a == nil {
+		buf.a = make([]fr.Element, nbConstraints, pk.Domain.Cardinality)
+		buf.b = make([]fr.Element, nbConstraints, pk.Domain.Cardinality)
+		buf.c = make([]fr.Element, nbConstraints, pk.Domain.Cardinality)
+		buf.wireValuesA = make([]fr.Element, len(p.wiresA))
+		buf.wireValuesB = make([]fr.Element, len(p.wiresB))
+	} else {
+		utils.Parallelize(nbConstraints, func(start, end int) {
+			for i := start; i < end; i++ {
+				buf.a[i].SetZero()
+				buf.b[i].SetZero()
+				buf.c[i].SetZero()
+			}
+		}, p.nbTasks)
+	}
 
 	// solve the R1CS and compute the a, b, c vectors
-	a := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
-	b := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
-	c := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
-	var wireValues []fr.Element
-	var err error
-	if wireValues, err = r1cs.Solve(witness, a, b, c, opt); err != nil {
-		if !opt.Force {
-			return nil, err
+	endSolve := opt.StartPhase(backend.PhaseSolve, "solve", nbConstraints)
+	wireValues, err := r1cs.Solve(witness, buf.a, buf.b, buf.c, opt)
+	endSolve(err)
+	if err != nil {
+		if !opt.Force || p.ctx.Err() != nil {
+			return err
 		} else {
 			// we need to fill wireValues with random values else multi exps don't do much
 			var r fr.Element
@@ -81,54 +237,56 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 			}
 		}
 	}
-	start := time.Now()
 
 	// set the wire values in regular form
 	utils.Parallelize(len(wireValues), func(start, end int) {
 		for i := start; i < end; i++ {
 			wireValues[i].FromMont()
 		}
-	})
+	}, p.nbTasks)
+	buf.wireValues = wireValues
+
+	// we need to copy and filter the wireValues for each multi exp
+	// as pk.G1.A, pk.G1.B and pk.G2.B may have (a significant) number of point at infinity
+	utils.Parallelize(len(p.wiresA), func(start, end int) {
+		for i := start; i < end; i++ {
+			buf.wireValuesA[i] = wireValues[p.wiresA[i]]
+		}
+	}, p.nbTasks)
+	utils.Parallelize(len(p.wiresB), func(start, end int) {
+		for i := start; i < end; i++ {
+			buf.wireValuesB[i] = wireValues[p.wiresB[i]]
+		}
+	}, p.nbTasks)
+
+	return nil
+}
+
+// prove computes the proof from the vectors of buf, see solve
+func (p *Prover) prove(buf *proverBuffers) (*Proof, error) {
+	r1cs, pk, opt, ctx, n := p.r1cs, p.pk, p.opt, p.ctx, p.nbTasks
+	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Int("nbConstraints", len(r1cs.Constraints)).Str("backend", "groth16").Logger()
+	start := time.Now()
+
+	// the goroutines below use the key and buf: we wait for them on every
+	// return, a cancelled proof (see backend.WithContext) included
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wireValues, wireValuesA, wireValuesB := buf.wireValues, buf.wireValuesA, buf.wireValuesB
 
 	// H (witness reduction / FFT part)
 	var h []fr.Element
 	chHDone := make(chan struct{}, 1)
+	wg.Add(1)
 	go func() {
-		h = computeH(a, b, c, &pk.Domain)
-		a = nil
-		b = nil
-		c = nil
+		defer wg.Done()
+		endH := opt.StartPhase(backend.PhaseFFT, "h", int(pk.Domain.Cardinality))
+		h = computeH(ctx, buf.a, buf.b, buf.c, &pk.Domain, n)
+		endH(ctx.Err())
 		chHDone <- struct{}{}
 	}()
 
-	// we need to copy and filter the wireValues for each multi exp
-	// as pk.G1.A, pk.G1.B and pk.G2.B may have (a significant) number of point at infinity
-	var wireValuesA, wireValuesB []fr.Element
-	chWireValuesA, chWireValuesB := make(chan struct{}, 1), make(chan struct{}, 1)
-
-	go func() {
-		wireValuesA = make([]fr.Element, len(wireValues)-int(pk.NbInfinityA))
-		for i, j := 0, 0; j < len(wireValuesA); i++ {
-			if pk.InfinityA[i] {
-				continue
-			}
-			wireValuesA[j] = wireValues[i]
-			j++
-		}
-		close(chWireValuesA)
-	}()
-	go func() {
-		wireValuesB = make([]fr.Element, len(wireValues)-int(pk.NbInfinityB))
-		for i, j := 0, 0; j < len(wireValuesB); i++ {
-			if pk.InfinityB[i] {
-				continue
-			}
-			wireValuesB[j] = wireValues[i]
-			j++
-		}
-		close(chWireValuesB)
-	}()
-
 	// sample random r and s
 	var r, s big.Int
 	var _r, _s, _kr fr.Element
@@ -152,12 +310,24 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 	proof := &Proof{}
 	var bs1, ar curve.G1Jac
 
-	n := runtime.NumCPU()
+	// the multi-exponentiations in G1 run concurrently, on half of the tasks each
+	nbTasksG1 := n / 2
+	if nbTasksG1 < 1 {
+		nbTasksG1 = 1
+	}
 
 	chBs1Done := make(chan error, 1)
 	computeBS1 := func() {
-		<-chWireValuesB
-		if _, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		// the multi-exponentiations may be cancelled (see backend.WithContext)
+		if err := ctx.Err(); err != nil {
+			chBs1Done <- err
+			close(chBs1Done)
+			return
+		}
+		end := opt.StartPhase(backend.PhaseMSM, "bs1", len(wireValuesB))
+		_, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasksG1})
+		end(err)
+		if err != nil {
 			chBs1Done <- err
 			close(chBs1Done)
 			return
@@ -169,8 +339,16 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 
 	chArDone := make(chan error, 1)
 	computeAR1 := func() {
-		<-chWireValuesA
-		if _, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		// the multi-exponentiations may be cancelled (see backend.WithContext)
+		if err := ctx.Err(); err != nil {
+			chArDone <- err
+			close(chArDone)
+			return
+		}
+		end := opt.StartPhase(backend.PhaseMSM, "ar", len(wireValuesA))
+		_, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: nbTasksG1})
+		end(err)
+		if err != nil {
 			chArDone <- err
 			close(chArDone)
 			return
@@ -188,11 +366,26 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 
 		var krs, krs2, p1 curve.G1Jac
 		chKrs2Done := make(chan error, 1)
+		wg.Add(1)
 		go func() {
-			_, err := krs2.MultiExp(pk.G1.Z, h, ecc.MultiExpConfig{NbTasks: n / 2})
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				chKrs2Done <- err
+				return
+			}
+			end := opt.StartPhase(backend.PhaseMSM, "krs2", len(h))
+			_, err := krs2.MultiExp(pk.G1.Z, h, ecc.MultiExpConfig{NbTasks: nbTasksG1})
+			end(err)
 			chKrs2Done <- err
 		}()
-		if _, err := krs.MultiExp(pk.G1.K, wireValues[r1cs.NbPublicVariables:], ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if err := ctx.Err(); err != nil {
+			chKrsDone <- err
+			return
+		}
+		end := opt.StartPhase(backend.PhaseMSM, "krs", len(pk.G1.K))
+		_, err := krs.MultiExp(pk.G1.K, wireValues[r1cs.NbPublicVariables:], ecc.MultiExpConfig{NbTasks: nbTasksG1})
+		end(err)
+		if err != nil {
 			chKrsDone <- err
 			return
 		}
@@ -237,8 +430,13 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 			// if we don't have a lot of CPUs, this may artificially split the MSM
 			nbTasks *= 2
 		}
-		<-chWireValuesB
-		if _, err := Bs.MultiExp(pk.G2.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := opt.StartPhase(backend.PhaseMSM, "bs2", len(wireValuesB))
+		_, err := Bs.MultiExp(pk.G2.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasks})
+		end(err)
+		if err != nil {
 			return err
 		}
 
@@ -253,11 +451,24 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 
 	// wait for FFT to end, as it uses all our CPUs
 	<-chHDone
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// schedule our proof part computations
-	go computeKRS()
-	go computeAR1()
-	go computeBS1()
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		computeKRS()
+	}()
+	go func() {
+		defer wg.Done()
+		computeAR1()
+	}()
+	go func() {
+		defer wg.Done()
+		computeBS1()
+	}()
 	if err := computeBS2(); err != nil {
 		return nil, err
 	}
@@ -272,7 +483,8 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_377witness.Witness, opt
 	return proof, nil
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// computeH returns nil if ctx is done before the end of the computation
+func computeH(ctx context.Context, a, b, c []fr.Element, domain *fft.Domain, nbTasks int) []fr.Element {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
@@ -291,10 +503,16 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	domain.FFTInverse(a, fft.DIF)
 	domain.FFTInverse(b, fft.DIF)
 	domain.FFTInverse(c, fft.DIF)
+	if ctx.Err() != nil {
+		return nil
+	}
 
 	domain.FFT(a, fft.DIT, true)
 	domain.FFT(b, fft.DIT, true)
 	domain.FFT(c, fft.DIT, true)
+	if ctx.Err() != nil {
+		return nil
+	}
 
 	var den, one fr.Element
 	one.SetOne()
@@ -309,7 +527,7 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 				Sub(&a[i], &c[i]).
 				Mul(&a[i], &den)
 		}
-	})
+	}, nbTasks)
 
 	// ifft_coset
 	domain.FFTInverse(a, fft.DIF, true)
@@ -318,7 +536,7 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 		for i := start; i < end; i++ {
 			a[i].FromMont()
 		}
-	})
+	}, nbTasks)
 
 	return a
 }
@@ -25,7 +25,9 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/fft"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/internal/utils"
 	"math/big"
 	"math/bits"
 )
@@ -77,7 +79,12 @@ type VerifyingKey struct {
 }
 
 // Setup constructs the SRS
-func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
+func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey, opts ...backend.SetupOption) error {
+	opt, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return err
+	}
+
 	/*
 		Setup
 		-----
@@ -129,33 +136,37 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	pkK := make([]fr.Element, nbPrivateWires)
 	vkK := make([]fr.Element, nbPublicWires)
 
-	var t0, t1 fr.Element
-
-	for i := 0; i < nbPublicWires; i++ {
-		t1.Mul(&A[i], &toxicWaste.beta)
-		t0.Mul(&B[i], &toxicWaste.alpha)
-		t1.Add(&t1, &t0).
-			Add(&t1, &C[i]).
-			Mul(&t1, &toxicWaste.gammaInv)
-		vkK[i] = t1.ToRegular()
-	}
-
-	for i := 0; i < nbPrivateWires; i++ {
-		t1.Mul(&A[i+nbPublicWires], &toxicWaste.beta)
-		t0.Mul(&B[i+nbPublicWires], &toxicWaste.alpha)
-		t1.Add(&t1, &t0).
-			Add(&t1, &C[i+nbPublicWires]).
-			Mul(&t1, &toxicWaste.deltaInv)
-		pkK[i] = t1.ToRegular()
-	}
+	utils.Parallelize(nbPublicWires, func(start, end int) {
+		var t0, t1 fr.Element
+		for i := start; i < end; i++ {
+			t1.Mul(&A[i], &toxicWaste.beta)
+			t0.Mul(&B[i], &toxicWaste.alpha)
+			t1.Add(&t1, &t0).
+				Add(&t1, &C[i]).
+				Mul(&t1, &toxicWaste.gammaInv)
+			vkK[i] = t1.ToRegular()
+		}
+	}, opt.NbTasks)
+
+	utils.Parallelize(nbPrivateWires, func(start, end int) {
+		var t0, t1 fr.Element
+		for i := start; i < end; i++ {
+			t1.Mul(&A[i+nbPublicWires], &toxicWaste.beta)
+			t0.Mul(&B[i+nbPublicWires], &toxicWaste.alpha)
+			t1.Add(&t1, &t0).
+				Add(&t1, &C[i+nbPublicWires]).
+				Mul(&t1, &toxicWaste.deltaInv)
+			pkK[i] = t1.ToRegular()
+		}
+	}, opt.NbTasks)
 
 	// convert A and B to regular form
-	for i := 0; i < int(nbWires); i++ {
-		A[i].FromMont()
-	}
-	for i := 0; i < int(nbWires); i++ {
-		B[i].FromMont()
-	}
+	utils.Parallelize(nbWires, func(start, end int) {
+		for i := start; i < end; i++ {
+			A[i].FromMont()
+			B[i].FromMont()
+		}
+	}, opt.NbTasks)
 
 	// Z part of the proving key (scalars)
 	Z := make([]fr.Element, domain.Cardinality)
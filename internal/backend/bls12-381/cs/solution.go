@@ -19,9 +19,11 @@ package cs
 import (
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"sync/atomic"
 
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/schema"
@@ -39,24 +41,31 @@ type solution struct {
 	values, coefficients []fr.Element
 	solved               []bool
 	nbSolved             uint64
-	mHintsFunctions      map[hint.ID]hint.Function // maps hintID to hint function
-	mHints               map[int]*compiled.Hint    // maps wireID to hint
+	mHintsFunctions      map[hint.ID]hint.Function     // maps hintID to hint function
+	mHints               map[int]*compiled.Hint        // maps wireID to hint
+	mRichHintsFunctions  map[hint.ID]hint.RichFunction // maps hintID to rich hint function
+	hintContext          interface{}                   // given to rich hint functions in hint.Context
+	solvedBy             []int                         // constraint which solved each wire, if tracked (see backend.WithSolutionDump)
 }
 
-func newSolution(nbWires int, hintFunctions map[hint.ID]hint.Function, hintsDependencies map[hint.ID]string, mHints map[int]*compiled.Hint, coefficients []fr.Element) (solution, error) {
+func newSolution(nbWires int, hintFunctions map[hint.ID]hint.Function, richHintFunctions map[hint.ID]hint.RichFunction, hintContext interface{}, hintsDependencies map[hint.ID]string, mHints map[int]*compiled.Hint, coefficients []fr.Element) (solution, error) {
 
 	s := solution{
-		values:          make([]fr.Element, nbWires),
-		coefficients:    coefficients,
-		solved:          make([]bool, nbWires),
-		mHintsFunctions: hintFunctions,
-		mHints:          mHints,
+		values:              make([]fr.Element, nbWires),
+		coefficients:        coefficients,
+		solved:              make([]bool, nbWires),
+		mHintsFunctions:     hintFunctions,
+		mRichHintsFunctions: richHintFunctions,
+		hintContext:         hintContext,
+		mHints:              mHints,
 	}
 
 	// hintsDependencies is from compile time; it contains the list of hints the solver **needs**
 	var missing []string
 	for hintUUID, hintID := range hintsDependencies {
-		if _, ok := s.mHintsFunctions[hintUUID]; !ok {
+		_, ok := s.mHintsFunctions[hintUUID]
+		_, okRich := s.mRichHintsFunctions[hintUUID]
+		if !ok && !okRich {
 			missing = append(missing, hintID)
 		}
 	}
@@ -68,16 +77,36 @@ func newSolution(nbWires int, hintFunctions map[hint.ID]hint.Function, hintsDepe
 	return s, nil
 }
 
-func (s *solution) set(id int, value fr.Element) {
+func (s *solution) set(id int, value fr.Element, cID int) {
 	if s.solved[id] {
 		panic("solving the same wire twice should never happen.")
 	}
 	s.values[id] = value
 	s.solved[id] = true
+	if s.solvedBy != nil {
+		s.solvedBy[id] = cID
+	}
 	atomic.AddUint64(&s.nbSolved, 1)
 	// s.nbSolved++
 }
 
+// trackSolvedBy makes the solution record the constraint which solves each wire
+func (s *solution) trackSolvedBy() {
+	s.solvedBy = make([]int, len(s.values))
+}
+
+// dump writes the solution, which may be partial, to w (see backend.WithSolutionDump)
+func (s *solution) dump(cs *compiled.ConstraintSystem, w io.Writer, format backend.DumpFormat) error {
+	values := make([]*big.Int, len(s.values))
+	for i := range s.values {
+		if s.solved[i] {
+			values[i] = new(big.Int)
+			s.values[i].ToBigIntRegular(values[i])
+		}
+	}
+	return cs.WriteSolution(w, format, values, s.solvedBy)
+}
+
 func (s *solution) isValid() bool {
 	return int(s.nbSolved) == len(s.values)
 }
@@ -131,21 +160,15 @@ func (s *solution) accumulateInto(t compiled.Term, r *fr.Element) {
 }
 
 // solveHint compute solution.values[vID] using provided solver hint
-func (s *solution) solveWithHint(vID int, h *compiled.Hint) error {
+func (s *solution) solveWithHint(vID int, h *compiled.Hint, cID int) error {
 	// skip if the wire is already solved by a call to the same hint
 	// function on the same inputs
 	if s.solved[vID] {
 		return nil
 	}
-	// ensure hint function was provided
-	f, ok := s.mHintsFunctions[h.ID]
-	if !ok {
-		return errors.New("missing hint function")
-	}
-
 	// tmp IO big int memory
 	nbInputs := len(h.Inputs)
-	nbOutputs := len(h.Wires)
+	nbOutputs := len(h.Outputs())
 	inputs := make([]*big.Int, nbInputs)
 	outputs := make([]*big.Int, nbOutputs)
 	for i := 0; i < nbOutputs; i++ {
@@ -167,7 +190,7 @@ func (s *solution) solveWithHint(vID int, h *compiled.Hint) error {
 		// unsolved dependency
 		if h, ok := s.mHints[wID]; ok {
 			// solve recursively.
-			return s.solveWithHint(wID, h)
+			return s.solveWithHint(wID, h, cID)
 		}
 
 		// it's not a hint, we panic.
@@ -202,32 +225,90 @@ func (s *solution) solveWithHint(vID int, h *compiled.Hint) error {
 		}
 	}
 
-	err := f(curve.ID, inputs, outputs)
+	nbSet, err := s.callHint(h, q, inputs, outputs)
 
 	var v fr.Element
 	for i := range outputs {
 		v.SetBigInt(outputs[i])
-		s.set(h.Wires[i], v)
+		s.set(h.Wires[i], v, cID)
+	}
+	if h.VariableOutputs {
+		v.SetUint64(uint64(nbSet))
+		s.set(h.Wires[nbOutputs], v, cID)
 	}
 
 	return err
 }
 
+// callHint calls the hint function of h and returns the number of outputs it set
+func (s *solution) callHint(h *compiled.Hint, q *big.Int, inputs, outputs []*big.Int) (int, error) {
+	if !h.Rich {
+		f, ok := s.mHintsFunctions[h.ID]
+		if !ok {
+			return 0, errors.New("missing hint function")
+		}
+		return len(outputs), f(curve.ID, inputs, outputs)
+	}
+
+	f, ok := s.mRichHintsFunctions[h.ID]
+	if !ok {
+		return 0, errors.New("missing hint function")
+	}
+	ctx := &hint.Context{CurveID: curve.ID, Modulus: q, Params: h.Params, Value: s.hintContext}
+	n, err := f(ctx, inputs, outputs)
+	if err != nil {
+		return 0, err
+	}
+	if !h.VariableOutputs {
+		if n != len(outputs) {
+			return 0, fmt.Errorf("hint function set %d outputs, expected %d", n, len(outputs))
+		}
+		return n, nil
+	}
+	if n < 0 || n > len(outputs) {
+		return 0, fmt.Errorf("hint function set %d outputs, expected at most %d", n, len(outputs))
+	}
+	for i := n; i < len(outputs); i++ {
+		outputs[i].SetUint64(0)
+	}
+	return n, nil
+}
+
 func (s *solution) printLogs(log zerolog.Logger, logs []compiled.LogEntry) {
 	if log.GetLevel() == zerolog.Disabled {
 		return
 	}
 
 	for i := 0; i < len(logs); i++ {
-		logLine := s.logValue(logs[i])
-		log.Debug().Str(zerolog.CallerFieldName, logs[i].Caller).Msg(logLine)
+		logLine, fields := s.resolveLog(logs[i])
+		e := log.Debug().Str(zerolog.CallerFieldName, logs[i].Caller)
+		for j, name := range logs[i].Fields {
+			e = e.Str(name, fields[j])
+		}
+		e.Msg(logLine)
 	}
 }
 
 const unsolvedVariable = "<unsolved>"
 
+// unsolved formats as unsolvedVariable with any verb
+type unsolved struct{}
+
+func (unsolved) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, unsolvedVariable)
+}
+
 func (s *solution) logValue(log compiled.LogEntry) string {
+	logLine, _ := s.resolveLog(log)
+	return logLine
+}
+
+// resolveLog returns the log line of the entry and, if it was created with
+// api.Printf, the decimal values of its fields
+func (s *solution) resolveLog(log compiled.LogEntry) (string, []string) {
 	var toResolve []interface{}
+	var fields []string
+	printf := len(log.Fields) != 0
 	var (
 		isEval       bool
 		eval         fr.Element
@@ -243,9 +324,19 @@ func (s *solution) logValue(log compiled.LogEntry) string {
 				continue
 			}
 			isEval = false
-			if missingValue {
+			switch {
+			case printf && missingValue:
+				toResolve = append(toResolve, unsolved{})
+				fields = append(fields, unsolvedVariable)
+			case printf:
+				// the verbs of api.Printf apply to the canonical value
+				v := new(big.Int)
+				eval.ToBigIntRegular(v)
+				toResolve = append(toResolve, v)
+				fields = append(fields, v.String())
+			case missingValue:
 				toResolve = append(toResolve, unsolvedVariable)
-			} else {
+			default:
 				// we have to append our accumulator
 				toResolve = append(toResolve, eval.String())
 			}
@@ -287,7 +378,7 @@ func (s *solution) logValue(log compiled.LogEntry) string {
 			toResolve = append(toResolve, s.values[vID].String())
 		}
 	}
-	return fmt.Sprintf(log.Format, toResolve...)
+	return fmt.Sprintf(log.Format, toResolve...), fields
 }
 
 // UnsatisfiedConstraintError wraps an error with useful metadata on the unsatisfied constraint
@@ -17,7 +17,9 @@
 package plonk
 
 import (
+	"context"
 	"crypto/sha256"
+	"fmt"
 	"math/big"
 	"math/bits"
 	"runtime"
@@ -62,24 +64,154 @@ type Proof struct {
 
 // Prove from the public data
 func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witness, opt backend.ProverConfig) (*Proof, error) {
+	return NewProver(spr, pk, opt).Prove(fullWitness)
+}
 
-	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "plonk").Logger()
-	start := time.Now()
-	// pick a hash function that will be used to derive the challenges
-	hFunc := sha256.New()
+// Prover generates the proofs of a sparse R1CS with a ProvingKey for many
+// witnesses. It keeps the buffers of a proof for the next ones, and the
+// evaluations which only depend on the ProvingKey. ProveBatch also solves a
+// witness while the proof of the previous one is computed.
+//
+// A Prover is not safe for concurrent use.
+type Prover struct {
+	spr     *cs.SparseR1CS
+	pk      *ProvingKey
+	opt     backend.ProverConfig
+	ctx     context.Context
+	nbTasks int
+
+	evaluationIDSmallDomain     []fr.Element // identity permutation on the small domain, see getIDSmallDomain
+	evaluationXnMinusOneInverse []fr.Element // inverses of Xᵐ-1 on the coset of the big domain
+	startsAtOne                 []fr.Element // L₁ on the coset of the big domain
+
+	// ProveBatch solves a witness with one while the proof of the previous
+	// witness is computed with the other
+	buffers [2]proverBuffers
+}
 
-	// create a transcript manager to apply Fiat Shamir
-	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
+// proverBuffers holds the vectors of a proof which depend on the witness
+type proverBuffers struct {
+	fullWitness bls12_381witness.Witness
+	l, r, o     []fr.Element // solution in Lagrange basis on the small domain, not blinded
+}
 
-	// result
-	proof := &Proof{}
+// NewProver returns a Prover of the sparse R1CS with the ProvingKey pk and the
+// options opt.
+func NewProver(spr *cs.SparseR1CS, pk *ProvingKey, opt backend.ProverConfig) *Prover {
+	p := &Prover{
+		spr:     spr,
+		pk:      pk,
+		opt:     opt,
+		ctx:     opt.Context,
+		nbTasks: opt.NbTasks,
+	}
+	if p.ctx == nil {
+		p.ctx = context.Background()
+	}
+	if p.nbTasks <= 0 {
+		p.nbTasks = runtime.NumCPU()
+	}
+
+	p.evaluationIDSmallDomain = getIDSmallDomain(&pk.Domain[0])
+
+	// evaluate Z = Xᵐ-1 on a coset of the big domain
+	p.evaluationXnMinusOneInverse = evaluateXnMinusOneDomainBigCoset(&pk.Domain[1], &pk.Domain[0])
+	p.evaluationXnMinusOneInverse = fr.BatchInvert(p.evaluationXnMinusOneInverse)
+
+	// computes L₁ (canonical form)
+	p.startsAtOne = make([]fr.Element, pk.Domain[1].Cardinality)
+	for i := 0; i < int(pk.Domain[0].Cardinality); i++ {
+		p.startsAtOne[i].Set(&pk.Domain[0].CardinalityInv)
+	}
+	pk.Domain[1].FFT(p.startsAtOne, fft.DIF, true)
+
+	return p
+}
+
+// Prove from the public data
+func (p *Prover) Prove(fullWitness bls12_381witness.Witness) (proof *Proof, err error) {
+	end := p.opt.StartPhase(backend.PhaseProve, "plonk", len(p.spr.Constraints))
+	defer func() { end(err) }()
+
+	if err = p.solve(fullWitness, &p.buffers[0]); err != nil {
+		return nil, err
+	}
+	return p.prove(&p.buffers[0])
+}
+
+// ProveBatch generates the proofs of the witnesses, in order: the witness i+1
+// is solved while the proof of the witness i is computed, so the events of
+// these steps (see backend.WithProgress) overlap. It stops at the first witness
+// which can't be proved and returns its error.
+func (p *Prover) ProveBatch(fullWitnesses []bls12_381witness.Witness) ([]*Proof, error) {
+	type solved struct {
+		buf *proverBuffers
+		err error
+	}
+	chSolved := make(chan solved, 1)
+	chFree := make(chan *proverBuffers, len(p.buffers))
+	for i := range p.buffers {
+		chFree <- &p.buffers[i]
+	}
+	// the solver stops once we return, and we wait for it: it mustn't use the
+	// buffers after ProveBatch returns
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, fullWitness := range fullWitnesses {
+			var buf *proverBuffers
+			select {
+			case buf = <-chFree:
+			case <-done:
+				return
+			}
+			err := p.solve(fullWitness, buf)
+			select {
+			case chSolved <- solved{buf: buf, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	proofs := make([]*Proof, len(fullWitnesses))
+	for i := range fullWitnesses {
+		s := <-chSolved
+		if s.err != nil {
+			return nil, fmt.Errorf("witness %d: %w", i, s.err)
+		}
+		proof, err := p.prove(s.buf)
+		if err != nil {
+			return nil, fmt.Errorf("witness %d: %w", i, err)
+		}
+		proofs[i] = proof
+		chFree <- s.buf
+	}
+
+	return proofs, nil
+}
+
+// solve solves the sparse R1CS with the witness and sets the vectors of buf
+func (p *Prover) solve(fullWitness bls12_381witness.Witness, buf *proverBuffers) error {
+	spr, pk, opt := p.spr, p.pk, p.opt
 
 	// compute the constraint system solution
-	var solution []fr.Element
-	var err error
-	if solution, err = spr.Solve(fullWitness, opt); err != nil {
-		if !opt.Force {
-			return nil, err
+	endSolve := opt.StartPhase(backend.PhaseSolve, "solve", len(spr.Constraints))
+	solution, err := spr.Solve(fullWitness, opt)
+	endSolve(err)
+	if err != nil {
+		if !opt.Force || p.ctx.Err() != nil {
+			return err
 		} else {
 			// we need to fill solution with random values
 			var r fr.Element
@@ -92,21 +224,65 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	}
 
 	// query l, r, o in Lagrange basis, not blinded
-	evaluationLDomainSmall, evaluationRDomainSmall, evaluationODomainSmall := evaluateLROSmallDomain(spr, pk, solution)
+	if buf.l == nil {
+		buf.l = make([]fr.Element, pk.Domain[0].Cardinality)
+		buf.r = make([]fr.Element, pk.Domain[0].Cardinality)
+		buf.o = make([]fr.Element, pk.Domain[0].Cardinality)
+	}
+	evaluateLROSmallDomain(spr, pk, solution, buf.l, buf.r, buf.o)
+	buf.fullWitness = fullWitness
+
+	return nil
+}
+
+// prove computes the proof from the vectors of buf, see solve
+func (p *Prover) prove(buf *proverBuffers) (*Proof, error) {
+	spr, pk, opt, ctx, nbTasks := p.spr, p.pk, p.opt, p.ctx, p.nbTasks
+	fullWitness := buf.fullWitness
+	evaluationLDomainSmall, evaluationRDomainSmall, evaluationODomainSmall := buf.l, buf.r, buf.o
+
+	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "plonk").Logger()
+	start := time.Now()
+
+	// the goroutines below use the key and buf: we wait for them on every
+	// return, a cancelled proof (see backend.WithContext) included
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// pick a hash function that will be used to derive the challenges
+	hFunc := sha256.New()
+
+	// create a transcript manager to apply Fiat Shamir
+	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
+
+	// result
+	proof := &Proof{}
 
 	// save ll, lr, lo, and make a copy of them in canonical basis.
 	// note that we allocate more capacity to reuse for blinded polynomials
+	endLRO := opt.StartPhase(backend.PhaseFFT, "lro", int(pk.Domain[0].Cardinality))
 	blindedLCanonical, blindedRCanonical, blindedOCanonical, err := computeBlindedLROCanonical(
 		evaluationLDomainSmall,
 		evaluationRDomainSmall,
 		evaluationODomainSmall,
 		&pk.Domain[0])
+	endLRO(err)
 	if err != nil {
 		return nil, err
 	}
+	// the proof may be cancelled between the FFTs and the commitments (see backend.WithContext)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// compute kzg commitments of bcl, bcr and bco
-	if err := commitToLRO(blindedLCanonical, blindedRCanonical, blindedOCanonical, proof, pk.Vk.KZGSRS); err != nil {
+	endCommit := opt.StartPhase(backend.PhaseCommit, "lro", len(blindedLCanonical))
+	err = commitToLRO(blindedLCanonical, blindedRCanonical, blindedOCanonical, proof, pk.Vk.KZGSRS, nbTasks)
+	endCommit(err)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
@@ -134,25 +310,36 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	var blindedZCanonical []fr.Element
 	chZ := make(chan error, 1)
 	var alpha fr.Element
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		var err error
 		blindedZCanonical, err = computeBlindedZCanonical(
 			evaluationLDomainSmall,
 			evaluationRDomainSmall,
 			evaluationODomainSmall,
-			pk, beta, gamma)
+			pk, p.evaluationIDSmallDomain, beta, gamma, nbTasks)
 		if err != nil {
 			chZ <- err
 			close(chZ)
 			return
 		}
 
+		if err := ctx.Err(); err != nil {
+			chZ <- err
+			close(chZ)
+			return
+		}
+
 		// commit to the blinded version of z
 		// note that we explicitly double the number of tasks for the multi exp in kzg.Commit
 		// this may add additional arithmetic operations, but with smaller tasks
 		// we ensure that this commitment is well parallelized, without having a "unbalanced task" making
 		// the rest of the code wait too long.
-		if proof.Z, err = kzg.Commit(blindedZCanonical, pk.Vk.KZGSRS, runtime.NumCPU()*2); err != nil {
+		endZ := opt.StartPhase(backend.PhaseCommit, "z", len(blindedZCanonical))
+		proof.Z, err = kzg.Commit(blindedZCanonical, pk.Vk.KZGSRS, nbTasks*2)
+		endZ(err)
+		if err != nil {
 			chZ <- err
 			close(chZ)
 			return
@@ -172,25 +359,34 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		evaluationBlindedODomainBigBitReversed []fr.Element
 		evaluationBlindedZDomainBigBitReversed []fr.Element
 	)
+	endQuotient := opt.StartPhase(backend.PhaseQuotient, "h", int(pk.Domain[1].Cardinality))
 	chEvalBL := make(chan struct{}, 1)
 	chEvalBR := make(chan struct{}, 1)
 	chEvalBO := make(chan struct{}, 1)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		evaluationBlindedLDomainBigBitReversed = evaluateDomainBigBitReversed(blindedLCanonical, &pk.Domain[1])
 		close(chEvalBL)
 	}()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		evaluationBlindedRDomainBigBitReversed = evaluateDomainBigBitReversed(blindedRCanonical, &pk.Domain[1])
 		close(chEvalBR)
 	}()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		evaluationBlindedODomainBigBitReversed = evaluateDomainBigBitReversed(blindedOCanonical, &pk.Domain[1])
 		close(chEvalBO)
 	}()
 
 	var constraintsInd, constraintsOrdering []fr.Element
 	chConstraintInd := make(chan struct{}, 1)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		// compute qk in canonical basis, completed with the public inputs
 		qkCompletedCanonical := make([]fr.Element, pk.Domain[0].Cardinality)
 		copy(qkCompletedCanonical, fullWitness[:spr.NbPublicVariables])
@@ -208,12 +404,15 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			evaluationBlindedLDomainBigBitReversed,
 			evaluationBlindedRDomainBigBitReversed,
 			evaluationBlindedODomainBigBitReversed,
-			qkCompletedCanonical)
+			qkCompletedCanonical,
+			nbTasks)
 		close(chConstraintInd)
 	}()
 
 	chConstraintOrdering := make(chan error, 1)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		if err := <-chZ; err != nil {
 			chConstraintOrdering <- err
 			return
@@ -232,22 +431,38 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			evaluationBlindedRDomainBigBitReversed,
 			evaluationBlindedODomainBigBitReversed,
 			beta,
-			gamma)
+			gamma,
+			nbTasks)
 		chConstraintOrdering <- nil
 		close(chConstraintOrdering)
 	}()
 
 	if err := <-chConstraintOrdering; err != nil {
+		endQuotient(err)
 		return nil, err
 	}
 
 	<-chConstraintInd
+	if err := ctx.Err(); err != nil {
+		endQuotient(err)
+		return nil, err
+	}
 
 	// compute h in canonical form
-	h1, h2, h3 := computeQuotientCanonical(pk, constraintsInd, constraintsOrdering, evaluationBlindedZDomainBigBitReversed, alpha)
+	h1, h2, h3 := computeQuotientCanonical(pk, constraintsInd, constraintsOrdering, evaluationBlindedZDomainBigBitReversed, p.evaluationXnMinusOneInverse, p.startsAtOne, alpha, nbTasks)
+	endQuotient(nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// compute kzg commitments of h1, h2 and h3
-	if err := commitToQuotient(h1, h2, h3, proof, pk.Vk.KZGSRS); err != nil {
+	endH := opt.StartPhase(backend.PhaseCommit, "h", len(h1))
+	err = commitToQuotient(h1, h2, h3, proof, pk.Vk.KZGSRS, nbTasks)
+	endH(err)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
@@ -261,27 +476,39 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	var blzeta, brzeta, bozeta fr.Element
 	var wgZetaEvals sync.WaitGroup
 	wgZetaEvals.Add(3)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		blzeta = eval(blindedLCanonical, zeta)
 		wgZetaEvals.Done()
 	}()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		brzeta = eval(blindedRCanonical, zeta)
 		wgZetaEvals.Done()
 	}()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		bozeta = eval(blindedOCanonical, zeta)
 		wgZetaEvals.Done()
 	}()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// open blinded Z at zeta*z
 	var zetaShifted fr.Element
 	zetaShifted.Mul(&zeta, &pk.Vk.Generator)
+	endZ := opt.StartPhase(backend.PhaseOpen, "z", len(blindedZCanonical))
 	proof.ZShiftedOpening, err = kzg.Open(
 		blindedZCanonical,
 		zetaShifted,
 		pk.Vk.KZGSRS,
 	)
+	endZ(err)
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +523,9 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	)
 	chLpoly := make(chan struct{}, 1)
 
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		// compute the linearization polynomial r at zeta (goal: save committing separately to z, ql, qr, qm, qo, k)
 		wgZetaEvals.Wait()
 		linearizedPolynomialCanonical = computeLinearizedPolynomial(
@@ -310,11 +539,19 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			bzuzeta,
 			blindedZCanonical,
 			pk,
+			nbTasks,
 		)
 
+		if errLPoly = ctx.Err(); errLPoly != nil {
+			close(chLpoly)
+			return
+		}
+
 		// TODO this commitment is only necessary to derive the challenge, we should
 		// be able to avoid doing it and get the challenge in another way
-		linearizedPolynomialDigest, errLPoly = kzg.Commit(linearizedPolynomialCanonical, pk.Vk.KZGSRS)
+		endLinearized := opt.StartPhase(backend.PhaseCommit, "linearized", len(linearizedPolynomialCanonical))
+		linearizedPolynomialDigest, errLPoly = kzg.Commit(linearizedPolynomialCanonical, pk.Vk.KZGSRS, nbTasks)
+		endLinearized(errLPoly)
 		close(chLpoly)
 	}()
 
@@ -339,14 +576,19 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			foldedH[i].Mul(&foldedH[i], &zetaPowerm) // ζ²⁽ᵐ⁺²⁾*h3+h2*ζᵐ⁺²
 			foldedH[i].Add(&foldedH[i], &h1[i])      // ζ^{2(m+2)*h3+ζᵐ⁺²*h2 + h1
 		}
-	})
+	}, nbTasks)
 
 	<-chLpoly
 	if errLPoly != nil {
 		return nil, errLPoly
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Batch open the first list of polynomials
+	endBatch := opt.StartPhase(backend.PhaseOpen, "batch", len(foldedH))
 	proof.BatchedProof, err = kzg.BatchOpenSinglePoint(
 		[][]fr.Element{
 			foldedH,
@@ -370,6 +612,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		hFunc,
 		pk.Vk.KZGSRS,
 	)
+	endBatch(err)
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
@@ -391,8 +634,11 @@ func eval(c []fr.Element, p fr.Element) fr.Element {
 }
 
 // fills proof.LRO with kzg commits of bcl, bcr and bco
-func commitToLRO(bcl, bcr, bco []fr.Element, proof *Proof, srs *kzg.SRS) error {
-	n := runtime.NumCPU() / 2
+func commitToLRO(bcl, bcr, bco []fr.Element, proof *Proof, srs *kzg.SRS, nbTasks int) error {
+	n := nbTasks / 2
+	if n < 1 {
+		n = 1
+	}
 	var err0, err1, err2 error
 	chCommit0 := make(chan struct{}, 1)
 	chCommit1 := make(chan struct{}, 1)
@@ -417,8 +663,11 @@ func commitToLRO(bcl, bcr, bco []fr.Element, proof *Proof, srs *kzg.SRS) error {
 	return err1
 }
 
-func commitToQuotient(h1, h2, h3 []fr.Element, proof *Proof, srs *kzg.SRS) error {
-	n := runtime.NumCPU() / 2
+func commitToQuotient(h1, h2, h3 []fr.Element, proof *Proof, srs *kzg.SRS, nbTasks int) error {
+	n := nbTasks / 2
+	if n < 1 {
+		n = 1
+	}
 	var err0, err1, err2 error
 	chCommit0 := make(chan struct{}, 1)
 	chCommit1 := make(chan struct{}, 1)
@@ -519,16 +768,13 @@ func blindPoly(cp []fr.Element, rou, bo uint64) ([]fr.Element, error) {
 
 }
 
-// evaluateLROSmallDomain extracts the solution l, r, o, and returns it in lagrange form.
+// evaluateLROSmallDomain extracts the solution l, r, o in lagrange form, of size
+// pk.Domain[0].Cardinality.
 // solution = [ public | secret | internal ]
-func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
+func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.Element, l, r, o []fr.Element) {
 
 	s := int(pk.Domain[0].Cardinality)
 
-	var l, r, o []fr.Element
-	l = make([]fr.Element, s)
-	r = make([]fr.Element, s)
-	o = make([]fr.Element, s)
 	s0 := solution[0]
 
 	for i := 0; i < spr.NbPublicVariables; i++ { // placeholders
@@ -550,8 +796,6 @@ func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.El
 		o[offset+i] = s0
 	}
 
-	return l, r, o
-
 }
 
 // computeZ computes Z, in canonical basis, where:
@@ -563,7 +807,7 @@ func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.El
 //								     (l(g^k)+β*s1(g^k)+γ)*(r(g^k)+β*s2(g^k)+γ)*(o(g^k)+β*s3(\g^k)+γ)
 //
 //	* l, r, o are the solution in Lagrange basis, evaluated on the small domain
-func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma fr.Element) ([]fr.Element, error) {
+func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, evaluationIDSmallDomain []fr.Element, beta, gamma fr.Element, nbTasks int) ([]fr.Element, error) {
 
 	// note that z has more capacity has its memory is reused for blinded z later on
 	z := make([]fr.Element, pk.Domain[0].Cardinality, pk.Domain[0].Cardinality+3)
@@ -573,8 +817,6 @@ func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma
 	z[0].SetOne()
 	gInv[0].SetOne()
 
-	evaluationIDSmallDomain := getIDSmallDomain(&pk.Domain[0])
-
 	utils.Parallelize(nbElmts-1, func(start, end int) {
 
 		var f [3]fr.Element
@@ -596,7 +838,7 @@ func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma
 			gInv[i+1] = g[0]
 			z[i+1] = f[0]
 		}
-	})
+	}, nbTasks)
 
 	gInv = fr.BatchInvert(gInv)
 	for i := 1; i < nbElmts; i++ {
@@ -616,7 +858,7 @@ func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma
 //
 // * evalL, evalR, evalO are the evaluation of the blinded solution vectors on odd cosets
 // * qk is the completed version of qk, in canonical version
-func evaluateConstraintsDomainBigBitReversed(pk *ProvingKey, evalL, evalR, evalO, qk []fr.Element) []fr.Element {
+func evaluateConstraintsDomainBigBitReversed(pk *ProvingKey, evalL, evalR, evalO, qk []fr.Element, nbTasks int) []fr.Element {
 	var evalQl, evalQr, evalQm, evalQo, evalQk []fr.Element
 	var wg sync.WaitGroup
 	wg.Add(4)
@@ -655,7 +897,7 @@ func evaluateConstraintsDomainBigBitReversed(pk *ProvingKey, evalL, evalR, evalO
 			t0.Add(&t0, &t1)               // ql.l + qr.r + qm.l.r + qo.o
 			evalQk[i].Add(&t0, &evalQk[i]) // ql.l + qr.r + qm.l.r + qo.o + k
 		}
-	})
+	}, nbTasks)
 
 	return evalQk
 }
@@ -666,7 +908,7 @@ func evaluateConstraintsDomainBigBitReversed(pk *ProvingKey, evalL, evalR, evalO
 // * z evaluation of the blinded permutation accumulator polynomial on odd cosets
 // * l, r, o evaluation of the blinded solution vectors on odd cosets
 // * gamma randomization
-func evaluateOrderingDomainBigBitReversed(pk *ProvingKey, z, l, r, o []fr.Element, beta, gamma fr.Element) []fr.Element {
+func evaluateOrderingDomainBigBitReversed(pk *ProvingKey, z, l, r, o []fr.Element, beta, gamma fr.Element, nbTasks int) []fr.Element {
 
 	nbElmts := int(pk.Domain[1].Cardinality)
 
@@ -713,7 +955,7 @@ func evaluateOrderingDomainBigBitReversed(pk *ProvingKey, z, l, r, o []fr.Elemen
 
 			evaluationIDBigDomain.Mul(&evaluationIDBigDomain, &pk.Domain[1].Generator) // gⁱ*g
 		}
-	})
+	}, nbTasks)
 
 	return res
 }
@@ -761,21 +1003,10 @@ func evaluateXnMinusOneDomainBigCoset(domainBig, domainSmall *fft.Domain) []fr.E
 // ql(X)L(X)+qr(X)R(X)+qm(X)L(X)R(X)+qo(X)O(X)+k(X) + α.(z(μX)*g₁(X)*g₂(X)*g₃(X)-z(X)*f₁(X)*f₂(X)*f₃(X)) + α²*L₁(X)*(Z(X)-1)= h(X)Z(X)
 //
 // constraintInd, constraintOrdering are evaluated on the big domain (coset).
-func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReversed, evaluationConstraintOrderingBitReversed, evaluationBlindedZDomainBigBitReversed []fr.Element, alpha fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
+func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReversed, evaluationConstraintOrderingBitReversed, evaluationBlindedZDomainBigBitReversed, evaluationXnMinusOneInverse, startsAtOne []fr.Element, alpha fr.Element, nbTasks int) ([]fr.Element, []fr.Element, []fr.Element) {
 
 	h := make([]fr.Element, pk.Domain[1].Cardinality)
 
-	// evaluate Z = Xᵐ-1 on a coset of the big domain
-	evaluationXnMinusOneInverse := evaluateXnMinusOneDomainBigCoset(&pk.Domain[1], &pk.Domain[0])
-	evaluationXnMinusOneInverse = fr.BatchInvert(evaluationXnMinusOneInverse)
-
-	// computes L₁ (canonical form)
-	startsAtOne := make([]fr.Element, pk.Domain[1].Cardinality)
-	for i := 0; i < int(pk.Domain[0].Cardinality); i++ {
-		startsAtOne[i].Set(&pk.Domain[0].CardinalityInv)
-	}
-	pk.Domain[1].FFT(startsAtOne, fft.DIF, true)
-
 	// ql(X)L(X)+qr(X)R(X)+qm(X)L(X)R(X)+qo(X)O(X)+k(X) + α.(z(μX)*g₁(X)*g₂(X)*g₃(X)-z(X)*f₁(X)*f₂(X)*f₃(X)) + α**2*L₁(X)(Z(X)-1)
 	// on a coset of the big domain
 	nn := uint64(64 - bits.TrailingZeros64(pk.Domain[1].Cardinality))
@@ -798,7 +1029,7 @@ func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReverse
 				Add(&h[_i], &evaluationConstraintsIndBitReversed[_i]).
 				Mul(&h[_i], &evaluationXnMinusOneInverse[i%ratio])
 		}
-	})
+	}, nbTasks)
 
 	// put h in canonical form. h is of degree 3*(n+1)+2.
 	// using fft.DIT put h revert bit reverse
@@ -824,7 +1055,7 @@ func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReverse
 // α²*L₁(ζ)*Z(X)
 // + α*( (l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*Z(μζ)*s3(X) - Z(X)*(l(ζ)+β*id1(ζ)+γ)*(r(ζ)+β*id2(ζ)+γ)*(o(ζ)+β*id3(ζ)+γ))
 // + l(ζ)*Ql(X) + l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X)
-func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu fr.Element, blindedZCanonical []fr.Element, pk *ProvingKey) []fr.Element {
+func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu fr.Element, blindedZCanonical []fr.Element, pk *ProvingKey, nbTasks int) []fr.Element {
 
 	// first part: individual constraints
 	var rl fr.Element
@@ -907,7 +1138,7 @@ func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta,
 			t0.Mul(&blindedZCanonical[i], &lagrangeZeta)
 			linPol[i].Add(&linPol[i], &t0) // finish the computation
 		}
-	})
+	}, nbTasks)
 
 	return linPol
 }
@@ -17,6 +17,7 @@
 package cs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/fxamacker/cbor/v2"
@@ -32,6 +33,7 @@ import (
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/backend/ioutils"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -54,9 +56,11 @@ func NewR1CS(cs compiled.R1CS, coefficients []big.Int) *R1CS {
 		R1CS:         cs,
 		Coefficients: make([]fr.Element, len(coefficients)),
 	}
-	for i := 0; i < len(coefficients); i++ {
-		r.Coefficients[i].SetBigInt(&coefficients[i])
-	}
+	utils.Parallelize(len(coefficients), func(start, end int) {
+		for i := start; i < end; i++ {
+			r.Coefficients[i].SetBigInt(&coefficients[i])
+		}
+	})
 
 	return &r
 }
@@ -66,14 +70,18 @@ func NewR1CS(cs compiled.R1CS, coefficients []big.Int) *R1CS {
 // a, b, c vectors: ab-c = hz
 // witness = [publicWires | secretWires] (without the ONE_WIRE !)
 // returns  [publicWires | secretWires | internalWires ]
+// the outputs (cs.Outputs) are computed by the solver and set in witness
 func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) ([]fr.Element, error) {
 	log := logger.Logger().With().Str("curve", cs.CurveID().String()).Int("nbConstraints", len(cs.Constraints)).Str("backend", "groth16").Logger()
 
 	nbWires := cs.NbPublicVariables + cs.NbSecretVariables + cs.NbInternalVariables
-	solution, err := newSolution(nbWires, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
+	solution, err := newSolution(nbWires, opt.HintFunctions, opt.RichHintFunctions, opt.HintContext, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
 	if err != nil {
 		return make([]fr.Element, nbWires), err
 	}
+	if opt.SolutionDump != nil {
+		solution.trackSolvedBy()
+	}
 	start := time.Now()
 
 	if len(witness) != int(cs.NbPublicVariables-1+cs.NbSecretVariables) { // - 1 for ONE_WIRE
@@ -95,16 +103,34 @@ func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) (
 	for i := 0; i < len(witness); i++ {
 		solution.solved[i+1] = true
 	}
+	for _, wID := range cs.Outputs {
+		solution.solved[wID] = false
+	}
 
 	// keep track of the number of wire instantiations we do, for a sanity check to ensure
 	// we instantiated all wires
-	solution.nbSolved += uint64(len(witness) + 1)
+	solution.nbSolved += uint64(len(witness) + 1 - len(cs.Outputs))
 
 	// now that we know all inputs are set, defer log printing once all solution.values are computed
 	// (or sooner, if a constraint is not satisfied)
 	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
 
-	if err := cs.parallelSolve(a, b, c, &solution); err != nil {
+	ctx := opt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	nbTasks := opt.NbTasks
+	if nbTasks <= 0 {
+		nbTasks = runtime.NumCPU()
+	}
+	err = cs.parallelSolve(ctx, nbTasks, a, b, c, &solution)
+	if opt.SolutionDump != nil {
+		// the solution is partial if the solver failed
+		if err := solution.dump(&cs.ConstraintSystem, opt.SolutionDump, opt.SolutionDumpFormat); err != nil {
+			log.Err(err).Msg("couldn't dump the solution")
+		}
+	}
+	if err != nil {
 		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
 			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
 		} else {
@@ -119,12 +145,16 @@ func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) (
 		panic("solver didn't instantiate all wires")
 	}
 
+	for _, wID := range cs.Outputs {
+		witness[wID-1] = solution.values[wID]
+	}
+
 	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
 
 	return solution.values, nil
 }
 
-func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
+func (cs *R1CS) parallelSolve(ctx context.Context, nbWorkers int, a, b, c []fr.Element, solution *solution) error {
 	// minWorkPerCPU is the minimum target number of constraint a task should hold
 	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
 	// sequentially without sync.
@@ -139,18 +169,18 @@ func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
 	// if a[i] * b[i] != c[i]; it means the constraint is not satisfied
 
 	var wg sync.WaitGroup
-	chTasks := make(chan []int, runtime.NumCPU())
-	chError := make(chan *UnsatisfiedConstraintError, runtime.NumCPU())
+	chTasks := make(chan []int, nbWorkers)
+	chError := make(chan *UnsatisfiedConstraintError, nbWorkers)
 
 	// start a worker pool
 	// each worker wait on chTasks
 	// a task is a slice of constraint indexes to be solved
-	for i := 0; i < runtime.NumCPU(); i++ {
+	for i := 0; i < nbWorkers; i++ {
 		go func() {
 			for t := range chTasks {
 				for _, i := range t {
 					// for each constraint in the task, solve it.
-					if err := cs.solveConstraint(cs.Constraints[i], solution, &a[i], &b[i], &c[i]); err != nil {
+					if err := cs.solveConstraint(i, cs.Constraints[i], solution, &a[i], &b[i], &c[i]); err != nil {
 						var debugInfo *string
 						if dID, ok := cs.MDebug[int(i)]; ok {
 							debugInfo = new(string)
@@ -174,6 +204,10 @@ func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
 
 	// for each level, we push the tasks
 	for _, level := range cs.Levels {
+		// the solver may be cancelled between levels (see backend.WithContext)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
@@ -181,7 +215,7 @@ func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
 		if maxCPU <= 1.0 {
 			// we do it sequentially
 			for _, i := range level {
-				if err := cs.solveConstraint(cs.Constraints[i], solution, &a[i], &b[i], &c[i]); err != nil {
+				if err := cs.solveConstraint(i, cs.Constraints[i], solution, &a[i], &b[i], &c[i]); err != nil {
 					var debugInfo *string
 					if dID, ok := cs.MDebug[int(i)]; ok {
 						debugInfo = new(string)
@@ -193,9 +227,9 @@ func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
 			continue
 		}
 
-		// number of tasks for this level is set to num cpus
+		// number of tasks for this level is set to the number of workers
 		// but if we don't have enough work for all our CPUS, it can be lower.
-		nbTasks := runtime.NumCPU()
+		nbTasks := nbWorkers
 		maxTasks := int(math.Ceil(maxCPU))
 		if nbTasks > maxTasks {
 			nbTasks = maxTasks
@@ -277,7 +311,7 @@ func (cs *R1CS) divByCoeff(res *fr.Element, t compiled.Term) {
 // returns false, nil if there was no wire to solve
 // returns true, nil if exactly one wire was solved. In that case, it is redundant to check that
 // the constraint is satisfied later.
-func (cs *R1CS) solveConstraint(r compiled.R1C, solution *solution, a, b, c *fr.Element) error {
+func (cs *R1CS) solveConstraint(cID int, r compiled.R1C, solution *solution, a, b, c *fr.Element) error {
 
 	// the index of the non zero entry shows if L, R or O has an uninstantiated wire
 	// the content is the ID of the wire non instantiated
@@ -297,7 +331,7 @@ func (cs *R1CS) solveConstraint(r compiled.R1C, solution *solution, a, b, c *fr.
 
 			// first we check if this is a hint wire
 			if hint, ok := cs.MHints[vID]; ok {
-				if err := solution.solveWithHint(vID, hint); err != nil {
+				if err := solution.solveWithHint(vID, hint, cID); err != nil {
 					return err
 				}
 				// now that the wire is saved, accumulate it into a, b or c
@@ -378,7 +412,7 @@ func (cs *R1CS) solveConstraint(r compiled.R1C, solution *solution, a, b, c *fr.
 	// but in the solution we want to store the value only
 	// note that in gnark frontend, coeff here is always 1 or -1
 	cs.divByCoeff(&wire, termToCompute)
-	solution.set(wID, wire)
+	solution.set(wID, wire, cID)
 
 	return nil
 }
@@ -17,6 +17,7 @@
 package cs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
@@ -34,6 +35,7 @@ import (
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/backend/ioutils"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
 
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
@@ -54,9 +56,11 @@ func NewSparseR1CS(ccs compiled.SparseR1CS, coefficients []big.Int) *SparseR1CS
 		SparseR1CS:   ccs,
 		Coefficients: make([]fr.Element, len(coefficients)),
 	}
-	for i := 0; i < len(coefficients); i++ {
-		cs.Coefficients[i].SetBigInt(&coefficients[i])
-	}
+	utils.Parallelize(len(coefficients), func(start, end int) {
+		for i := start; i < end; i++ {
+			cs.Coefficients[i].SetBigInt(&coefficients[i])
+		}
+	})
 
 	return &cs
 }
@@ -65,6 +69,7 @@ func NewSparseR1CS(ccs compiled.SparseR1CS, coefficients []big.Int) *SparseR1CS
 // solution.values =  [publicInputs | secretInputs | internalVariables ]
 // witness: contains the input variables
 // it returns the full slice of wires
+// the outputs (cs.Outputs) are computed by the solver and set in witness
 func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]fr.Element, error) {
 	log := logger.Logger().With().Str("curve", cs.CurveID().String()).Int("nbConstraints", len(cs.Constraints)).Str("backend", "plonk").Logger()
 
@@ -85,20 +90,26 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 	}
 
 	// keep track of wire that have a value
-	solution, err := newSolution(nbVariables, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
+	solution, err := newSolution(nbVariables, opt.HintFunctions, opt.RichHintFunctions, opt.HintContext, cs.MHintsDependencies, cs.MHints, cs.Coefficients)
 	if err != nil {
 		return solution.values, err
 	}
+	if opt.SolutionDump != nil {
+		solution.trackSolvedBy()
+	}
 
 	// solution.values = [publicInputs | secretInputs | internalVariables ] -> we fill publicInputs | secretInputs
 	copy(solution.values, witness)
 	for i := 0; i < len(witness); i++ {
 		solution.solved[i] = true
 	}
+	for _, wID := range cs.Outputs {
+		solution.solved[wID] = false
+	}
 
 	// keep track of the number of wire instantiations we do, for a sanity check to ensure
 	// we instantiated all wires
-	solution.nbSolved += uint64(len(witness))
+	solution.nbSolved += uint64(len(witness) - len(cs.Outputs))
 
 	// defer log printing once all solution.values are computed
 	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
@@ -109,7 +120,22 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 		coefficientsNegInv[i].Neg(&coefficientsNegInv[i])
 	}
 
-	if err := cs.parallelSolve(&solution, coefficientsNegInv); err != nil {
+	ctx := opt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	nbTasks := opt.NbTasks
+	if nbTasks <= 0 {
+		nbTasks = runtime.NumCPU()
+	}
+	err = cs.parallelSolve(ctx, nbTasks, &solution, coefficientsNegInv)
+	if opt.SolutionDump != nil {
+		// the solution is partial if the solver failed
+		if err := solution.dump(&cs.ConstraintSystem, opt.SolutionDump, opt.SolutionDumpFormat); err != nil {
+			log.Err(err).Msg("couldn't dump the solution")
+		}
+	}
+	if err != nil {
 		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
 			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
 		} else {
@@ -124,13 +150,17 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 		panic("solver didn't instantiate all wires")
 	}
 
+	for _, wID := range cs.Outputs {
+		witness[wID] = solution.values[wID]
+	}
+
 	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
 
 	return solution.values, nil
 
 }
 
-func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.Element) error {
+func (cs *SparseR1CS) parallelSolve(ctx context.Context, nbWorkers int, solution *solution, coefficientsNegInv []fr.Element) error {
 	// minWorkPerCPU is the minimum target number of constraint a task should hold
 	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
 	// sequentially without sync.
@@ -140,18 +170,18 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 	// and may only have dependencies on previous levels
 
 	var wg sync.WaitGroup
-	chTasks := make(chan []int, runtime.NumCPU())
-	chError := make(chan *UnsatisfiedConstraintError, runtime.NumCPU())
+	chTasks := make(chan []int, nbWorkers)
+	chError := make(chan *UnsatisfiedConstraintError, nbWorkers)
 
 	// start a worker pool
 	// each worker wait on chTasks
 	// a task is a slice of constraint indexes to be solved
-	for i := 0; i < runtime.NumCPU(); i++ {
+	for i := 0; i < nbWorkers; i++ {
 		go func() {
 			for t := range chTasks {
 				for _, i := range t {
 					// for each constraint in the task, solve it.
-					if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+					if err := cs.solveConstraint(i, cs.Constraints[i], solution, coefficientsNegInv); err != nil {
 						chError <- &UnsatisfiedConstraintError{CID: i, Err: err}
 						wg.Done()
 						return
@@ -180,6 +210,10 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 
 	// for each level, we push the tasks
 	for _, level := range cs.Levels {
+		// the solver may be cancelled between levels (see backend.WithContext)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
@@ -187,7 +221,7 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 		if maxCPU <= 1.0 {
 			// we do it sequentially
 			for _, i := range level {
-				if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+				if err := cs.solveConstraint(i, cs.Constraints[i], solution, coefficientsNegInv); err != nil {
 					return &UnsatisfiedConstraintError{CID: i, Err: err}
 				}
 				if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
@@ -201,9 +235,9 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 			continue
 		}
 
-		// number of tasks for this level is set to num cpus
+		// number of tasks for this level is set to the number of workers
 		// but if we don't have enough work for all our CPUS, it can be lower.
-		nbTasks := runtime.NumCPU()
+		nbTasks := nbWorkers
 		maxTasks := int(math.Ceil(maxCPU))
 		if nbTasks > maxTasks {
 			nbTasks = maxTasks
@@ -248,14 +282,14 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 // computeHints computes wires associated with a hint function, if any
 // if there is no remaining wire to solve, returns -1
 // else returns the wire position (L -> 0, R -> 1, O -> 2)
-func (cs *SparseR1CS) computeHints(c compiled.SparseR1C, solution *solution) (int, error) {
+func (cs *SparseR1CS) computeHints(cID int, c compiled.SparseR1C, solution *solution) (int, error) {
 	r := -1
 	lID, rID, oID := c.L.WireID(), c.R.WireID(), c.O.WireID()
 
 	if (c.L.CoeffID() != 0 || c.M[0].CoeffID() != 0) && !solution.solved[lID] {
 		// check if it's a hint
 		if hint, ok := cs.MHints[lID]; ok {
-			if err := solution.solveWithHint(lID, hint); err != nil {
+			if err := solution.solveWithHint(lID, hint, cID); err != nil {
 				return -1, err
 			}
 		} else {
@@ -267,7 +301,7 @@ func (cs *SparseR1CS) computeHints(c compiled.SparseR1C, solution *solution) (in
 	if (c.R.CoeffID() != 0 || c.M[1].CoeffID() != 0) && !solution.solved[rID] {
 		// check if it's a hint
 		if hint, ok := cs.MHints[rID]; ok {
-			if err := solution.solveWithHint(rID, hint); err != nil {
+			if err := solution.solveWithHint(rID, hint, cID); err != nil {
 				return -1, err
 			}
 		} else {
@@ -278,7 +312,7 @@ func (cs *SparseR1CS) computeHints(c compiled.SparseR1C, solution *solution) (in
 	if (c.O.CoeffID() != 0) && !solution.solved[oID] {
 		// check if it's a hint
 		if hint, ok := cs.MHints[oID]; ok {
-			if err := solution.solveWithHint(oID, hint); err != nil {
+			if err := solution.solveWithHint(oID, hint, cID); err != nil {
 				return -1, err
 			}
 		} else {
@@ -291,9 +325,9 @@ func (cs *SparseR1CS) computeHints(c compiled.SparseR1C, solution *solution) (in
 // solveConstraint solve any unsolved wire in given constraint and update the solution
 // a SparseR1C may have up to one unsolved wire (excluding hints)
 // if it doesn't, then this function returns and does nothing
-func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution, coefficientsNegInv []fr.Element) error {
+func (cs *SparseR1CS) solveConstraint(cID int, c compiled.SparseR1C, solution *solution, coefficientsNegInv []fr.Element) error {
 
-	lro, err := cs.computeHints(c, solution)
+	lro, err := cs.computeHints(cID, c, solution)
 	if err != nil {
 		return err
 	}
@@ -318,7 +352,7 @@ func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution,
 
 		// TODO find a way to do lazy div (/ batch inversion)
 		num.Div(&num, &den).Neg(&num)
-		solution.set(c.L.WireID(), num)
+		solution.set(c.L.WireID(), num, cID)
 		return nil
 	}
 
@@ -338,7 +372,7 @@ func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution,
 
 		// TODO find a way to do lazy div (/ batch inversion)
 		num.Div(&num, &den).Neg(&num)
-		solution.set(c.L.WireID(), num)
+		solution.set(c.L.WireID(), num, cID)
 		return nil
 
 	}
@@ -355,7 +389,7 @@ func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution,
 	o.Mul(&m0, &m1).Add(&o, &l).Add(&o, &r).Add(&o, &cs.Coefficients[c.K])
 	o.Mul(&o, &coefficientsNegInv[cID])
 
-	solution.set(vID, o)
+	solution.set(vID, o, cID)
 
 	return nil
 }
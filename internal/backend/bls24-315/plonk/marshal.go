@@ -19,8 +19,12 @@ package plonk
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 
-	"errors"
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/internal/utils"
 	"io"
 )
 
@@ -232,3 +236,95 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 
 	return dec.BytesRead(), nil
 }
+
+// WriteMappedTo writes the ProvingKey to w in its memory layout, with the
+// precomputed tables of its domains and the permutation polynomials on the
+// big domain, so that ReadMappedFrom uses it in place. The layout depends on
+// the byte order of the machine.
+func (pk *ProvingKey) WriteMappedTo(w io.Writer) (int64, error) {
+	mw := utils.NewMappedWriter(w)
+	mw.WriteUint64(uint64(curve.ID))
+
+	// the verifying key, without its SRS
+	mw.WriteUint64(pk.Vk.Size)
+	mw.Write(&pk.Vk.SizeInv)
+	mw.Write(&pk.Vk.Generator)
+	mw.WriteUint64(pk.Vk.NbPublicVariables)
+	mw.Write(&pk.Vk.CosetShift)
+	mw.Write(&pk.Vk.S)
+	mw.Write(&pk.Vk.Ql)
+	mw.Write(&pk.Vk.Qr)
+	mw.Write(&pk.Vk.Qm)
+	mw.Write(&pk.Vk.Qo)
+	mw.Write(&pk.Vk.Qk)
+
+	mw.WriteFields(&pk.Domain[0])
+	mw.WriteFields(&pk.Domain[1])
+
+	mw.WriteSlice(pk.Ql)
+	mw.WriteSlice(pk.Qr)
+	mw.WriteSlice(pk.Qm)
+	mw.WriteSlice(pk.Qo)
+	mw.WriteSlice(pk.CQk)
+	mw.WriteSlice(pk.LQk)
+	mw.WriteSlice(pk.EvaluationPermutationBigDomainBitReversed)
+	mw.WriteSlice(pk.S1Canonical)
+	mw.WriteSlice(pk.S2Canonical)
+	mw.WriteSlice(pk.S3Canonical)
+	mw.WriteSlice(pk.Permutation)
+
+	return mw.Result()
+}
+
+// ReadMappedFrom sets the ProvingKey from data written by WriteMappedTo, such
+// as a memory-mapped file. The slices of the ProvingKey point into data instead
+// of being decoded: data must not be modified or released while the
+// ProvingKey is in use. As with ReadFrom, InitKZG must be called before
+// proving.
+func (pk *ProvingKey) ReadMappedFrom(data []byte) (int64, error) {
+	mr := utils.NewMappedReader(data)
+	curveID := ecc.ID(mr.ReadUint64())
+	if n, err := mr.Result(); err != nil {
+		return n, err
+	}
+	if curveID != curve.ID {
+		return 0, fmt.Errorf("mapped ProvingKey of curve %s, expected %s", curveID, curve.ID)
+	}
+
+	pk.Vk = &VerifyingKey{}
+	pk.Vk.Size = mr.ReadUint64()
+	mr.Read(&pk.Vk.SizeInv)
+	mr.Read(&pk.Vk.Generator)
+	pk.Vk.NbPublicVariables = mr.ReadUint64()
+	mr.Read(&pk.Vk.CosetShift)
+	mr.Read(&pk.Vk.S)
+	mr.Read(&pk.Vk.Ql)
+	mr.Read(&pk.Vk.Qr)
+	mr.Read(&pk.Vk.Qm)
+	mr.Read(&pk.Vk.Qo)
+	mr.Read(&pk.Vk.Qk)
+
+	mr.ReadFields(&pk.Domain[0])
+	mr.ReadFields(&pk.Domain[1])
+
+	mr.ReadSlice(&pk.Ql)
+	mr.ReadSlice(&pk.Qr)
+	mr.ReadSlice(&pk.Qm)
+	mr.ReadSlice(&pk.Qo)
+	mr.ReadSlice(&pk.CQk)
+	mr.ReadSlice(&pk.LQk)
+	mr.ReadSlice(&pk.EvaluationPermutationBigDomainBitReversed)
+	mr.ReadSlice(&pk.S1Canonical)
+	mr.ReadSlice(&pk.S2Canonical)
+	mr.ReadSlice(&pk.S3Canonical)
+	mr.ReadSlice(&pk.Permutation)
+
+	n, err := mr.Result()
+	if err != nil {
+		return n, err
+	}
+	if len(pk.Permutation) != 3*int(pk.Domain[0].Cardinality) {
+		return n, errors.New("invalid permutation size, expected 3*domain cardinality")
+	}
+	return n, nil
+}
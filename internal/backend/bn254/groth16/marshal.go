@@ -18,6 +18,10 @@ package groth16
 
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/internal/utils"
 	"io"
 )
 
@@ -171,15 +175,25 @@ func (vk *VerifyingKey) readFrom(r io.Reader, decOptions ...func(*curve.Decoder)
 	}
 
 	// recompute vk.e (e(α, β)) and  -[δ]2, -[γ]2
+	if err := vk.Precompute(); err != nil {
+		return dec.BytesRead(), err
+	}
+
+	return dec.BytesRead(), nil
+}
+
+// Precompute computes the elements of the VerifyingKey which are not serialized,
+// e(α, β), -[δ]2 and -[γ]2, from its other elements. It must be called on a
+// VerifyingKey built from its exported fields rather than by Setup or ReadFrom.
+func (vk *VerifyingKey) Precompute() error {
 	var err error
 	vk.e, err = curve.Pair([]curve.G1Affine{vk.G1.Alpha}, []curve.G2Affine{vk.G2.Beta})
 	if err != nil {
-		return dec.BytesRead(), err
+		return err
 	}
 	vk.G2.deltaNeg.Neg(&vk.G2.Delta)
 	vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
-
-	return dec.BytesRead(), nil
+	return nil
 }
 
 // WriteTo writes binary encoding of the key elements to writer
@@ -294,3 +308,65 @@ func (pk *ProvingKey) readFrom(r io.Reader, decOptions ...func(*curve.Decoder))
 
 	return n + dec.BytesRead(), nil
 }
+
+// WriteMappedTo writes the ProvingKey to w in its memory layout, with the
+// precomputed tables of its domain, so that ReadMappedFrom uses it in place.
+// The layout depends on the byte order of the machine.
+func (pk *ProvingKey) WriteMappedTo(w io.Writer) (int64, error) {
+	mw := utils.NewMappedWriter(w)
+	mw.WriteUint64(uint64(curve.ID))
+	mw.WriteFields(&pk.Domain)
+
+	mw.Write(&pk.G1.Alpha)
+	mw.Write(&pk.G1.Beta)
+	mw.Write(&pk.G1.Delta)
+	mw.WriteSlice(pk.G1.A)
+	mw.WriteSlice(pk.G1.B)
+	mw.WriteSlice(pk.G1.Z)
+	mw.WriteSlice(pk.G1.K)
+	mw.Write(&pk.G2.Beta)
+	mw.Write(&pk.G2.Delta)
+	mw.WriteSlice(pk.G2.B)
+
+	mw.WriteSlice(pk.InfinityA)
+	mw.WriteSlice(pk.InfinityB)
+	mw.WriteUint64(pk.NbInfinityA)
+	mw.WriteUint64(pk.NbInfinityB)
+
+	return mw.Result()
+}
+
+// ReadMappedFrom sets the ProvingKey from data written by WriteMappedTo, such
+// as a memory-mapped file. The slices of the ProvingKey point into data instead
+// of being decoded: data must not be modified or released while the
+// ProvingKey is in use. Like UnsafeReadFrom, it doesn't check that the points
+// are on the curve or in the correct subgroup.
+func (pk *ProvingKey) ReadMappedFrom(data []byte) (int64, error) {
+	mr := utils.NewMappedReader(data)
+	curveID := ecc.ID(mr.ReadUint64())
+	if n, err := mr.Result(); err != nil {
+		return n, err
+	}
+	if curveID != curve.ID {
+		return 0, fmt.Errorf("mapped ProvingKey of curve %s, expected %s", curveID, curve.ID)
+	}
+	mr.ReadFields(&pk.Domain)
+
+	mr.Read(&pk.G1.Alpha)
+	mr.Read(&pk.G1.Beta)
+	mr.Read(&pk.G1.Delta)
+	mr.ReadSlice(&pk.G1.A)
+	mr.ReadSlice(&pk.G1.B)
+	mr.ReadSlice(&pk.G1.Z)
+	mr.ReadSlice(&pk.G1.K)
+	mr.Read(&pk.G2.Beta)
+	mr.Read(&pk.G2.Delta)
+	mr.ReadSlice(&pk.G2.B)
+
+	mr.ReadSlice(&pk.InfinityA)
+	mr.ReadSlice(&pk.InfinityB)
+	pk.NbInfinityA = mr.ReadUint64()
+	pk.NbInfinityB = mr.ReadUint64()
+
+	return mr.Result()
+}
@@ -86,6 +86,37 @@ func TestProvingKeySerialization(t *testing.T) {
 	if written != read {
 		t.Fatal("bytes written / read don't match")
 	}
+
+	// mapped layout, with the permutation polynomials on the big domain
+	pk.S1Canonical = make([]fr.Element, pk.Domain[0].Cardinality)
+	pk.S1Canonical[3].SetUint64(7)
+	pk.EvaluationPermutationBigDomainBitReversed = make([]fr.Element, 3*pk.Domain[1].Cardinality)
+	pk.EvaluationPermutationBigDomainBitReversed[1].SetOne()
+
+	buf.Reset()
+	written, err = pk.WriteMappedTo(&buf)
+	if err != nil {
+		t.Fatal("couldn't write mapped", err)
+	}
+
+	var mapped ProvingKey
+	read, err = mapped.ReadMappedFrom(buf.Bytes())
+	if err != nil {
+		t.Fatal("couldn't read mapped", err)
+	}
+
+	if !reflect.DeepEqual(&pk, &mapped) {
+		t.Fatal("mapped object don't match original")
+	}
+
+	if written != read {
+		t.Fatal("bytes written / read mapped don't match")
+	}
+
+	// truncated layout
+	if _, err := mapped.ReadMappedFrom(buf.Bytes()[:buf.Len()/2]); err == nil {
+		t.Fatal("truncated mapped layout should fail")
+	}
 }
 
 func TestVerifyingKeySerialization(t *testing.T) {
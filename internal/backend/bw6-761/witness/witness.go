@@ -101,7 +101,13 @@ func (witness *Witness) FromAssignment(assignment interface{}, leafType reflect.
 	i = nbPublic // offset
 
 	var collectHandler schema.LeafHandler = func(visibility schema.Visibility, name string, tInput reflect.Value) error {
-		if publicOnly && visibility != schema.Public {
+		if publicOnly && !visibility.IsPublic() {
+			return nil
+		}
+		if !publicOnly && visibility == schema.Output && tInput.IsNil() {
+			// computed by the solver
+			(*witness)[j].SetZero()
+			j++
 			return nil
 		}
 		if tInput.IsNil() {
@@ -118,7 +124,7 @@ func (witness *Witness) FromAssignment(assignment interface{}, leafType reflect.
 				return fmt.Errorf("when parsing variable %s: %v", name, err)
 			}
 			i++
-		} else if visibility == schema.Public {
+		} else if visibility.IsPublic() {
 			if _, err := (*witness)[j].SetInterface(v); err != nil {
 				return fmt.Errorf("when parsing variable %s: %v", name, err)
 			}
@@ -136,7 +142,7 @@ func (witness *Witness) ToAssignment(assignment interface{}, leafType reflect.Ty
 	setAddr := leafType.Kind() == reflect.Ptr
 	setHandler := func(v schema.Visibility) schema.LeafHandler {
 		return func(visibility schema.Visibility, name string, tInput reflect.Value) error {
-			if visibility == v {
+			if visibility == v || (v == schema.Public && visibility == schema.Output) {
 				if setAddr {
 					tInput.Set(reflect.ValueOf((&(*witness)[i])))
 				} else {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/math/bits"
 )
@@ -78,7 +79,61 @@ func (circuit *recursiveHint) Define(api frontend.API) error {
 	return nil
 }
 
+type richHintCircuit struct {
+	A, B frontend.Variable
+	N    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *richHintCircuit) Define(api frontend.API) error {
+	params := []hint.Param{hint.Int(7), hint.BigInt(big.NewInt(-1)), hint.String("mul")}
+	res, err := api.Compiler().NewRichHint(mulByParam, 1, params, circuit.A)
+	if err != nil {
+		return fmt.Errorf("mulByParam: %w", err)
+	}
+	api.AssertIsEqual(res[0], api.Mul(circuit.A, 7))
+	api.AssertIsEqual(res[0], circuit.B)
+
+	// decimal digits of A, with N the number of digits
+	digits, nbDigits, err := api.Compiler().NewVariableHint(decimalDigits, 4, []hint.Param{hint.Int(10)}, circuit.A)
+	if err != nil {
+		return fmt.Errorf("decimalDigits: %w", err)
+	}
+	var a frontend.Variable = 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		a = api.Add(api.Mul(a, 10), digits[i])
+	}
+	api.AssertIsEqual(a, circuit.A)
+	api.AssertIsEqual(nbDigits, circuit.N)
+
+	return nil
+}
+
 func init() {
+	{
+		good := []frontend.Circuit{
+			&richHintCircuit{
+				A: 42,
+				B: 42 * 7,
+				N: 2,
+			},
+		}
+
+		bad := []frontend.Circuit{
+			&richHintCircuit{
+				A: 42,
+				B: 42,
+				N: 2,
+			},
+			&richHintCircuit{
+				A: 42,
+				B: 42 * 7,
+				N: 4,
+			},
+		}
+
+		addNewEntry("rich_hint", &richHintCircuit{}, good, bad, gnark.Curves())
+	}
+
 	{
 		good := []frontend.Circuit{
 			&recursiveHint{
@@ -139,6 +194,49 @@ func init() {
 	}
 }
 
+func init() {
+	hint.RegisterRich(mulByParam)
+	hint.RegisterRich(decimalDigits)
+}
+
+// mulByParam multiplies its input by the integer parameter
+func mulByParam(ctx *hint.Context, inputs []*big.Int, results []*big.Int) (int, error) {
+	if len(ctx.Params) != 3 {
+		return 0, fmt.Errorf("expected 3 parameters, got %d", len(ctx.Params))
+	}
+	k, err := ctx.Params[0].AsInt()
+	if err != nil {
+		return 0, err
+	}
+	if v, err := ctx.Params[1].AsBigInt(); err != nil || v.Cmp(big.NewInt(-1)) != 0 {
+		return 0, fmt.Errorf("unexpected big int parameter %v", v)
+	}
+	if op, err := ctx.Params[2].AsString(); err != nil || op != "mul" {
+		return 0, fmt.Errorf("unexpected string parameter %q", op)
+	}
+	results[0].Mul(inputs[0], big.NewInt(k)).Mod(results[0], ctx.Modulus)
+	return 1, nil
+}
+
+// decimalDigits sets the digits of its input in the base given as parameter,
+// least significant first, and returns their number
+func decimalDigits(ctx *hint.Context, inputs []*big.Int, results []*big.Int) (int, error) {
+	b, err := ctx.Params[0].AsInt()
+	if err != nil {
+		return 0, err
+	}
+	base := big.NewInt(b)
+	v := new(big.Int).Set(inputs[0])
+	n := 0
+	for ; v.Sign() != 0; n++ {
+		if n == len(results) {
+			return 0, fmt.Errorf("%s has more than %d digits", inputs[0], len(results))
+		}
+		v.DivMod(v, base, results[n])
+	}
+	return n, nil
+}
+
 var mulBy7 = func(curveID ecc.ID, inputs []*big.Int, result []*big.Int) error {
 	result[0].Mul(inputs[0], big.NewInt(7)).Mod(result[0], curveID.Info().Fr.Modulus())
 	return nil
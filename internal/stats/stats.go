@@ -71,7 +71,7 @@ func (s *globalStats) Load(path string) error {
 	return err
 }
 
-func NewSnippetStats(curve ecc.ID, backendID backend.ID, circuit frontend.Circuit) (snippetStats, error) {
+func NewSnippetStats(curve ecc.ID, backendID backend.ID, circuit frontend.Circuit, opts ...frontend.CompileOption) (snippetStats, error) {
 	var newCompiler frontend.NewBuilder
 
 	switch backendID {
@@ -83,7 +83,8 @@ func NewSnippetStats(curve ecc.ID, backendID backend.ID, circuit frontend.Circui
 		panic("not implemented")
 	}
 
-	ccs, err := frontend.Compile(curve, newCompiler, circuit, frontend.IgnoreUnconstrainedInputs())
+	opts = append([]frontend.CompileOption{frontend.IgnoreUnconstrainedInputs()}, opts...)
+	ccs, err := frontend.Compile(curve, newCompiler, circuit, opts...)
 	if err != nil {
 		return snippetStats{}, err
 	}
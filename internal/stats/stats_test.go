@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
 )
 
@@ -48,3 +49,37 @@ func TestCircuitStatistics(t *testing.T) {
 	}
 
 }
+
+func TestCommonSubexpressionElimination(t *testing.T) {
+	const refPath = "latest.stats"
+	assert := test.NewAssert(t)
+
+	// load reference
+	reference := NewGlobalStats()
+	assert.NoError(reference.Load(refPath))
+
+	// without the common subexpression elimination, no circuit should have less
+	// constraints than the reference, and some should have more
+	improved := false
+	snippets := GetSnippets()
+	for name, c := range snippets {
+		ref, ok := reference.Stats[name]
+		if !ok {
+			continue
+		}
+		for _, curve := range c.Curves {
+			for _, backendID := range backend.Implemented() {
+				rs := ref[backendID][CurveIdx(curve)]
+
+				s, err := NewSnippetStats(curve, backendID, c.Circuit, frontend.WithoutCommonSubexpressionElimination())
+				assert.NoError(err, "building stats for circuit "+name)
+
+				assert.LessOrEqual(rs.NbConstraints, s.NbConstraints, "%s - %s - %s", name, backendID.String(), curve.String())
+				if rs.NbConstraints < s.NbConstraints {
+					improved = true
+				}
+			}
+		}
+	}
+	assert.True(improved, "common subexpression elimination didn't reduce any constraint count")
+}
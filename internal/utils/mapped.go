@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// mappedMagic starts a mapped layout. It is written in the byte order of the
+// machine, so that a machine with another byte order doesn't read it.
+const mappedMagic uint64 = 0x70616d6b72616e67 // "gnarkmap" in little endian
+
+// mappedAlign is the alignment of the values of a mapped layout
+const mappedAlign = 8
+
+// ErrInvalidMapped is returned when reading data which isn't a mapped layout,
+// or which was written by a machine with another byte order.
+var ErrInvalidMapped = errors.New("invalid mapped layout")
+
+// MappedWriter writes values in their memory layout, each aligned on 8 bytes,
+// so that a MappedReader uses them in place. The values must not hold
+// pointers, and the slices are written after their length. Errors are sticky:
+// after the first one, writes are no-ops and Result returns it.
+type MappedWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// NewMappedWriter returns a MappedWriter to w, and writes the header of the
+// mapped layout.
+func NewMappedWriter(w io.Writer) *MappedWriter {
+	mw := &MappedWriter{w: w}
+	mw.WriteUint64(mappedMagic)
+	return mw
+}
+
+// WriteUint64 writes v
+func (mw *MappedWriter) WriteUint64(v uint64) {
+	mw.Write(&v)
+}
+
+// Write writes the value v points to
+func (mw *MappedWriter) Write(v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	mw.write(unsafe.Pointer(rv.UnsafeAddr()), int(rv.Type().Size()))
+}
+
+// WriteSlice writes the length of the slice v, then its elements
+func (mw *MappedWriter) WriteSlice(v interface{}) {
+	mw.writeSlice(reflect.ValueOf(v))
+}
+
+// WriteFields writes the fields of the struct v points to, in their order: the
+// slices as with WriteSlice, the slices of slices as their length then their
+// slices, and the other fields as with Write. The structs shared by the
+// curves, such as fft.Domain, are written this way.
+func (mw *MappedWriter) WriteFields(v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Field(i)
+		switch {
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Slice:
+			mw.WriteUint64(uint64(f.Len()))
+			for j := 0; j < f.Len(); j++ {
+				mw.writeSlice(f.Index(j))
+			}
+		case f.Kind() == reflect.Slice:
+			mw.writeSlice(f)
+		default:
+			mw.write(unsafe.Pointer(f.UnsafeAddr()), int(f.Type().Size()))
+		}
+	}
+}
+
+// Result returns the number of bytes written and the first error
+func (mw *MappedWriter) Result() (int64, error) {
+	return mw.n, mw.err
+}
+
+func (mw *MappedWriter) writeSlice(rv reflect.Value) {
+	mw.WriteUint64(uint64(rv.Len()))
+	if rv.Len() != 0 {
+		mw.write(unsafe.Pointer(rv.Pointer()), rv.Len()*int(rv.Type().Elem().Size()))
+	}
+}
+
+func (mw *MappedWriter) write(p unsafe.Pointer, size int) {
+	if mw.err != nil {
+		return
+	}
+	var padding [mappedAlign]byte
+	b := unsafe.Slice((*byte)(p), size)
+	n, err := mw.w.Write(b)
+	mw.n += int64(n)
+	if err != nil {
+		mw.err = err
+		return
+	}
+	if r := len(b) % mappedAlign; r != 0 {
+		n, err = mw.w.Write(padding[:mappedAlign-r])
+		mw.n += int64(n)
+		mw.err = err
+	}
+}
+
+// MappedReader reads the values written by a MappedWriter in place: the
+// slices it returns point into its data. Errors are sticky: after the first
+// one, reads return zero values and Result returns it.
+type MappedReader struct {
+	data []byte
+	n    int
+	err  error
+}
+
+// NewMappedReader returns a MappedReader of data, and checks the header of the
+// mapped layout. data must be aligned on 8 bytes, as a memory-mapped file is.
+func NewMappedReader(data []byte) *MappedReader {
+	mr := &MappedReader{data: data}
+	if len(data) != 0 && uintptr(unsafe.Pointer(&data[0]))%mappedAlign != 0 {
+		mr.err = errors.New("mapped layout isn't aligned on 8 bytes")
+		return mr
+	}
+	if mr.ReadUint64() != mappedMagic && mr.err == nil {
+		mr.err = ErrInvalidMapped
+	}
+	return mr
+}
+
+// ReadUint64 reads a uint64
+func (mr *MappedReader) ReadUint64() uint64 {
+	var v uint64
+	mr.Read(&v)
+	return v
+}
+
+// ReadLen reads the length of a sequence of values, written with WriteUint64.
+// As a value takes at least 8 bytes, the length is bounded by the remaining
+// data.
+func (mr *MappedReader) ReadLen() int {
+	n := mr.ReadUint64()
+	if n > uint64(len(mr.data)-mr.n)/mappedAlign {
+		if mr.err == nil {
+			mr.err = io.ErrUnexpectedEOF
+		}
+		return 0
+	}
+	return int(n)
+}
+
+// Read copies the next value to the value v points to
+func (mr *MappedReader) Read(v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	size := int(rv.Type().Size())
+	if b := mr.next(size); b != nil {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(rv.UnsafeAddr())), size), b)
+	}
+}
+
+// ReadSlice sets the slice v points to to the next slice, in place
+func (mr *MappedReader) ReadSlice(v interface{}) {
+	mr.readSlice(reflect.ValueOf(v).Elem())
+}
+
+// ReadFields reads the fields of the struct v points to, written by
+// WriteFields. The slices point into the data, the slices of slices are
+// allocated.
+func (mr *MappedReader) ReadFields(v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Field(i)
+		switch {
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Slice:
+			n := mr.ReadLen()
+			s := reflect.MakeSlice(f.Type(), n, n)
+			for j := 0; j < n; j++ {
+				mr.readSlice(s.Index(j))
+			}
+			f.Set(s)
+		case f.Kind() == reflect.Slice:
+			mr.readSlice(f)
+		default:
+			size := int(f.Type().Size())
+			if b := mr.next(size); b != nil {
+				copy(unsafe.Slice((*byte)(unsafe.Pointer(f.UnsafeAddr())), size), b)
+			}
+		}
+	}
+}
+
+// readSlice sets the slice rv to the next slice, in place
+func (mr *MappedReader) readSlice(rv reflect.Value) {
+	rv.Set(reflect.Zero(rv.Type()))
+	tElem := rv.Type().Elem()
+	size := int(tElem.Size())
+
+	n := mr.ReadUint64()
+	if mr.err != nil || n == 0 {
+		return
+	}
+	if size != 0 && n > uint64(len(mr.data)/size) {
+		mr.err = io.ErrUnexpectedEOF
+		return
+	}
+	b := mr.next(int(n) * size)
+	if b == nil {
+		return
+	}
+	a := reflect.NewAt(reflect.ArrayOf(int(n), tElem), unsafe.Pointer(&b[0])).Elem()
+	rv.Set(a.Slice3(0, int(n), int(n)))
+}
+
+// Result returns the number of bytes read and the first error
+func (mr *MappedReader) Result() (int64, error) {
+	return int64(mr.n), mr.err
+}
+
+// next returns the next size bytes and skips their padding
+func (mr *MappedReader) next(size int) []byte {
+	if mr.err != nil || size == 0 {
+		return nil
+	}
+	padded := size
+	if r := size % mappedAlign; r != 0 {
+		padded += mappedAlign - r
+	}
+	if padded > len(mr.data)-mr.n {
+		mr.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := mr.data[mr.n : mr.n+size : mr.n+size]
+	mr.n += padded
+	return b
+}
@@ -5,11 +5,13 @@ import (
 	"sync"
 )
 
-// Parallelize process in parallel the work function
+// Parallelize process in parallel the work function. It splits the iterations
+// in maxCpus tasks, or runtime.NumCPU() if maxCpus isn't given or is not
+// positive.
 func Parallelize(nbIterations int, work func(int, int), maxCpus ...int) {
 
 	nbTasks := runtime.NumCPU()
-	if len(maxCpus) == 1 {
+	if len(maxCpus) == 1 && maxCpus[0] > 0 {
 		nbTasks = maxCpus[0]
 	}
 	nbIterationsPerCpus := nbIterations / nbTasks
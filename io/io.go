@@ -39,3 +39,21 @@ type WriterRawTo interface {
 type UnsafeReaderFrom interface {
 	UnsafeReadFrom(r io.Reader) (int64, error)
 }
+
+// WriterMappedTo is the interface that wraps the WriteMappedTo method.
+//
+// WriteMappedTo writes data to w in its memory layout, so that ReadMappedFrom
+// can use it in place, for instance from a memory-mapped file. The layout
+// depends on the byte order of the machine.
+type WriterMappedTo interface {
+	WriteMappedTo(w io.Writer) (n int64, err error)
+}
+
+// ReaderMappedFrom is the interface that wraps the ReadMappedFrom method.
+//
+// ReadMappedFrom reads data written by WriteMappedTo without copying it: the
+// object points into data, which must not be modified or released while the
+// object is in use. Like UnsafeReadFrom, it doesn't perform any checks.
+type ReaderMappedFrom interface {
+	ReadMappedFrom(data []byte) (n int64, err error)
+}
@@ -0,0 +1,70 @@
+package gnark_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMappedProvingKey(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curveID := range []ecc.ID{ecc.BN254, ecc.BLS12_377} {
+		w, err := frontend.NewWitness(&proverCircuit{X: 3, Y: 35}, curveID)
+		assert.NoError(err)
+		path := filepath.Join(t.TempDir(), "pk")
+
+		for _, ps := range newProvingSystems(assert, curveID, &proverCircuit{}, nil) {
+			assert.NoError(writeMappedFile(path, ps.pk.(gnarkio.WriterMappedTo).WriteMappedTo))
+
+			mapped := *ps
+			var unmap func() error
+			if ps.ID == backend.GROTH16 {
+				var pk groth16.ProvingKey
+				pk, unmap, err = groth16.ReadMappedProvingKey(curveID, path)
+				assert.NoError(err)
+				assert.False(ps.pk.(groth16.ProvingKey).IsDifferent(pk), "mapped key differs")
+				mapped.pk = pk
+
+				// the curve is checked
+				_, _, err = groth16.ReadMappedProvingKey(ecc.BW6_761, path)
+				assert.Error(err)
+			} else {
+				var pk plonk.ProvingKey
+				pk, unmap, err = plonk.ReadMappedProvingKey(curveID, path)
+				assert.NoError(err)
+				assert.NoError(pk.InitKZG(ps.srs))
+				mapped.pk = pk
+			}
+			mapped.proveAndVerify(assert, w)
+			assert.NoError(unmap())
+		}
+	}
+
+	// not a mapped layout
+	path := filepath.Join(t.TempDir(), "invalid")
+	assert.NoError(os.WriteFile(path, []byte("not a proving key"), 0600))
+	_, _, err := groth16.ReadMappedProvingKey(ecc.BN254, path)
+	assert.Error(err)
+}
+
+func writeMappedFile(path string, writeMappedTo func(io.Writer) (int64, error)) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := writeMappedTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,47 @@
+package gnark_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type nbTasksCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *nbTasksCircuit) Define(api frontend.API) error {
+	x := circuit.X
+	for i := 0; i < 64; i++ {
+		x = api.Add(api.Mul(x, x), i)
+	}
+	api.AssertIsDifferent(x, circuit.Y)
+	return nil
+}
+
+func TestNbTasks(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&nbTasksCircuit{X: 3, Y: 0}, ecc.BN254)
+	assert.NoError(err)
+
+	for _, nbTasks := range []int{1, 3} {
+		compileOpts := []frontend.CompileOption{frontend.WithNbTasks(nbTasks)}
+		for _, ps := range newProvingSystems(assert, ecc.BN254, &nbTasksCircuit{}, compileOpts, backend.WithSetupNbTasks(nbTasks)) {
+			ps.proveAndVerify(assert, w, backend.WithNbTasks(nbTasks))
+		}
+	}
+
+	// invalid number of tasks
+	_, err = frontend.Compile(ecc.BN254, r1cs.NewBuilder, &nbTasksCircuit{}, frontend.WithNbTasks(0))
+	assert.Error(err)
+	_, err = backend.NewProverConfig(backend.WithNbTasks(0))
+	assert.Error(err)
+	_, err = backend.NewSetupConfig(backend.WithSetupNbTasks(-1))
+	assert.Error(err)
+}
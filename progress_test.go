@@ -0,0 +1,143 @@
+package gnark_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+type progressCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *progressCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestProverProgress(t *testing.T) {
+	assert := require.New(t)
+
+	w, err := frontend.NewWitness(&progressCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	invalid, err := frontend.NewWitness(&progressCircuit{X: 3, Y: 28}, ecc.BN254)
+	assert.NoError(err)
+
+	// proveCancelled returns the events of a proof cancelled when a step of
+	// the phase starts; the steps in progress end before the prover returns
+	proveCancelled := func(ps *provingSystem, phase backend.ProverPhase) []backend.ProverEvent {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var events []backend.ProverEvent
+		var returned int32
+		_, err := ps.prove(w, backend.WithContext(ctx), backend.WithProgress(func(ev backend.ProverEvent) {
+			assert.Zero(atomic.LoadInt32(&returned), "event after the prover returned")
+			if ev.Phase == phase {
+				cancel()
+			}
+			events = append(events, ev)
+		}))
+		atomic.StoreInt32(&returned, 1)
+		assert.ErrorIs(err, context.Canceled)
+		return events
+	}
+
+	// checkEvents checks that each step which starts ends, inside the proof, and
+	// returns the steps. The end of a failed proof holds its error.
+	checkEvents := func(events []backend.ProverEvent, failed bool) map[backend.ProverPhase][]string {
+		assert.NotEmpty(events)
+		assert.Equal(backend.PhaseProve, events[0].Phase)
+		assert.False(events[0].End)
+		last := events[len(events)-1]
+		assert.Equal(backend.PhaseProve, last.Phase)
+		assert.True(last.End)
+		if failed {
+			assert.Error(last.Err)
+		} else {
+			assert.NoError(last.Err)
+		}
+
+		steps := make(map[backend.ProverPhase][]string)
+		started := make(map[string]bool)
+		for _, ev := range events {
+			key := ev.Phase.String() + "/" + ev.Name
+			assert.Positive(ev.NbItems, key)
+			if !ev.End {
+				assert.False(started[key], "%s started twice", key)
+				started[key] = true
+				assert.Zero(ev.Elapsed)
+				continue
+			}
+			assert.True(started[key], "%s ended before it started", key)
+			if !failed {
+				assert.NoError(ev.Err, key)
+			}
+			delete(started, key)
+			steps[ev.Phase] = append(steps[ev.Phase], ev.Name)
+		}
+		assert.Empty(started, "steps which didn't end")
+		return steps
+	}
+
+	{
+		ps := newGroth16System(assert, ecc.BN254, &progressCircuit{}, nil)
+
+		var events []backend.ProverEvent
+		_, err = ps.prove(w, backend.WithProgress(func(ev backend.ProverEvent) {
+			events = append(events, ev)
+		}))
+		assert.NoError(err)
+
+		steps := checkEvents(events, false)
+		assert.Equal([]string{"solve"}, steps[backend.PhaseSolve])
+		assert.Equal([]string{"h"}, steps[backend.PhaseFFT])
+		assert.ElementsMatch([]string{"ar", "bs1", "bs2", "krs", "krs2"}, steps[backend.PhaseMSM])
+
+		// the solver fails: the solve step and the proof end with its error
+		events = nil
+		_, err = ps.prove(invalid, backend.WithProgress(func(ev backend.ProverEvent) {
+			events = append(events, ev)
+		}))
+		assert.Error(err)
+		steps = checkEvents(events, true)
+		assert.Equal([]string{"solve"}, steps[backend.PhaseSolve])
+		assert.Error(events[len(events)-2].Err)
+
+		// cancelled when the multi-exponentiations start: the started ones end
+		events = proveCancelled(ps, backend.PhaseMSM)
+		steps = checkEvents(events, true)
+		assert.ErrorIs(events[len(events)-1].Err, context.Canceled)
+		assert.NotEmpty(steps[backend.PhaseMSM])
+	}
+
+	{
+		ps := newPlonkSystem(assert, ecc.BN254, &progressCircuit{}, nil)
+
+		var events []backend.ProverEvent
+		_, err = ps.prove(w, backend.WithProgress(func(ev backend.ProverEvent) {
+			events = append(events, ev)
+		}))
+		assert.NoError(err)
+
+		steps := checkEvents(events, false)
+		assert.Equal([]string{"solve"}, steps[backend.PhaseSolve])
+		assert.Equal([]string{"lro"}, steps[backend.PhaseFFT])
+		assert.ElementsMatch([]string{"lro", "z", "h", "linearized"}, steps[backend.PhaseCommit])
+		assert.Equal([]string{"h"}, steps[backend.PhaseQuotient])
+		assert.ElementsMatch([]string{"z", "batch"}, steps[backend.PhaseOpen])
+
+		// cancelled when the commitments start: the proof stops after the
+		// commitment of l, r, o
+		events = proveCancelled(ps, backend.PhaseCommit)
+		steps = checkEvents(events, true)
+		assert.ErrorIs(events[len(events)-1].Err, context.Canceled)
+		assert.Equal([]string{"lro"}, steps[backend.PhaseCommit])
+		assert.Empty(steps[backend.PhaseOpen])
+	}
+}
@@ -0,0 +1,134 @@
+package gnark_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+type proverCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *proverCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func TestProver(t *testing.T) {
+	assert := require.New(t)
+
+	var witnesses, publicWitnesses []*witness.Witness
+	for x := 0; x < 5; x++ {
+		w, err := frontend.NewWitness(&proverCircuit{X: x, Y: x*x*x + x + 5}, ecc.BN254)
+		assert.NoError(err)
+		publicW, err := w.Public()
+		assert.NoError(err)
+		witnesses = append(witnesses, w)
+		publicWitnesses = append(publicWitnesses, publicW)
+	}
+	invalid, err := frontend.NewWitness(&proverCircuit{X: 3, Y: 42}, ecc.BN254)
+	assert.NoError(err)
+	invalidBatch := []*witness.Witness{witnesses[0], witnesses[1], invalid, witnesses[2]}
+
+	for _, ps := range newProvingSystems(assert, ecc.BN254, &proverCircuit{}, nil) {
+		ps := ps
+		t.Run(ps.ID.String(), func(t *testing.T) {
+			assert := require.New(t)
+			prover, err := ps.newProver()
+			assert.NoError(err)
+
+			for i, w := range witnesses {
+				proof, err := prover.prove(w)
+				assert.NoError(err)
+				assert.NoError(ps.verify(proof, publicWitnesses[i]))
+			}
+			proofs, err := prover.proveBatch(witnesses)
+			assert.NoError(err)
+			assert.Len(proofs, len(witnesses))
+			for i, proof := range proofs {
+				assert.NoError(ps.verify(proof, publicWitnesses[i]), "proof %d", i)
+			}
+
+			_, err = prover.proveBatch(invalidBatch)
+			assert.Error(err)
+			assert.Contains(err.Error(), "witness 2")
+
+			// the prover is still usable after an error
+			proof, err := prover.prove(witnesses[4])
+			assert.NoError(err)
+			assert.NoError(ps.verify(proof, publicWitnesses[4]))
+		})
+	}
+}
+
+// cancelBatch is called by batchHint on the witness X == cancelledX, which then
+// sleeps so that it is still solved when the previous proof is cancelled
+var (
+	cancelBatch   context.CancelFunc
+	batchHintDone int32 // set once batchHint returns for cancelledX
+)
+
+const cancelledX = 42
+
+func batchHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if inputs[0].IsInt64() && inputs[0].Int64() == cancelledX {
+		cancelBatch()
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&batchHintDone, 1)
+	}
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+type batchCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *batchCircuit) Define(api frontend.API) error {
+	r, err := api.Compiler().NewHint(batchHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(r[0], circuit.X)
+	api.AssertIsEqual(api.Mul(r[0], r[0]), circuit.Y)
+	return nil
+}
+
+func TestProveBatchCancelled(t *testing.T) {
+	assert := require.New(t)
+
+	// the witness 2 cancels the batch while the witness 1 is proved
+	var witnesses []*witness.Witness
+	for _, x := range []int{1, 2, cancelledX, 3} {
+		w, err := frontend.NewWitness(&batchCircuit{X: x, Y: x * x}, ecc.BN254)
+		assert.NoError(err)
+		witnesses = append(witnesses, w)
+	}
+
+	for _, ps := range newProvingSystems(assert, ecc.BN254, &batchCircuit{}, nil) {
+		var ctx context.Context
+		ctx, cancelBatch = context.WithCancel(context.Background())
+		atomic.StoreInt32(&batchHintDone, 0)
+
+		prover, err := ps.newProver(backend.WithHints(batchHint), backend.WithContext(ctx))
+		assert.NoError(err)
+		_, err = prover.proveBatch(witnesses)
+		assert.ErrorIs(err, context.Canceled, ps.ID.String())
+
+		// the solver of the witness 2 is done once ProveBatch returns
+		assert.Equal(int32(1), atomic.LoadInt32(&batchHintDone), ps.ID.String())
+		cancelBatch()
+	}
+}
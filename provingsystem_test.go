@@ -0,0 +1,115 @@
+package gnark_test
+
+import (
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+// provingSystem is a circuit compiled and set up for groth16 or plonk; pk and
+// vk are the keys of the backend, srs is the KZG SRS of plonk
+type provingSystem struct {
+	backend.ID
+	ccs    frontend.CompiledConstraintSystem
+	pk, vk interface{}
+	srs    kzg.SRS
+}
+
+// newProvingSystems returns the circuit set up for groth16 and for plonk, in
+// this order
+func newProvingSystems(assert *require.Assertions, curveID ecc.ID, circuit frontend.Circuit, compileOpts []frontend.CompileOption, setupOpts ...backend.SetupOption) []*provingSystem {
+	return []*provingSystem{
+		newGroth16System(assert, curveID, circuit, compileOpts, setupOpts...),
+		newPlonkSystem(assert, curveID, circuit, compileOpts, setupOpts...),
+	}
+}
+
+func newGroth16System(assert *require.Assertions, curveID ecc.ID, circuit frontend.Circuit, compileOpts []frontend.CompileOption, setupOpts ...backend.SetupOption) *provingSystem {
+	ccs, err := frontend.Compile(curveID, r1cs.NewBuilder, circuit, compileOpts...)
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs, setupOpts...)
+	assert.NoError(err)
+	return &provingSystem{ID: backend.GROTH16, ccs: ccs, pk: pk, vk: vk}
+}
+
+func newPlonkSystem(assert *require.Assertions, curveID ecc.ID, circuit frontend.Circuit, compileOpts []frontend.CompileOption, setupOpts ...backend.SetupOption) *provingSystem {
+	ccs, err := frontend.Compile(curveID, scs.NewBuilder, circuit, compileOpts...)
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs, setupOpts...)
+	assert.NoError(err)
+	return &provingSystem{ID: backend.PLONK, ccs: ccs, pk: pk, vk: vk, srs: srs}
+}
+
+func (ps *provingSystem) prove(fullWitness *witness.Witness, opts ...backend.ProverOption) (interface{}, error) {
+	if ps.ID == backend.GROTH16 {
+		return groth16.Prove(ps.ccs, ps.pk.(groth16.ProvingKey), fullWitness, opts...)
+	}
+	return plonk.Prove(ps.ccs, ps.pk.(plonk.ProvingKey), fullWitness, opts...)
+}
+
+func (ps *provingSystem) verify(proof interface{}, publicWitness *witness.Witness) error {
+	if ps.ID == backend.GROTH16 {
+		return groth16.Verify(proof.(groth16.Proof), ps.vk.(groth16.VerifyingKey), publicWitness)
+	}
+	return plonk.Verify(proof.(plonk.Proof), ps.vk.(plonk.VerifyingKey), publicWitness)
+}
+
+// proveAndVerify checks that the proof of fullWitness verifies
+func (ps *provingSystem) proveAndVerify(assert *require.Assertions, fullWitness *witness.Witness, opts ...backend.ProverOption) {
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	proof, err := ps.prove(fullWitness, opts...)
+	assert.NoError(err, ps.ID.String())
+	assert.NoError(ps.verify(proof, publicWitness), ps.ID.String())
+}
+
+// testProver is a groth16.Prover or a plonk.Prover
+type testProver struct {
+	prove      func(*witness.Witness) (interface{}, error)
+	proveBatch func([]*witness.Witness) ([]interface{}, error)
+}
+
+func (ps *provingSystem) newProver(opts ...backend.ProverOption) (*testProver, error) {
+	if ps.ID == backend.GROTH16 {
+		p, err := groth16.NewProver(ps.ccs, ps.pk.(groth16.ProvingKey), opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &testProver{
+			prove: func(w *witness.Witness) (interface{}, error) { return p.Prove(w) },
+			proveBatch: func(ws []*witness.Witness) ([]interface{}, error) {
+				proofs, err := p.ProveBatch(ws)
+				r := make([]interface{}, len(proofs))
+				for i := range proofs {
+					r[i] = proofs[i]
+				}
+				return r, err
+			},
+		}, nil
+	}
+	p, err := plonk.NewProver(ps.ccs, ps.pk.(plonk.ProvingKey), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &testProver{
+		prove: func(w *witness.Witness) (interface{}, error) { return p.Prove(w) },
+		proveBatch: func(ws []*witness.Witness) ([]interface{}, error) {
+			proofs, err := p.ProveBatch(ws)
+			r := make([]interface{}, len(proofs))
+			for i := range proofs {
+				r[i] = proofs[i]
+			}
+			return r, err
+		},
+	}, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kzg_bls12377
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+)
+
+// BatchOpenSinglePoint creates a batch opening proof at point of a list of
+// polynomials, which BatchVerifySinglePoint accepts. It is the out-of-circuit
+// counterpart of BatchVerifySinglePoint, and differs from
+// kzg.BatchOpenSinglePoint only by the derivation of the folding challenge.
+func BatchOpenSinglePoint(polynomials [][]fr.Element, digests []kzg.Digest, point fr.Element, srs *kzg.SRS) (kzg.BatchOpeningProof, error) {
+	if len(polynomials) == 0 || len(polynomials) != len(digests) {
+		return kzg.BatchOpeningProof{}, kzg.ErrInvalidNbDigests
+	}
+
+	var res kzg.BatchOpeningProof
+	res.ClaimedValues = make([]fr.Element, len(polynomials))
+	largestPoly := 0
+	for i, p := range polynomials {
+		res.ClaimedValues[i] = eval(p, point)
+		if len(p) > largestPoly {
+			largestPoly = len(p)
+		}
+	}
+
+	gamma, err := nativeGamma(digests, res.ClaimedValues, point)
+	if err != nil {
+		return kzg.BatchOpeningProof{}, err
+	}
+
+	// ∑ᵢγⁱfᵢ
+	folded := make([]fr.Element, largestPoly)
+	var acc, tmp fr.Element
+	acc.SetOne()
+	for _, p := range polynomials {
+		for j := range p {
+			tmp.Mul(&p[j], &acc)
+			folded[j].Add(&folded[j], &tmp)
+		}
+		acc.Mul(&acc, &gamma)
+	}
+
+	proof, err := kzg.Open(folded, point, srs)
+	if err != nil {
+		return kzg.BatchOpeningProof{}, err
+	}
+	res.H = proof.H
+
+	return res, nil
+}
+
+// nativeGamma computes the challenge derived in-circuit by deriveGamma.
+func nativeGamma(digests []kzg.Digest, claimedValues []fr.Element, point fr.Element) (fr.Element, error) {
+	var values []*big.Int
+	values = append(values, point.ToBigIntRegular(new(big.Int)))
+	for i := range digests {
+		values = append(values, digests[i].X.ToBigIntRegular(new(big.Int)), digests[i].Y.ToBigIntRegular(new(big.Int)))
+	}
+	for i := range claimedValues {
+		values = append(values, claimedValues[i].ToBigIntRegular(new(big.Int)))
+	}
+
+	// the in-circuit transcript writes the challenge name as a single field
+	// element, and each binded value as a field element.
+	h := mimc.NewMiMC()
+	buf := make([]byte, mimc.BlockSize)
+	if _, err := h.Write(new(big.Int).SetBytes([]byte("gamma")).FillBytes(buf)); err != nil {
+		return fr.Element{}, err
+	}
+	for _, v := range values {
+		if _, err := h.Write(v.FillBytes(buf)); err != nil {
+			return fr.Element{}, err
+		}
+	}
+
+	// keep the gammaBits low bits
+	gamma := new(big.Int).SetBytes(h.Sum(nil))
+	gamma.Mod(gamma, new(big.Int).Lsh(big.NewInt(1), gammaBits))
+
+	var res fr.Element
+	res.SetBigInt(gamma)
+	return res, nil
+}
+
+// eval returns p(point).
+func eval(p []fr.Element, point fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &point).Add(&res, &p[i])
+	}
+	return res
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kzg_bls12377 provides a ZKP-circuit function to verify BLS12_377 KZG openings inside a BW6_761 circuit.
+//
+// The openings are the ones produced by gnark-crypto's kzg package. The
+// polynomials and their evaluations live in the scalar field of BLS12_377,
+// which is smaller than the native field of BW6_761, so that a BLS12_377
+// scalar fits in a single frontend.Variable.
+package kzg_bls12377
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
+	fiatshamir "github.com/consensys/gnark/std/fiat-shamir"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Digest is a commitment to a polynomial, [f(α)]G₁. It is assigned directly
+// from a kzg.Digest with Digest.Assign.
+type Digest = sw_bls12377.G1Affine
+
+// OpeningProof is a KZG proof of the evaluation of a polynomial at a point.
+type OpeningProof struct {
+	// H quotient polynomial (f - f(z))/(x-z)
+	H sw_bls12377.G1Affine
+
+	// ClaimedValue purported value
+	ClaimedValue frontend.Variable
+}
+
+// BatchOpeningProof is a KZG proof of the evaluations of several polynomials
+// at a single point.
+type BatchOpeningProof struct {
+	// H quotient polynomial Sum_i gamma**i*(f - f(z))/(x-z)
+	H sw_bls12377.G1Affine
+
+	// ClaimedValues purported values
+	ClaimedValues []frontend.Variable
+}
+
+// VerifyingKey is the part of the SRS needed to verify openings.
+type VerifyingKey struct {
+	// G₁
+	G1 sw_bls12377.G1Affine
+
+	// [G₂, [α]G₂]
+	G2 [2]sw_bls12377.G2Affine
+}
+
+// Assign values to the "in-circuit" OpeningProof from a "out-of-circuit" kzg.OpeningProof
+func (proof *OpeningProof) Assign(p *kzg.OpeningProof) {
+	proof.H.Assign(&p.H)
+	proof.ClaimedValue = p.ClaimedValue.ToBigIntRegular(new(big.Int))
+}
+
+// Assign values to the "in-circuit" BatchOpeningProof from a "out-of-circuit" kzg.BatchOpeningProof
+func (proof *BatchOpeningProof) Assign(p *kzg.BatchOpeningProof) {
+	proof.H.Assign(&p.H)
+	proof.ClaimedValues = make([]frontend.Variable, len(p.ClaimedValues))
+	for i := range p.ClaimedValues {
+		proof.ClaimedValues[i] = p.ClaimedValues[i].ToBigIntRegular(new(big.Int))
+	}
+}
+
+// Assign values to the "in-circuit" VerifyingKey from a "out-of-circuit" kzg.SRS
+func (vk *VerifyingKey) Assign(srs *kzg.SRS) {
+	vk.G1.Assign(&srs.G1[0])
+	vk.G2[0].Assign(&srs.G2[0])
+	vk.G2[1].Assign(&srs.G2[1])
+}
+
+// Verify verifies a KZG opening proof of digest at point.
+func Verify(api frontend.API, vk VerifyingKey, digest Digest, proof OpeningProof, point frontend.Variable) {
+	// [f(α) - f(a)]G₁
+	var fminusfa sw_bls12377.G1Affine
+	fminusfa.ScalarMul(api, vk.G1, proof.ClaimedValue).
+		Neg(api, fminusfa).
+		AddAssign(api, digest)
+
+	checkOpening(api, vk, fminusfa, proof.H, point)
+}
+
+// BatchVerifySinglePoint verifies a batched KZG opening proof of digests at
+// point.
+//
+// The digests and claimed values are folded with a challenge γ derived with
+// std/fiat-shamir and MiMC, which differs from the challenge derived by
+// kzg.BatchOpenSinglePoint. The proof must be computed with
+// BatchOpenSinglePoint of this package.
+func BatchVerifySinglePoint(api frontend.API, vk VerifyingKey, digests []Digest, proof BatchOpeningProof, point frontend.Variable) {
+	if len(digests) == 0 || len(digests) != len(proof.ClaimedValues) {
+		panic("the number of digests and claimed values must be equal and non-zero")
+	}
+
+	gamma := deriveGamma(api, digests, proof.ClaimedValues, point)
+
+	// ∑ᵢγⁱ[fᵢ(α) - fᵢ(a)]G₁, by Horner's method
+	var folded, tmp sw_bls12377.G1Affine
+	for i := len(digests) - 1; i >= 0; i-- {
+		tmp.ScalarMul(api, vk.G1, proof.ClaimedValues[i]).
+			Neg(api, tmp).
+			AddAssign(api, digests[i])
+		if i == len(digests)-1 {
+			folded = tmp
+		} else {
+			folded.ScalarMul(api, folded, gamma).
+				AddAssign(api, tmp)
+		}
+	}
+
+	checkOpening(api, vk, folded, proof.H, point)
+}
+
+// checkOpening checks that (α-a)·H(α) = f(α) - f(a), given [f(α) - f(a)]G₁.
+//
+// The check is rewritten as e([f(α) - f(a) + a·H(α)]G₁, G₂).e([-H(α)]G₁, [α]G₂) == 1
+// so that no scalar multiplication is needed on G₂.
+func checkOpening(api frontend.API, vk VerifyingKey, fminusfa, h sw_bls12377.G1Affine, point frontend.Variable) {
+	var lhs, negH sw_bls12377.G1Affine
+	lhs.ScalarMul(api, h, point).
+		AddAssign(api, fminusfa)
+	negH.Neg(api, h)
+
+	ml, _ := sw_bls12377.MillerLoop(api, []sw_bls12377.G1Affine{lhs, negH}, []sw_bls12377.G2Affine{vk.G2[0], vk.G2[1]})
+	pairing := sw_bls12377.FinalExponentiation(api, ml)
+
+	var one fields_bls12377.E12
+	one.SetOne()
+	pairing.AssertIsEqual(api, one)
+}
+
+// deriveGamma derives the folding challenge, binded to the point, the digests
+// and the claimed values. It is truncated to gammaBits bits, so that it is a
+// canonical BLS12_377 scalar.
+func deriveGamma(api frontend.API, digests []Digest, claimedValues []frontend.Variable, point frontend.Variable) frontend.Variable {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		panic(err)
+	}
+	fs := fiatshamir.NewTranscript(api, &h, "gamma")
+	if err := fs.Bind("gamma", gammaBindings(point, digests, claimedValues)); err != nil {
+		panic(err)
+	}
+	gamma, err := fs.ComputeChallenge("gamma")
+	if err != nil {
+		panic(err)
+	}
+
+	b := bits.ToBinary(api, gamma)
+	return bits.FromBinary(api, b[:gammaBits])
+}
+
+// gammaBits is the size of the folding challenge.
+const gammaBits = 128
+
+// gammaBindings returns the values the folding challenge is binded to, in the
+// order in which they are hashed.
+func gammaBindings(point frontend.Variable, digests []Digest, claimedValues []frontend.Variable) []frontend.Variable {
+	res := make([]frontend.Variable, 0, 1+3*len(digests))
+	res = append(res, point)
+	for i := range digests {
+		res = append(res, digests[i].X, digests[i].Y)
+	}
+	return append(res, claimedValues...)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kzg_bls12377
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+const polySize = 8
+
+func randomPolynomial() []fr.Element {
+	p := make([]fr.Element, polySize)
+	for i := range p {
+		p[i].SetRandom()
+	}
+	return p
+}
+
+func newSRS(t *testing.T) *kzg.SRS {
+	srs, err := kzg.NewSRS(polySize, big.NewInt(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srs
+}
+
+type verifierCircuit struct {
+	Vk     VerifyingKey
+	Digest Digest
+	Proof  OpeningProof
+	Point  frontend.Variable
+}
+
+func (circuit *verifierCircuit) Define(api frontend.API) error {
+	Verify(api, circuit.Vk, circuit.Digest, circuit.Proof, circuit.Point)
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	srs := newSRS(t)
+
+	p := randomPolynomial()
+	digest, err := kzg.Commit(p, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var point fr.Element
+	point.SetRandom()
+	proof, err := kzg.Open(p, point, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kzg.Verify(&digest, &proof, point, srs); err != nil {
+		t.Fatal(err)
+	}
+
+	var witness verifierCircuit
+	witness.Vk.Assign(srs)
+	witness.Digest.Assign(&digest)
+	witness.Proof.Assign(&proof)
+	witness.Point = point.ToBigIntRegular(new(big.Int))
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&verifierCircuit{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	proof.ClaimedValue.Double(&proof.ClaimedValue)
+	witness.Proof.Assign(&proof)
+	assert.SolvingFailed(&verifierCircuit{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+const nbPolynomials = 3
+
+type batchVerifierCircuit struct {
+	Vk      VerifyingKey
+	Digests [nbPolynomials]Digest
+	Proof   BatchOpeningProof
+	Point   frontend.Variable
+}
+
+func (circuit *batchVerifierCircuit) Define(api frontend.API) error {
+	BatchVerifySinglePoint(api, circuit.Vk, circuit.Digests[:], circuit.Proof, circuit.Point)
+	return nil
+}
+
+func TestBatchVerifier(t *testing.T) {
+	srs := newSRS(t)
+
+	polynomials := make([][]fr.Element, nbPolynomials)
+	digests := make([]kzg.Digest, nbPolynomials)
+	for i := range polynomials {
+		polynomials[i] = randomPolynomial()
+		var err error
+		digests[i], err = kzg.Commit(polynomials[i], srs)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var point fr.Element
+	point.SetRandom()
+	proof, err := BatchOpenSinglePoint(polynomials, digests, point, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var circuit, witness batchVerifierCircuit
+	circuit.Proof.ClaimedValues = make([]frontend.Variable, nbPolynomials)
+	witness.Vk.Assign(srs)
+	for i := range digests {
+		witness.Digests[i].Assign(&digests[i])
+	}
+	witness.Proof.Assign(&proof)
+	witness.Point = point.ToBigIntRegular(new(big.Int))
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// wrong claimed value
+	proof.ClaimedValues[1].Double(&proof.ClaimedValues[1])
+	witness.Proof.Assign(&proof)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls24315"
 	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/signed"
+	"github.com/consensys/gnark/std/permutation"
+	"github.com/consensys/gnark/std/selector"
 )
 
 var registerOnce sync.Once
@@ -27,4 +30,8 @@ func registerHints() {
 	hint.Register(bits.NNAF)
 	hint.Register(bits.IthBit)
 	hint.Register(bits.NBits)
+	hint.Register(signed.DivModHint)
+	hint.Register(selector.KeyIndicators)
+	hint.Register(selector.StepIndicators)
+	hint.Register(permutation.SortHint)
 }
@@ -0,0 +1,152 @@
+// Package fixedpoint provides fixed-point arithmetic on signed decimals.
+//
+// A fixed-point number x is represented by the signed integer
+// X = x·2^FractionalBits, which must fit in IntegerBits+FractionalBits bits
+// (see package signed for the encoding of negative values). Results of
+// multiplications and divisions are range checked, so that a circuit fails to
+// be satisfied rather than silently overflow.
+//
+// Config provides native counterparts of the circuit operations, to compute
+// the witness values matching a circuit.
+package fixedpoint
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/signed"
+)
+
+// API performs fixed-point operations with a given precision.
+type API struct {
+	api    frontend.API
+	signed *signed.API
+	cfg    Config
+	one    *big.Int // 2^FractionalBits
+}
+
+// New returns an API for fixed-point numbers described by cfg.
+func New(api frontend.API, cfg Config) (*API, error) {
+	if cfg.IntegerBits < 1 {
+		return nil, errors.New("at least one integer bit is needed for the sign")
+	}
+	if cfg.FractionalBits < 1 {
+		return nil, errors.New("at least one fractional bit is needed")
+	}
+	s, err := signed.New(api, cfg.NbBits())
+	if err != nil {
+		return nil, err
+	}
+	return &API{
+		api:    api,
+		signed: s,
+		cfg:    cfg,
+		one:    cfg.one(),
+	}, nil
+}
+
+// Signed returns the signed integer API used to handle the representations of
+// fixed-point numbers.
+func (f *API) Signed() *signed.API {
+	return f.signed
+}
+
+// FromInt returns the fixed-point representation of the integer v. It fails if
+// the result overflows.
+func (f *API) FromInt(v frontend.Variable) frontend.Variable {
+	return f.signed.Mul(v, f.one)
+}
+
+// Floor returns the largest integer smaller or equal to v. The result is an
+// integer, not a fixed-point representation.
+func (f *API) Floor(v frontend.Variable) frontend.Variable {
+	q, _ := f.shiftRight(v)
+	return q
+}
+
+// AssertIsInRange fails if v is not a valid fixed-point representation.
+func (f *API) AssertIsInRange(v frontend.Variable) {
+	f.signed.AssertIsInRange(v)
+}
+
+// Add returns a + b. It fails if the result overflows.
+func (f *API) Add(a, b frontend.Variable) frontend.Variable {
+	return f.signed.Add(a, b)
+}
+
+// Sub returns a - b. It fails if the result overflows.
+func (f *API) Sub(a, b frontend.Variable) frontend.Variable {
+	return f.signed.Sub(a, b)
+}
+
+// Neg returns -a.
+func (f *API) Neg(a frontend.Variable) frontend.Variable {
+	return f.api.Neg(a)
+}
+
+// Abs returns |a|.
+func (f *API) Abs(a frontend.Variable) frontend.Variable {
+	return f.signed.Abs(a)
+}
+
+// Mul returns a * b, truncated to the precision of the representation
+// (rounded towards -∞). It fails if the result overflows.
+func (f *API) Mul(a, b frontend.Variable) frontend.Variable {
+	q, _ := f.shiftRight(f.api.Mul(a, b))
+	return q
+}
+
+// MulRound returns a * b, rounded to the nearest representable value (halves
+// are rounded towards +∞). It fails if the result overflows.
+func (f *API) MulRound(a, b frontend.Variable) frontend.Variable {
+	half := new(big.Int).Rsh(f.one, 1)
+	q, _ := f.shiftRight(f.api.Add(f.api.Mul(a, b), half))
+	return q
+}
+
+// Div returns a / b, truncated to the precision of the representation. The
+// division is Euclidean (see signed.API.DivMod), that is, it rounds towards
+// -∞ when b > 0 and towards +∞ when b < 0. It fails if b == 0 or if the result
+// overflows.
+func (f *API) Div(a, b frontend.Variable) frontend.Variable {
+	return f.signed.Div(f.api.Mul(a, f.one), b)
+}
+
+// Cmp returns 1 if a > b, 0 if a == b and -1 if a < b.
+func (f *API) Cmp(a, b frontend.Variable) frontend.Variable {
+	return f.signed.Cmp(a, b)
+}
+
+// IsLess returns 1 if a < b, 0 otherwise.
+func (f *API) IsLess(a, b frontend.Variable) frontend.Variable {
+	return f.signed.IsLess(a, b)
+}
+
+// AssertIsLess fails if a >= b.
+func (f *API) AssertIsLess(a, b frontend.Variable) {
+	f.signed.AssertIsLess(a, b)
+}
+
+// AssertIsLessOrEqual fails if a > b.
+func (f *API) AssertIsLessOrEqual(a, b frontend.Variable) {
+	f.signed.AssertIsLessOrEqual(a, b)
+}
+
+// shiftRight returns q, r such that v = q·2^FractionalBits + r with
+// 0 <= r < 2^FractionalBits. q is range checked, v is expected to fit in
+// 2·(IntegerBits+FractionalBits) bits.
+func (f *API) shiftRight(v frontend.Variable) (q, r frontend.Variable) {
+	res, err := f.api.Compiler().NewHint(signed.DivModHint, 2, v, f.one)
+	if err != nil {
+		panic(err)
+	}
+	q, r = res[0], res[1]
+
+	bits.ToBinary(f.api, r, bits.WithNbDigits(f.cfg.FractionalBits))
+	f.signed.AssertIsInRange(q)
+	f.api.AssertIsEqual(v, f.api.Add(f.api.Mul(q, f.one), r))
+
+	return q, r
+}
@@ -0,0 +1,96 @@
+package fixedpoint_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/fixedpoint"
+	"github.com/consensys/gnark/test"
+)
+
+var cfg = fixedpoint.Config{IntegerBits: 16, FractionalBits: 16}
+
+type arithCircuit struct {
+	A, B                 frontend.Variable
+	Sum, Prod, ProdRound frontend.Variable
+	Quo, FloorA, Cmp     frontend.Variable
+}
+
+func (c *arithCircuit) Define(api frontend.API) error {
+	f, err := fixedpoint.New(api, cfg)
+	if err != nil {
+		return err
+	}
+	f.AssertIsInRange(c.A)
+	f.AssertIsInRange(c.B)
+	api.AssertIsEqual(f.Add(c.A, c.B), c.Sum)
+	api.AssertIsEqual(f.Mul(c.A, c.B), c.Prod)
+	api.AssertIsEqual(f.MulRound(c.A, c.B), c.ProdRound)
+	api.AssertIsEqual(f.Div(c.A, c.B), c.Quo)
+	api.AssertIsEqual(f.Floor(c.A), c.FloorA)
+	api.AssertIsEqual(f.Cmp(c.A, c.B), c.Cmp)
+	return nil
+}
+
+func newArithAssignment(a, b float64) *arithCircuit {
+	fa, fb := cfg.FromFloat(a), cfg.FromFloat(b)
+	cmp := 0
+	if a < b {
+		cmp = -1
+	} else if a > b {
+		cmp = 1
+	}
+	return &arithCircuit{
+		A:         fa,
+		B:         fb,
+		Sum:       cfg.FromFloat(a + b),
+		Prod:      cfg.Mul(fa, fb),
+		ProdRound: cfg.MulRound(fa, fb),
+		Quo:       cfg.Div(fa, fb),
+		FloorA:    cfg.Floor(fa),
+		Cmp:       cmp,
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&arithCircuit{}, newArithAssignment(3.25, 1.5))
+	assert.ProverSucceeded(&arithCircuit{}, newArithAssignment(-2.7, 0.333))
+	assert.ProverSucceeded(&arithCircuit{}, newArithAssignment(-100.125, -7.75))
+
+	// truncated product is off by one ulp
+	w := newArithAssignment(-2.7, 0.333)
+	w.Prod = w.ProdRound
+	assert.ProverFailed(&arithCircuit{}, w)
+}
+
+func TestNative(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.Equal(3.25, cfg.ToFloat(cfg.FromFloat(3.25)))
+	assert.Equal(-1.5, cfg.ToFloat(cfg.FromFloat(-1.5)))
+	assert.Equal(0.75, cfg.ToFloat(cfg.Mul(cfg.FromFloat(1.5), cfg.FromFloat(0.5))))
+	assert.Equal(-3.0, cfg.ToFloat(cfg.Div(cfg.FromFloat(1.5), cfg.FromFloat(-0.5))))
+	assert.Equal(int64(-3), cfg.Floor(cfg.FromFloat(-2.5)).Int64())
+}
+
+type overflowCircuit struct {
+	A, B frontend.Variable
+}
+
+func (c *overflowCircuit) Define(api frontend.API) error {
+	f, err := fixedpoint.New(api, cfg)
+	if err != nil {
+		return err
+	}
+	f.Mul(c.A, c.B)
+	return nil
+}
+
+func TestOverflow(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&overflowCircuit{}, &overflowCircuit{A: cfg.FromInt(100), B: cfg.FromInt(300)})
+	assert.ProverFailed(&overflowCircuit{}, &overflowCircuit{A: cfg.FromInt(200), B: cfg.FromInt(300)})
+}
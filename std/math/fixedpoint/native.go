@@ -0,0 +1,72 @@
+package fixedpoint
+
+import (
+	"math"
+	"math/big"
+)
+
+// Config describes a fixed-point representation.
+type Config struct {
+	// IntegerBits is the number of bits of the integer part, including the
+	// sign bit.
+	IntegerBits int
+
+	// FractionalBits is the number of bits of the fractional part.
+	FractionalBits int
+}
+
+// NbBits returns the total number of bits of the representation.
+func (c Config) NbBits() int {
+	return c.IntegerBits + c.FractionalBits
+}
+
+func (c Config) one() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(c.FractionalBits))
+}
+
+// FromInt returns the representation of the integer v.
+func (c Config) FromInt(v int64) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(v), uint(c.FractionalBits))
+}
+
+// FromFloat returns the representation of v, rounded to the nearest
+// representable value.
+func (c Config) FromFloat(v float64) *big.Int {
+	f := new(big.Float).SetFloat64(math.Round(math.Ldexp(v, c.FractionalBits)))
+	res, _ := f.Int(nil)
+	return res
+}
+
+// ToFloat returns the value represented by v.
+func (c Config) ToFloat(v *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return math.Ldexp(f, -c.FractionalBits)
+}
+
+// Floor returns the largest integer smaller or equal to the value represented
+// by v, as API.Floor does.
+func (c Config) Floor(v *big.Int) *big.Int {
+	var m big.Int
+	q, _ := new(big.Int).DivMod(v, c.one(), &m)
+	return q
+}
+
+// Mul returns the representation of a * b truncated as API.Mul does.
+func (c Config) Mul(a, b *big.Int) *big.Int {
+	return c.Floor(new(big.Int).Mul(a, b))
+}
+
+// MulRound returns the representation of a * b rounded as API.MulRound does.
+func (c Config) MulRound(a, b *big.Int) *big.Int {
+	p := new(big.Int).Mul(a, b)
+	p.Add(p, new(big.Int).Rsh(c.one(), 1))
+	return c.Floor(p)
+}
+
+// Div returns the representation of a / b truncated as API.Div does.
+func (c Config) Div(a, b *big.Int) *big.Int {
+	var m big.Int
+	n := new(big.Int).Mul(a, c.one())
+	q, _ := new(big.Int).DivMod(n, b, &m)
+	return q
+}
@@ -0,0 +1,56 @@
+package signed
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+)
+
+func init() {
+	hint.Register(DivModHint)
+}
+
+// DivModHint computes the Euclidean division of inputs[0] by inputs[1], both
+// interpreted as signed integers (see FromField). It returns the quotient and
+// the remainder, encoded as field elements.
+func DivModHint(curveID ecc.ID, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) != 2 || len(results) != 2 {
+		return errors.New("DivModHint expects 2 inputs and 2 outputs")
+	}
+	q := curveID.Info().Fr.Modulus()
+	a := FromField(inputs[0], q)
+	b := FromField(inputs[1], q)
+	if b.Sign() == 0 {
+		return errors.New("division by zero")
+	}
+	results[0].DivMod(a, b, results[1])
+	results[0].Mod(results[0], q)
+	return nil
+}
+
+// FromField returns the signed integer encoded by the field element v modulo
+// q: elements larger than (q-1)/2 represent negative integers.
+func FromField(v, q *big.Int) *big.Int {
+	res := new(big.Int).Mod(v, q)
+	half := new(big.Int).Rsh(q, 1)
+	if res.Cmp(half) > 0 {
+		res.Sub(res, q)
+	}
+	return res
+}
+
+// Wrap reduces v to a signed integer of nbBits bits, as two's complement
+// arithmetic and the API.*WithOverflow methods do, and reports whether v was
+// out of range.
+func Wrap(v *big.Int, nbBits int) (res *big.Int, overflow bool) {
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(nbBits-1))
+	modulus := new(big.Int).Lsh(bound, 1)
+
+	// res = ((v + 2^(nbBits-1)) mod 2^nbBits) - 2^(nbBits-1)
+	res = new(big.Int).Add(v, bound)
+	res.Mod(res, modulus).Sub(res, bound)
+
+	return res, res.Cmp(v) != 0
+}
@@ -0,0 +1,242 @@
+// Package signed provides arithmetic and comparisons on signed integers
+// embedded in the native field.
+//
+// frontend.API treats variables as unsigned field elements: api.Cmp and
+// api.AssertIsLessOrEqual compare the canonical representatives in [0, r).
+// This package instead interprets a variable as an nbBits wide signed integer
+// x ∈ [-2^(nbBits-1), 2^(nbBits-1)), where a negative value -x is encoded by
+// the field element r - x (which is what frontend.NewWitness does when
+// assigning a negative integer).
+//
+// Signed comparisons are implemented as in two's complement: the operand is
+// offset by 2^(nbBits-1) so that the signed range maps onto [0, 2^nbBits), and
+// the offset value is decomposed into nbBits bits. The most significant bit
+// then gives the sign, and the decomposition itself is the range check.
+package signed
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// API performs signed operations on nbBits wide integers.
+type API struct {
+	api    frontend.API
+	nbBits int
+}
+
+// New returns an API for signed integers of nbBits bits. nbBits must be at
+// least 2 and small enough so that the product of two such integers does not
+// wrap around the field modulus.
+func New(api frontend.API, nbBits int) (*API, error) {
+	if nbBits < 2 {
+		return nil, errors.New("nbBits must be at least 2")
+	}
+	if 2*nbBits+1 >= api.Compiler().Curve().Info().Fr.Bits {
+		return nil, errors.New("nbBits is too large for the scalar field")
+	}
+	return &API{api: api, nbBits: nbBits}, nil
+}
+
+// NbBits returns the width of the signed integers handled by s.
+func (s *API) NbBits() int {
+	return s.nbBits
+}
+
+// decompose constrains v to be in [-2^(nbBits-1), 2^(nbBits-1)) and returns the
+// little-endian bits of v + 2^(nbBits-1).
+func (s *API) decompose(v frontend.Variable, nbBits int) []frontend.Variable {
+	offset := new(big.Int).Lsh(big.NewInt(1), uint(nbBits-1))
+	return s.toBinary(s.api.Add(v, offset), nbBits)
+}
+
+// toBinary constrains v to be in [0, 2^nbBits) and returns its little-endian
+// bits.
+func (s *API) toBinary(v frontend.Variable, nbBits int) []frontend.Variable {
+	// bits.ToBinary truncates constants without checking them
+	if c, ok := s.api.Compiler().ConstantValue(v); ok && c.BitLen() > nbBits {
+		panic(fmt.Sprintf("constant %s does not fit in %d bits", c.String(), nbBits))
+	}
+	return bits.ToBinary(s.api, v, bits.WithNbDigits(nbBits))
+}
+
+// AssertIsInRange fails if v ∉ [-2^(nbBits-1), 2^(nbBits-1)).
+func (s *API) AssertIsInRange(v frontend.Variable) {
+	s.decompose(v, s.nbBits)
+}
+
+// AssertIsNonNegative fails if v ∉ [0, 2^(nbBits-1)).
+func (s *API) AssertIsNonNegative(v frontend.Variable) {
+	s.toBinary(v, s.nbBits-1)
+}
+
+// IsNegative returns 1 if v < 0, 0 otherwise. It fails if v is not in range.
+func (s *API) IsNegative(v frontend.Variable) frontend.Variable {
+	b := s.decompose(v, s.nbBits)
+	return s.api.Sub(1, b[s.nbBits-1])
+}
+
+// Abs returns |v|. It fails if v is not in range.
+//
+// Note that Abs(-2^(nbBits-1)) = 2^(nbBits-1) is not itself in range.
+func (s *API) Abs(v frontend.Variable) frontend.Variable {
+	return s.api.Select(s.IsNegative(v), s.api.Neg(v), v)
+}
+
+// Cmp returns 1 if a > b, 0 if a == b and -1 if a < b.
+//
+// a and b are expected to be in range; the difference a - b is range checked
+// over nbBits+1 bits, so that the result is the sign of a - b.
+func (s *API) Cmp(a, b frontend.Variable) frontend.Variable {
+	d := s.api.Sub(a, b)
+	isNeg := s.isNegativeDiff(d)
+	isZero := s.api.IsZero(d)
+
+	// (1 - isZero) * (1 - 2*isNeg)
+	return s.api.Mul(s.api.Sub(1, isZero), s.api.Sub(1, s.api.Mul(2, isNeg)))
+}
+
+// IsLess returns 1 if a < b, 0 otherwise.
+func (s *API) IsLess(a, b frontend.Variable) frontend.Variable {
+	return s.isNegativeDiff(s.api.Sub(a, b))
+}
+
+// IsLessOrEqual returns 1 if a <= b, 0 otherwise.
+func (s *API) IsLessOrEqual(a, b frontend.Variable) frontend.Variable {
+	return s.api.Sub(1, s.IsLess(b, a))
+}
+
+// AssertIsLess fails if a >= b.
+func (s *API) AssertIsLess(a, b frontend.Variable) {
+	// b - a - 1 ∈ [0, 2^nbBits)
+	s.toBinary(s.api.Sub(b, a, 1), s.nbBits)
+}
+
+// AssertIsLessOrEqual fails if a > b.
+func (s *API) AssertIsLessOrEqual(a, b frontend.Variable) {
+	// b - a ∈ [0, 2^nbBits)
+	s.toBinary(s.api.Sub(b, a), s.nbBits)
+}
+
+// Min returns the smallest of a and b.
+func (s *API) Min(a, b frontend.Variable) frontend.Variable {
+	return s.api.Select(s.IsLess(a, b), a, b)
+}
+
+// Max returns the largest of a and b.
+func (s *API) Max(a, b frontend.Variable) frontend.Variable {
+	return s.api.Select(s.IsLess(a, b), b, a)
+}
+
+// isNegativeDiff returns 1 if d < 0, where d is the difference of two
+// integers in range and thus fits in nbBits+1 bits.
+func (s *API) isNegativeDiff(d frontend.Variable) frontend.Variable {
+	b := s.decompose(d, s.nbBits+1)
+	return s.api.Sub(1, b[s.nbBits])
+}
+
+// Add returns a + b. It fails if the result overflows.
+func (s *API) Add(a, b frontend.Variable) frontend.Variable {
+	res := s.api.Add(a, b)
+	s.AssertIsInRange(res)
+	return res
+}
+
+// Sub returns a - b. It fails if the result overflows.
+func (s *API) Sub(a, b frontend.Variable) frontend.Variable {
+	res := s.api.Sub(a, b)
+	s.AssertIsInRange(res)
+	return res
+}
+
+// Mul returns a * b. It fails if the result overflows.
+func (s *API) Mul(a, b frontend.Variable) frontend.Variable {
+	res := s.api.Mul(a, b)
+	s.AssertIsInRange(res)
+	return res
+}
+
+// AddWithOverflow returns a + b wrapped around to nbBits bits, as with two's
+// complement arithmetic, and 1 if the addition overflowed, 0 otherwise.
+//
+// a and b are expected to be in range.
+func (s *API) AddWithOverflow(a, b frontend.Variable) (res, overflow frontend.Variable) {
+	return s.wrap(s.api.Add(a, b), s.nbBits+1)
+}
+
+// SubWithOverflow returns a - b wrapped around to nbBits bits, as with two's
+// complement arithmetic, and 1 if the subtraction overflowed, 0 otherwise.
+//
+// a and b are expected to be in range.
+func (s *API) SubWithOverflow(a, b frontend.Variable) (res, overflow frontend.Variable) {
+	return s.wrap(s.api.Sub(a, b), s.nbBits+1)
+}
+
+// MulWithOverflow returns a * b wrapped around to nbBits bits, as with two's
+// complement arithmetic, and 1 if the multiplication overflowed, 0 otherwise.
+//
+// a and b are expected to be in range.
+func (s *API) MulWithOverflow(a, b frontend.Variable) (res, overflow frontend.Variable) {
+	return s.wrap(s.api.Mul(a, b), 2*s.nbBits)
+}
+
+// wrap reduces v, a signed integer of width bits, to s.nbBits bits.
+//
+// Let b be the bits of v + 2^(width-1). The low s.nbBits bits of b are the
+// two's complement representation of v mod 2^s.nbBits. v is in range iff the
+// bits b[s.nbBits-1 .. width-2] all differ from the top bit b[width-1].
+func (s *API) wrap(v frontend.Variable, width int) (res, overflow frontend.Variable) {
+	b := s.decompose(v, width)
+
+	n := s.nbBits
+	low := bits.FromBinary(s.api, b[:n-1], bits.WithUnconstrainedInputs())
+	res = s.api.Sub(low, s.api.Mul(b[n-1], new(big.Int).Lsh(big.NewInt(1), uint(n-1))))
+
+	inRange := frontend.Variable(1)
+	for i := n - 1; i < width-1; i++ {
+		inRange = s.api.Mul(inRange, s.api.Xor(b[i], b[width-1]))
+	}
+	overflow = s.api.Sub(1, inRange)
+
+	return res, overflow
+}
+
+// DivMod returns the quotient and remainder of the Euclidean division of a by
+// b, as big.Int.DivMod does: a = q*b + m with 0 <= m < |b|.
+//
+// b must be in range and non-zero, and the quotient must fit in nbBits bits
+// (which is not the case for -2^(nbBits-1) / -1). a may be wider than nbBits
+// bits as long as the quotient fits.
+func (s *API) DivMod(a, b frontend.Variable) (q, m frontend.Variable) {
+	res, err := s.api.Compiler().NewHint(DivModHint, 2, a, b)
+	if err != nil {
+		panic(err)
+	}
+	q, m = res[0], res[1]
+
+	// 0 <= m < |b|
+	s.AssertIsNonNegative(m)
+	s.toBinary(s.api.Sub(s.Abs(b), m, 1), s.nbBits)
+
+	// the quotient is range checked, so that q*b + m can't wrap around the modulus
+	s.AssertIsInRange(q)
+	s.api.AssertIsEqual(a, s.api.Add(s.api.Mul(q, b), m))
+
+	return q, m
+}
+
+// Div returns the quotient of the Euclidean division of a by b. See DivMod.
+func (s *API) Div(a, b frontend.Variable) frontend.Variable {
+	q, _ := s.DivMod(a, b)
+	return q
+}
+
+// Mod returns the remainder of the Euclidean division of a by b. See DivMod.
+func (s *API) Mod(a, b frontend.Variable) frontend.Variable {
+	_, m := s.DivMod(a, b)
+	return m
+}
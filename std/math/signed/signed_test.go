@@ -0,0 +1,128 @@
+package signed_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/signed"
+	"github.com/consensys/gnark/test"
+)
+
+const nbBits = 8
+
+type cmpCircuit struct {
+	A, B                frontend.Variable
+	Cmp, IsLess, IsNegA frontend.Variable
+	Min, Max, AbsA      frontend.Variable
+}
+
+func (c *cmpCircuit) Define(api frontend.API) error {
+	s, err := signed.New(api, nbBits)
+	if err != nil {
+		return err
+	}
+	s.AssertIsInRange(c.A)
+	s.AssertIsInRange(c.B)
+	api.AssertIsEqual(s.Cmp(c.A, c.B), c.Cmp)
+	api.AssertIsEqual(s.IsLess(c.A, c.B), c.IsLess)
+	api.AssertIsEqual(s.IsNegative(c.A), c.IsNegA)
+	api.AssertIsEqual(s.Min(c.A, c.B), c.Min)
+	api.AssertIsEqual(s.Max(c.A, c.B), c.Max)
+	api.AssertIsEqual(s.Abs(c.A), c.AbsA)
+	return nil
+}
+
+func TestCmp(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&cmpCircuit{}, &cmpCircuit{A: -3, B: 2, Cmp: -1, IsLess: 1, IsNegA: 1, Min: -3, Max: 2, AbsA: 3})
+	assert.ProverSucceeded(&cmpCircuit{}, &cmpCircuit{A: 127, B: -128, Cmp: 1, IsLess: 0, IsNegA: 0, Min: -128, Max: 127, AbsA: 127})
+	assert.ProverSucceeded(&cmpCircuit{}, &cmpCircuit{A: -5, B: -5, Cmp: 0, IsLess: 0, IsNegA: 1, Min: -5, Max: -5, AbsA: 5})
+
+	// unsigned comparison would say -3 > 2
+	assert.ProverFailed(&cmpCircuit{}, &cmpCircuit{A: -3, B: 2, Cmp: 1, IsLess: 0, IsNegA: 1, Min: 2, Max: -3, AbsA: 3})
+	// out of range
+	assert.ProverFailed(&cmpCircuit{}, &cmpCircuit{A: 128, B: 2, Cmp: 1, IsLess: 0, IsNegA: 0, Min: 2, Max: 128, AbsA: 128})
+}
+
+type overflowCircuit struct {
+	A, B                     frontend.Variable
+	Sum, Diff, Prod          frontend.Variable
+	SumOvf, DiffOvf, ProdOvf frontend.Variable
+}
+
+func (c *overflowCircuit) Define(api frontend.API) error {
+	s, err := signed.New(api, nbBits)
+	if err != nil {
+		return err
+	}
+	sum, sumOvf := s.AddWithOverflow(c.A, c.B)
+	diff, diffOvf := s.SubWithOverflow(c.A, c.B)
+	prod, prodOvf := s.MulWithOverflow(c.A, c.B)
+	api.AssertIsEqual(sum, c.Sum)
+	api.AssertIsEqual(sumOvf, c.SumOvf)
+	api.AssertIsEqual(diff, c.Diff)
+	api.AssertIsEqual(diffOvf, c.DiffOvf)
+	api.AssertIsEqual(prod, c.Prod)
+	api.AssertIsEqual(prodOvf, c.ProdOvf)
+	return nil
+}
+
+func newOverflowAssignment(a, b int64) *overflowCircuit {
+	toVar := func(v *big.Int, ovf bool) (frontend.Variable, frontend.Variable) {
+		if ovf {
+			return v, 1
+		}
+		return v, 0
+	}
+	var w overflowCircuit
+	w.A, w.B = a, b
+	w.Sum, w.SumOvf = toVar(signed.Wrap(big.NewInt(a+b), nbBits))
+	w.Diff, w.DiffOvf = toVar(signed.Wrap(big.NewInt(a-b), nbBits))
+	w.Prod, w.ProdOvf = toVar(signed.Wrap(big.NewInt(a*b), nbBits))
+	return &w
+}
+
+func TestWithOverflow(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&overflowCircuit{}, newOverflowAssignment(3, -7))
+	assert.ProverSucceeded(&overflowCircuit{}, newOverflowAssignment(100, 100))
+	assert.ProverSucceeded(&overflowCircuit{}, newOverflowAssignment(-128, 1))
+	assert.ProverSucceeded(&overflowCircuit{}, newOverflowAssignment(-128, -128))
+
+	// 100 + 100 overflows
+	assert.ProverFailed(&overflowCircuit{}, &overflowCircuit{A: 100, B: 100, Sum: 200, SumOvf: 0, Diff: 0, DiffOvf: 0, Prod: 16, ProdOvf: 1})
+}
+
+type divModCircuit struct {
+	A, B, Q, M frontend.Variable
+}
+
+func (c *divModCircuit) Define(api frontend.API) error {
+	s, err := signed.New(api, nbBits)
+	if err != nil {
+		return err
+	}
+	q, m := s.DivMod(c.A, c.B)
+	api.AssertIsEqual(q, c.Q)
+	api.AssertIsEqual(m, c.M)
+	return nil
+}
+
+func TestDivMod(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, tc := range [][2]int64{{7, 2}, {-7, 2}, {7, -2}, {-7, -2}, {-128, 127}, {0, -3}} {
+		var q, m big.Int
+		q.DivMod(big.NewInt(tc[0]), big.NewInt(tc[1]), &m)
+		assert.ProverSucceeded(&divModCircuit{}, &divModCircuit{A: tc[0], B: tc[1], Q: &q, M: &m}, test.WithCurves(ecc.BN254))
+	}
+
+	// truncated division is not Euclidean division
+	assert.ProverFailed(&divModCircuit{}, &divModCircuit{A: -7, B: 2, Q: -3, M: -1})
+	// quotient overflows
+	assert.ProverFailed(&divModCircuit{}, &divModCircuit{A: -128, B: -1, Q: 128, M: 0})
+}
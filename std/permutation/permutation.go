@@ -0,0 +1,100 @@
+// Package permutation provides gadgets to prove that an array is a permutation
+// of another one, and to sort arrays and records in-circuit.
+//
+// AssertIsPermutation uses a grand-product argument: b is a permutation of a
+// iff the polynomials Π(X - a[i]) and Π(X - b[i]) are equal, which is checked
+// at a random point γ. γ is derived Fiat-Shamir style, by hashing a and b with
+// MiMC, so that the prover can't choose the values after seeing it. Records
+// of several columns are first folded into a single value with a second
+// challenge α: row ↦ Σ α^j·column[j][row].
+package permutation
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/frontend"
+	fiatshamir "github.com/consensys/gnark/std/fiat-shamir"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// AssertIsPermutation fails if b is not a permutation of a.
+func AssertIsPermutation(api frontend.API, a, b []frontend.Variable) {
+	AssertIsPermutationColumns(api, [][]frontend.Variable{a}, [][]frontend.Variable{b})
+}
+
+// AssertIsPermutationColumns fails if the records of b are not a permutation
+// of the records of a. a and b are given column-wise: the i-th record of a is
+// (a[0][i], a[1][i], ...). All columns must have the same length.
+func AssertIsPermutationColumns(api frontend.API, a, b [][]frontend.Variable) {
+	n, err := checkColumns(a, b)
+	if err != nil {
+		panic(err)
+	}
+	if n == 0 {
+		return
+	}
+
+	alpha, gamma := challenges(api, a, b)
+
+	fa := fold(api, alpha, a)
+	fb := fold(api, alpha, b)
+
+	// Π(γ - a[i]) == Π(γ - b[i])
+	var pa, pb frontend.Variable = 1, 1
+	for i := 0; i < n; i++ {
+		pa = api.Mul(pa, api.Sub(gamma, fa[i]))
+		pb = api.Mul(pb, api.Sub(gamma, fb[i]))
+	}
+	api.AssertIsEqual(pa, pb)
+}
+
+// checkColumns returns the number of records in a and b.
+func checkColumns(a, b [][]frontend.Variable) (int, error) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, errors.New("a and b must have the same non-zero number of columns")
+	}
+	n := len(a[0])
+	for i := range a {
+		if len(a[i]) != n || len(b[i]) != n {
+			return 0, errors.New("all columns must have the same length")
+		}
+	}
+	return n, nil
+}
+
+// challenges returns the challenges (α, γ), bound to all the values of a and b.
+func challenges(api frontend.API, a, b [][]frontend.Variable) (alpha, gamma frontend.Variable) {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		panic(err)
+	}
+	t := fiatshamir.NewTranscript(api, &h, "alpha", "gamma")
+	for _, columns := range [][][]frontend.Variable{a, b} {
+		for _, c := range columns {
+			if err := t.Bind("alpha", c); err != nil {
+				panic(err)
+			}
+		}
+	}
+	if alpha, err = t.ComputeChallenge("alpha"); err != nil {
+		panic(err)
+	}
+	if gamma, err = t.ComputeChallenge("gamma"); err != nil {
+		panic(err)
+	}
+	return alpha, gamma
+}
+
+// fold returns the random linear combinations Σ α^j·columns[j][i].
+func fold(api frontend.API, alpha frontend.Variable, columns [][]frontend.Variable) []frontend.Variable {
+	res := make([]frontend.Variable, len(columns[0]))
+	copy(res, columns[0])
+	var coeff frontend.Variable = 1
+	for j := 1; j < len(columns); j++ {
+		coeff = api.Mul(coeff, alpha)
+		for i := range res {
+			res[i] = api.Add(res[i], api.Mul(coeff, columns[j][i]))
+		}
+	}
+	return res
+}
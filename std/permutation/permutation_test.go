@@ -0,0 +1,59 @@
+package permutation_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/permutation"
+	"github.com/consensys/gnark/test"
+)
+
+type permutationCircuit struct {
+	A, B [5]frontend.Variable
+}
+
+func (c *permutationCircuit) Define(api frontend.API) error {
+	permutation.AssertIsPermutation(api, c.A[:], c.B[:])
+	return nil
+}
+
+func TestPermutation(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&permutationCircuit{}, &permutationCircuit{
+		A: [5]frontend.Variable{1, 2, 3, 2, 5},
+		B: [5]frontend.Variable{2, 5, 2, 1, 3},
+	}, test.WithCurves(ecc.BN254))
+	// same set, different multiplicities
+	assert.ProverFailed(&permutationCircuit{}, &permutationCircuit{
+		A: [5]frontend.Variable{1, 2, 3, 2, 5},
+		B: [5]frontend.Variable{1, 5, 2, 3, 3},
+	}, test.WithCurves(ecc.BN254))
+}
+
+type columnsCircuit struct {
+	A, B [2][4]frontend.Variable
+}
+
+func (c *columnsCircuit) Define(api frontend.API) error {
+	permutation.AssertIsPermutationColumns(api,
+		[][]frontend.Variable{c.A[0][:], c.A[1][:]},
+		[][]frontend.Variable{c.B[0][:], c.B[1][:]})
+	return nil
+}
+
+func TestPermutationColumns(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	a := [2][4]frontend.Variable{{1, 2, 3, 4}, {10, 20, 30, 40}}
+	assert.ProverSucceeded(&columnsCircuit{}, &columnsCircuit{
+		A: a,
+		B: [2][4]frontend.Variable{{3, 1, 4, 2}, {30, 10, 40, 20}},
+	}, test.WithCurves(ecc.BN254))
+	// each column is a permutation, but the records are not
+	assert.ProverFailed(&columnsCircuit{}, &columnsCircuit{
+		A: a,
+		B: [2][4]frontend.Variable{{3, 1, 4, 2}, {10, 30, 40, 20}},
+	}, test.WithCurves(ecc.BN254))
+}
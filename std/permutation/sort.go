@@ -0,0 +1,125 @@
+package permutation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	mbits "math/bits"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+func init() {
+	hint.Register(SortHint)
+}
+
+// AssertIsSorted fails unless a is sorted in non-decreasing order, with
+// a[0] ∈ [0, 2^nbBits) and each gap a[i+1] - a[i] ∈ [0, 2^nbBits). In
+// particular, it succeeds for any sorted array of elements in [0, 2^nbBits).
+//
+// nbBits + log2(len(a)) must be smaller than the size of the scalar field, so
+// that the elements of a, bounded by len(a)·2^nbBits, can't wrap around the
+// modulus.
+func AssertIsSorted(api frontend.API, a []frontend.Variable, nbBits int) {
+	if len(a) == 0 {
+		return
+	}
+	if nbBits+mbits.Len(uint(len(a))) >= api.Compiler().Curve().Info().Fr.Bits {
+		panic("nbBits is too large for the scalar field")
+	}
+	toBinary(api, a[0], nbBits)
+	for i := 0; i+1 < len(a); i++ {
+		toBinary(api, api.Sub(a[i+1], a[i]), nbBits)
+	}
+}
+
+// Sort returns a copy of a sorted in non-decreasing order. The elements of a
+// must be in [0, 2^nbBits). See AssertIsSorted.
+func Sort(api frontend.API, a []frontend.Variable, nbBits int) []frontend.Variable {
+	sorted, _ := SortByKey(api, a, nil, nbBits)
+	return sorted
+}
+
+// SortByKey sorts the records (keys[i], columns[0][i], columns[1][i], ...) by
+// key in non-decreasing order. It returns the sorted keys and columns. Records
+// with equal keys keep their relative order in the witness computed by the
+// solver, but this is not enforced by the circuit. The keys must be in
+// [0, 2^nbBits). See AssertIsSorted.
+func SortByKey(api frontend.API, keys []frontend.Variable, columns [][]frontend.Variable, nbBits int) (sortedKeys []frontend.Variable, sortedColumns [][]frontend.Variable) {
+	n := len(keys)
+	for _, c := range columns {
+		if len(c) != n {
+			panic("all columns must have the same length as keys")
+		}
+	}
+	if n == 0 {
+		return nil, make([][]frontend.Variable, len(columns))
+	}
+
+	inputs := make([]frontend.Variable, 0, 1+n*(len(columns)+1))
+	inputs = append(inputs, len(columns))
+	inputs = append(inputs, keys...)
+	for _, c := range columns {
+		inputs = append(inputs, c...)
+	}
+	res, err := api.Compiler().NewHint(SortHint, n*(len(columns)+1), inputs...)
+	if err != nil {
+		panic(err)
+	}
+
+	sortedKeys = res[:n]
+	sortedColumns = make([][]frontend.Variable, len(columns))
+	for j := range sortedColumns {
+		sortedColumns[j] = res[(j+1)*n : (j+2)*n]
+	}
+
+	AssertIsSorted(api, sortedKeys, nbBits)
+	AssertIsPermutationColumns(api,
+		append([][]frontend.Variable{keys}, columns...),
+		append([][]frontend.Variable{sortedKeys}, sortedColumns...))
+
+	return sortedKeys, sortedColumns
+}
+
+// toBinary constrains v to be in [0, 2^nbBits).
+func toBinary(api frontend.API, v frontend.Variable, nbBits int) {
+	// bits.ToBinary truncates constants without checking them
+	if c, ok := api.Compiler().ConstantValue(v); ok && c.BitLen() > nbBits {
+		panic(fmt.Sprintf("constant %s does not fit in %d bits", c.String(), nbBits))
+	}
+	bits.ToBinary(api, v, bits.WithNbDigits(nbBits))
+}
+
+// SortHint is a hint which, given the inputs (nbColumns, keys..., columns...),
+// where each column has the same length as keys, returns the keys and the
+// columns, with the records stably sorted by key.
+func SortHint(_ ecc.ID, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) == 0 || !inputs[0].IsUint64() {
+		return errors.New("missing number of columns")
+	}
+	nbColumns := int(inputs[0].Uint64())
+	inputs = inputs[1:]
+	if len(inputs) != len(results) || len(inputs)%(nbColumns+1) != 0 {
+		return errors.New("inputs and results lengths mismatch")
+	}
+	n := len(inputs) / (nbColumns + 1)
+
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		return inputs[perm[i]].Cmp(inputs[perm[j]]) < 0
+	})
+
+	for j := 0; j <= nbColumns; j++ {
+		for i, p := range perm {
+			results[j*n+i].Set(inputs[j*n+p])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package permutation_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/permutation"
+	"github.com/consensys/gnark/test"
+)
+
+const nbBits = 16
+
+type isSortedCircuit struct {
+	A [4]frontend.Variable
+}
+
+func (c *isSortedCircuit) Define(api frontend.API) error {
+	permutation.AssertIsSorted(api, c.A[:], nbBits)
+	return nil
+}
+
+func TestIsSorted(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&isSortedCircuit{}, &isSortedCircuit{A: [4]frontend.Variable{0, 3, 3, 65535}}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&isSortedCircuit{}, &isSortedCircuit{A: [4]frontend.Variable{0, 4, 3, 5}}, test.WithCurves(ecc.BN254))
+	// r-1 < 0 in the field, but is not in range
+	assert.ProverFailed(&isSortedCircuit{}, &isSortedCircuit{A: [4]frontend.Variable{-1, 0, 1, 2}}, test.WithCurves(ecc.BN254))
+}
+
+type sortCircuit struct {
+	A, Sorted [5]frontend.Variable
+}
+
+func (c *sortCircuit) Define(api frontend.API) error {
+	sorted := permutation.Sort(api, c.A[:], nbBits)
+	for i := range sorted {
+		api.AssertIsEqual(sorted[i], c.Sorted[i])
+	}
+	return nil
+}
+
+func TestSort(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&sortCircuit{}, &sortCircuit{
+		A:      [5]frontend.Variable{42, 7, 1000, 7, 0},
+		Sorted: [5]frontend.Variable{0, 7, 7, 42, 1000},
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&sortCircuit{}, &sortCircuit{
+		A:      [5]frontend.Variable{42, 7, 1000, 7, 0},
+		Sorted: [5]frontend.Variable{0, 7, 42, 7, 1000},
+	}, test.WithCurves(ecc.BN254))
+}
+
+type sortByKeyCircuit struct {
+	Keys, Values             [4]frontend.Variable
+	SortedKeys, SortedValues [4]frontend.Variable
+}
+
+func (c *sortByKeyCircuit) Define(api frontend.API) error {
+	keys, columns := permutation.SortByKey(api, c.Keys[:], [][]frontend.Variable{c.Values[:]}, nbBits)
+	for i := range keys {
+		api.AssertIsEqual(keys[i], c.SortedKeys[i])
+		api.AssertIsEqual(columns[0][i], c.SortedValues[i])
+	}
+	return nil
+}
+
+func TestSortByKey(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&sortByKeyCircuit{}, &sortByKeyCircuit{
+		Keys:         [4]frontend.Variable{3, 1, 2, 1},
+		Values:       [4]frontend.Variable{30, 10, 20, 11},
+		SortedKeys:   [4]frontend.Variable{1, 1, 2, 3},
+		SortedValues: [4]frontend.Variable{10, 11, 20, 30},
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&sortByKeyCircuit{}, &sortByKeyCircuit{
+		Keys:         [4]frontend.Variable{3, 1, 2, 1},
+		Values:       [4]frontend.Variable{30, 10, 20, 11},
+		SortedKeys:   [4]frontend.Variable{1, 1, 2, 3},
+		SortedValues: [4]frontend.Variable{10, 20, 11, 30},
+	}, test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,92 @@
+package polynomial
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fft_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/fft"
+	fft_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	fft_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/fft"
+	fft_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	fft_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/fft"
+	fft_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/fft"
+)
+
+// Domain is a multiplicative subgroup <ω> of the native field, of power of two
+// cardinality n. The roots are the same as the ones of the fft.Domain of the
+// corresponding curve in gnark-crypto, so that in-circuit NTTs match the
+// evaluations computed by the backends.
+type Domain struct {
+	Cardinality    uint64
+	CardinalityInv *big.Int
+	Generator      *big.Int // ω, of order n
+	GeneratorInv   *big.Int
+	Modulus        *big.Int // modulus of the native field
+}
+
+// NewDomain returns the domain of cardinality the smallest power of two
+// greater or equal to m, in the scalar field of curveID.
+func NewDomain(curveID ecc.ID, m uint64) (*Domain, error) {
+	if m == 0 {
+		return nil, errors.New("domain can't be empty")
+	}
+	d := &Domain{
+		CardinalityInv: new(big.Int),
+		Generator:      new(big.Int),
+		GeneratorInv:   new(big.Int),
+		Modulus:        curveID.Info().Fr.Modulus(),
+	}
+	switch curveID {
+	case ecc.BN254:
+		domain := fft_bn254.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	case ecc.BLS12_377:
+		domain := fft_bls12377.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	case ecc.BLS12_381:
+		domain := fft_bls12381.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	case ecc.BW6_761:
+		domain := fft_bw6761.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	case ecc.BLS24_315:
+		domain := fft_bls24315.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	case ecc.BW6_633:
+		domain := fft_bw6633.NewDomain(m)
+		d.Cardinality = domain.Cardinality
+		domain.CardinalityInv.ToBigIntRegular(d.CardinalityInv)
+		domain.Generator.ToBigIntRegular(d.Generator)
+		domain.GeneratorInv.ToBigIntRegular(d.GeneratorInv)
+	default:
+		return nil, errors.New("unsupported curve")
+	}
+	return d, nil
+}
+
+// roots returns [1, g, g^2, ..., g^(n-1)].
+func (d *Domain) roots(g *big.Int) []*big.Int {
+	res := make([]*big.Int, d.Cardinality)
+	res[0] = big.NewInt(1)
+	for i := 1; i < len(res); i++ {
+		res[i] = new(big.Int).Mul(res[i-1], g)
+		res[i].Mod(res[i], d.Modulus)
+	}
+	return res
+}
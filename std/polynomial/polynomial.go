@@ -0,0 +1,143 @@
+// Package polynomial provides polynomial arithmetic over the native field.
+//
+// A polynomial is given either by its coefficients in the canonical basis,
+// p[0] + p[1]·X + p[2]·X² + ..., or by its evaluations on a multiplicative
+// Domain <ω>, values[i] = p(ω^i). NTT and InverseNTT convert between the two.
+//
+// Multiplications by roots of unity are multiplications by constants, so an
+// NTT of size n costs no constraint with R1CS and O(n·log(n)) with PLONK.
+package polynomial
+
+import (
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Eval returns p(x), where p is given by its coefficients.
+func Eval(api frontend.API, p []frontend.Variable, x frontend.Variable) frontend.Variable {
+	var res frontend.Variable = 0
+	for i := len(p) - 1; i >= 0; i-- {
+		res = api.Add(api.Mul(res, x), p[i])
+	}
+	return res
+}
+
+// Interpolate returns the coefficients of the polynomial of degree lower than
+// d.Cardinality which takes the values values[i] on ω^i. len(values) must be
+// d.Cardinality.
+func Interpolate(api frontend.API, d *Domain, values []frontend.Variable) []frontend.Variable {
+	if uint64(len(values)) != d.Cardinality {
+		panic("the number of values must be the cardinality of the domain")
+	}
+	return InverseNTT(api, d, values)
+}
+
+// EvalLagrange returns p(x), where p is given by its evaluations on d, with
+// the barycentric formula
+//
+//	p(x) = (x^n - 1)/n · Σ values[i]·ω^i/(x - ω^i)
+//
+// x is typically a random challenge, and the circuit fails if x is in d.
+// len(values) must be d.Cardinality.
+func EvalLagrange(api frontend.API, d *Domain, values []frontend.Variable, x frontend.Variable) frontend.Variable {
+	if uint64(len(values)) != d.Cardinality {
+		panic("the number of values must be the cardinality of the domain")
+	}
+
+	// x^n, n being a power of two
+	xn := x
+	for i := uint64(1); i < d.Cardinality; i <<= 1 {
+		xn = api.Mul(xn, xn)
+	}
+
+	var sum frontend.Variable = 0
+	for i, w := range d.roots(d.Generator) {
+		sum = api.Add(sum, api.Div(api.Mul(values[i], w), api.Sub(x, w)))
+	}
+
+	return api.Mul(sum, api.Sub(xn, 1), d.CardinalityInv)
+}
+
+// Mul returns the coefficients of p·q. The product is computed by
+// interpolation: p and q are evaluated on a domain large enough for p·q, the
+// evaluations are multiplied pointwise and interpolated back. This costs
+// O(len(p)+len(q)) multiplications instead of len(p)·len(q).
+func Mul(api frontend.API, p, q []frontend.Variable) []frontend.Variable {
+	if len(p) == 0 || len(q) == 0 {
+		return nil
+	}
+	n := len(p) + len(q) - 1
+	d, err := NewDomain(api.Compiler().Curve(), uint64(n))
+	if err != nil {
+		panic(err)
+	}
+
+	ep := NTT(api, d, p)
+	eq := NTT(api, d, q)
+	for i := range ep {
+		ep[i] = api.Mul(ep[i], eq[i])
+	}
+
+	return InverseNTT(api, d, ep)[:n]
+}
+
+// NTT returns the evaluations of p on d, [p(1), p(ω), ..., p(ω^(n-1))].
+// len(p) must be at most d.Cardinality; p is padded with zeros.
+func NTT(api frontend.API, d *Domain, p []frontend.Variable) []frontend.Variable {
+	return ntt(api, d, p, d.Generator)
+}
+
+// InverseNTT returns the coefficients of the polynomial of degree lower than
+// d.Cardinality whose evaluations on d are values. len(values) must be at most
+// d.Cardinality; values is padded with zeros.
+func InverseNTT(api frontend.API, d *Domain, values []frontend.Variable) []frontend.Variable {
+	res := ntt(api, d, values, d.GeneratorInv)
+	for i := range res {
+		res[i] = api.Mul(res[i], d.CardinalityInv)
+	}
+	return res
+}
+
+// ntt computes the DFT of a over d, with the root of unity g, using an
+// iterative radix-2 decimation in time.
+func ntt(api frontend.API, d *Domain, a []frontend.Variable, g *big.Int) []frontend.Variable {
+	n := int(d.Cardinality)
+	if len(a) > n {
+		panic("the input is larger than the domain")
+	}
+
+	// bit-reversed copy of a, padded with zeros
+	logN := mbits.TrailingZeros(uint(n))
+	res := make([]frontend.Variable, n)
+	for i := range res {
+		res[i] = 0
+	}
+	for i := range a {
+		res[bitReverse(i, logN)] = a[i]
+	}
+
+	roots := d.roots(g)
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		stride := n / size
+		for k := 0; k < n; k += size {
+			for j := 0; j < half; j++ {
+				u := res[k+j]
+				t := api.Mul(res[k+j+half], roots[j*stride])
+				res[k+j] = api.Add(u, t)
+				res[k+j+half] = api.Sub(u, t)
+			}
+		}
+	}
+
+	return res
+}
+
+func bitReverse(i, logN int) int {
+	if logN == 0 {
+		return 0
+	}
+	return int(mbits.Reverse(uint(i)) >> (mbits.UintSize - logN))
+}
@@ -0,0 +1,87 @@
+package polynomial_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/polynomial"
+	"github.com/consensys/gnark/test"
+)
+
+type nttCircuit struct {
+	P [5]frontend.Variable
+	X frontend.Variable
+}
+
+func (c *nttCircuit) Define(api frontend.API) error {
+	d, err := polynomial.NewDomain(api.Compiler().Curve(), 8)
+	if err != nil {
+		return err
+	}
+
+	values := polynomial.NTT(api, d, c.P[:])
+	var w frontend.Variable = 1
+	for i := range values {
+		api.AssertIsEqual(values[i], polynomial.Eval(api, c.P[:], w))
+		w = api.Mul(w, d.Generator)
+	}
+
+	coeffs := polynomial.Interpolate(api, d, values)
+	for i := range coeffs {
+		if i < len(c.P) {
+			api.AssertIsEqual(coeffs[i], c.P[i])
+		} else {
+			api.AssertIsEqual(coeffs[i], 0)
+		}
+	}
+
+	api.AssertIsEqual(polynomial.EvalLagrange(api, d, values, c.X), polynomial.Eval(api, c.P[:], c.X))
+
+	return nil
+}
+
+func TestNTT(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&nttCircuit{}, &nttCircuit{
+		P: [5]frontend.Variable{3, -1, 4, 1, 5},
+		X: 42,
+	}, test.WithCurves(ecc.BN254))
+
+	// x = 1 is in the domain
+	assert.ProverFailed(&nttCircuit{}, &nttCircuit{
+		P: [5]frontend.Variable{3, -1, 4, 1, 5},
+		X: 1,
+	}, test.WithCurves(ecc.BN254))
+}
+
+type mulCircuit struct {
+	P    [3]frontend.Variable
+	Q    [2]frontend.Variable
+	Prod [4]frontend.Variable
+}
+
+func (c *mulCircuit) Define(api frontend.API) error {
+	prod := polynomial.Mul(api, c.P[:], c.Q[:])
+	for i := range prod {
+		api.AssertIsEqual(prod[i], c.Prod[i])
+	}
+	return nil
+}
+
+func TestMul(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// (1 + 2X + 3X²)(4 + 5X) = 4 + 13X + 22X² + 15X³
+	assert.ProverSucceeded(&mulCircuit{}, &mulCircuit{
+		P:    [3]frontend.Variable{1, 2, 3},
+		Q:    [2]frontend.Variable{4, 5},
+		Prod: [4]frontend.Variable{4, 13, 22, 15},
+	})
+	assert.ProverFailed(&mulCircuit{}, &mulCircuit{
+		P:    [3]frontend.Variable{1, 2, 3},
+		Q:    [2]frontend.Variable{4, 5},
+		Prod: [4]frontend.Variable{4, 13, 22, 16},
+	}, test.WithCurves(ecc.BN254))
+}
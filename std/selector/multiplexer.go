@@ -0,0 +1,155 @@
+// Package selector provides multiplexers, key-value lookups and helpers to
+// manipulate variable-length arrays of frontend.Variable.
+//
+// frontend.API offers api.Select (2 inputs) and api.Lookup2 (4 inputs). The
+// functions of this package generalize them to any number of inputs. When the
+// cheapest encoding differs between R1CS and PLONK, they pick the one
+// matching api.Compiler().Backend().
+package selector
+
+import (
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+)
+
+func init() {
+	hint.Register(KeyIndicators)
+	hint.Register(StepIndicators)
+}
+
+// Mux returns inputs[sel]. It fails if sel ∉ [0, len(inputs)).
+func Mux(api frontend.API, sel frontend.Variable, inputs ...frontend.Variable) frontend.Variable {
+	if len(inputs) == 0 {
+		panic("Mux needs at least one input")
+	}
+	if c, ok := api.Compiler().ConstantValue(sel); ok {
+		if !c.IsUint64() || c.Uint64() >= uint64(len(inputs)) {
+			panic("selector out of range")
+		}
+		return inputs[c.Uint64()]
+	}
+	if len(inputs) == 1 {
+		api.AssertIsEqual(sel, 0)
+		return inputs[0]
+	}
+	// a binary tree of api.Select is cheaper than a dot product with
+	// Decoder(api, len(inputs), sel), for both R1CS and PLONK, even when
+	// the inputs are constants.
+	return BinaryMux(api, selectorBits(api, sel, len(inputs)), inputs)
+}
+
+// BinaryMux returns inputs[sel] where sel is given by its little-endian bits.
+// The bits are expected to be constrained to be boolean. If len(inputs) is not
+// a power of two, then the selector must be lower than len(inputs) for the
+// result to be meaningful.
+func BinaryMux(api frontend.API, selBits []frontend.Variable, inputs []frontend.Variable) frontend.Variable {
+	if len(inputs) == 0 {
+		panic("BinaryMux needs at least one input")
+	}
+	if len(inputs) > 1<<len(selBits) {
+		panic("not enough selector bits")
+	}
+
+	// at each level, pair the values whose selectors differ by the current bit
+	level := inputs
+	for _, b := range selBits {
+		if len(level) == 1 {
+			break
+		}
+		next := make([]frontend.Variable, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = api.Select(b, level[2*i+1], level[2*i])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// Map returns values[i] where keys[i] == key. The keys must be distinct, and
+// the circuit fails if key is not one of them.
+func Map(api frontend.API, key frontend.Variable, keys, values []frontend.Variable) frontend.Variable {
+	if len(keys) != len(values) {
+		panic("keys and values must have the same length")
+	}
+	if len(keys) == 0 {
+		panic("Map needs at least one key")
+	}
+	return dotProduct(api, KeyDecoder(api, key, keys), values)
+}
+
+// Decoder returns a vector of n elements which are all zero except the
+// element at index sel, which is one. It fails if sel ∉ [0, n).
+func Decoder(api frontend.API, n int, sel frontend.Variable) []frontend.Variable {
+	keys := make([]frontend.Variable, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	return KeyDecoder(api, sel, keys)
+}
+
+// KeyDecoder returns a vector with the same length as keys, whose elements are
+// all zero except the element at the index i where keys[i] == key, which is
+// one. The keys must be distinct, and the circuit fails if key is not one of
+// them.
+func KeyDecoder(api frontend.API, key frontend.Variable, keys []frontend.Variable) []frontend.Variable {
+	res, err := api.Compiler().NewHint(KeyIndicators, len(keys), append([]frontend.Variable{key}, keys...)...)
+	if err != nil {
+		panic(err)
+	}
+
+	// res[i] * (key - keys[i]) == 0 forces res[i] to be 0 unless key == keys[i],
+	// and Σ res[i] == 1 forces the remaining indicator to be 1.
+	var sum frontend.Variable = 0
+	for i := range keys {
+		api.AssertIsEqual(api.Mul(res[i], api.Sub(key, keys[i])), 0)
+		sum = api.Add(sum, res[i])
+	}
+	api.AssertIsEqual(sum, 1)
+
+	return res
+}
+
+// selectorBits returns the little-endian bits of sel, constrained so that
+// sel ∈ [0, n).
+func selectorBits(api frontend.API, sel frontend.Variable, n int) []frontend.Variable {
+	nbBits := mbits.Len(uint(n - 1))
+	b := toBinary(api, sel, nbBits)
+	if n != 1<<nbBits {
+		// n-1-sel ∈ [0, 2^nbBits)
+		toBinary(api, api.Sub(n-1, sel), nbBits)
+	}
+	return b
+}
+
+func dotProduct(api frontend.API, a, b []frontend.Variable) frontend.Variable {
+	var res frontend.Variable = 0
+	for i := range a {
+		res = api.Add(res, api.Mul(a[i], b[i]))
+	}
+	return res
+}
+
+// KeyIndicators is a hint which, given the inputs (key, keys...), returns the
+// indicators [key == keys[i]] for each key. Only the first matching key is
+// indicated.
+func KeyIndicators(_ ecc.ID, inputs []*big.Int, results []*big.Int) error {
+	found := false
+	for i := range results {
+		if !found && inputs[0].Cmp(inputs[i+1]) == 0 {
+			results[i].SetUint64(1)
+			found = true
+		} else {
+			results[i].SetUint64(0)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/selector"
+	"github.com/consensys/gnark/test"
+)
+
+type muxCircuit struct {
+	Sel    frontend.Variable
+	Inputs [5]frontend.Variable
+	Res    frontend.Variable
+}
+
+func (c *muxCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(selector.Mux(api, c.Sel, c.Inputs[:]...), c.Res)
+	return nil
+}
+
+func TestMux(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	inputs := [5]frontend.Variable{10, 11, 12, 13, 14}
+	for i := 0; i < len(inputs); i++ {
+		assert.ProverSucceeded(&muxCircuit{}, &muxCircuit{Sel: i, Inputs: inputs, Res: 10 + i}, test.WithCurves(ecc.BN254))
+	}
+	assert.ProverFailed(&muxCircuit{}, &muxCircuit{Sel: 1, Inputs: inputs, Res: 12}, test.WithCurves(ecc.BN254))
+	// 5 and 7 have 3 bits, but are out of range
+	assert.ProverFailed(&muxCircuit{}, &muxCircuit{Sel: 5, Inputs: inputs, Res: 0}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&muxCircuit{}, &muxCircuit{Sel: 7, Inputs: inputs, Res: 0}, test.WithCurves(ecc.BN254))
+}
+
+type mapCircuit struct {
+	Key    frontend.Variable
+	Keys   [4]frontend.Variable
+	Values [4]frontend.Variable
+	Res    frontend.Variable
+}
+
+func (c *mapCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(selector.Map(api, c.Key, c.Keys[:], c.Values[:]), c.Res)
+	return nil
+}
+
+func TestMap(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	keys := [4]frontend.Variable{42, 7, 1000, 3}
+	values := [4]frontend.Variable{1, 2, 3, 4}
+	assert.ProverSucceeded(&mapCircuit{}, &mapCircuit{Key: 1000, Keys: keys, Values: values, Res: 3}, test.WithCurves(ecc.BN254))
+	assert.ProverSucceeded(&mapCircuit{}, &mapCircuit{Key: 42, Keys: keys, Values: values, Res: 1}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&mapCircuit{}, &mapCircuit{Key: 7, Keys: keys, Values: values, Res: 1}, test.WithCurves(ecc.BN254))
+	// missing key
+	assert.ProverFailed(&mapCircuit{}, &mapCircuit{Key: 8, Keys: keys, Values: values, Res: 0}, test.WithCurves(ecc.BN254))
+}
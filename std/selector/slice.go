@@ -0,0 +1,156 @@
+package selector
+
+import (
+	"fmt"
+	"math/big"
+	mbits "math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Partition returns a copy of input where the elements on one side of pivot
+// are set to zero. If rightSide is false, out[i] = input[i] for i < pivot and
+// 0 otherwise. If rightSide is true, out[i] = input[i] for i >= pivot and 0
+// otherwise. It fails if pivot ∉ [0, len(input)].
+func Partition(api frontend.API, pivot frontend.Variable, rightSide bool, input []frontend.Variable) []frontend.Variable {
+	mask := stepMask(api, len(input), pivot)
+	out := make([]frontend.Variable, len(input))
+	for i := range input {
+		if rightSide {
+			out[i] = api.Mul(input[i], mask[i])
+		} else {
+			out[i] = api.Sub(input[i], api.Mul(input[i], mask[i]))
+		}
+	}
+	return out
+}
+
+// Slice returns input[start:end], zero-padded to len(input), so that the
+// result is a variable-length array of length end-start. It fails unless
+// 0 <= start <= end <= len(input).
+func Slice(api frontend.API, start, end frontend.Variable, input []frontend.Variable) []frontend.Variable {
+	// zero out input[end:], this ensures end <= len(input)
+	out := Partition(api, end, false, input)
+
+	// ensure start <= end; start >= 0 is ensured by the shift decomposition
+	toBinary(api, api.Sub(end, start), mbits.Len(uint(len(input))))
+
+	return shiftLeft(api, out, start)
+}
+
+// Concat concatenates the variable-length arrays a[:aLen] and b[:bLen]. It
+// returns an array of len(a)+len(b) elements, zero-padded after the
+// concatenation, and its length aLen+bLen. It fails if aLen ∉ [0, len(a)] or
+// bLen ∉ [0, len(b)].
+func Concat(api frontend.API, a []frontend.Variable, aLen frontend.Variable, b []frontend.Variable, bLen frontend.Variable) ([]frontend.Variable, frontend.Variable) {
+	a = Partition(api, aLen, false, a)
+	b = Partition(api, bLen, false, b)
+
+	// b is moved to out[aLen:] by placing it at out[len(a):] and shifting it by
+	// len(a)-aLen positions to the left
+	shifted := make([]frontend.Variable, len(a)+len(b))
+	for i := range shifted {
+		if i < len(a) {
+			shifted[i] = 0
+		} else {
+			shifted[i] = b[i-len(a)]
+		}
+	}
+	shifted = shiftLeft(api, shifted, api.Sub(len(a), aLen))
+
+	out := make([]frontend.Variable, len(shifted))
+	for i := range out {
+		if i < len(a) {
+			out[i] = api.Add(a[i], shifted[i])
+		} else {
+			out[i] = shifted[i]
+		}
+	}
+
+	return out, api.Add(aLen, bLen)
+}
+
+// shiftLeft returns input shifted by amount positions towards index 0, filling
+// with zeros. It uses a barrel shifter over the bits of amount, which must be
+// in [0, len(input)].
+func shiftLeft(api frontend.API, input []frontend.Variable, amount frontend.Variable) []frontend.Variable {
+	b := toBinary(api, amount, mbits.Len(uint(len(input))))
+
+	out := input
+	for j := range b {
+		step := 1 << j
+		next := make([]frontend.Variable, len(out))
+		for i := range next {
+			var shifted frontend.Variable = 0
+			if i+step < len(out) {
+				shifted = out[i+step]
+			}
+			next[i] = api.Select(b[j], shifted, out[i])
+		}
+		out = next
+	}
+	return out
+}
+
+// toBinary constrains v to be in [0, 2^nbBits) and returns its little-endian
+// bits.
+func toBinary(api frontend.API, v frontend.Variable, nbBits int) []frontend.Variable {
+	// bits.ToBinary truncates constants without checking them
+	if c, ok := api.Compiler().ConstantValue(v); ok && c.BitLen() > nbBits {
+		panic(fmt.Sprintf("constant %s does not fit in %d bits", c.String(), nbBits))
+	}
+	return bits.ToBinary(api, v, bits.WithNbDigits(nbBits))
+}
+
+// stepMask returns a vector of n elements with mask[i] = 1 if i >= pivot and
+// 0 otherwise. It fails if pivot ∉ [0, n].
+func stepMask(api frontend.API, n int, pivot frontend.Variable) []frontend.Variable {
+	if api.Compiler().Backend() == backend.GROTH16 {
+		// with R1CS, the prefix sums of a one-hot vector are linear
+		// expressions, which come for free (2n+3 constraints).
+		d := Decoder(api, n+1, pivot)
+		mask := make([]frontend.Variable, n)
+		var acc frontend.Variable = 0
+		for i := range mask {
+			acc = api.Add(acc, d[i])
+			mask[i] = acc
+		}
+		return mask
+	}
+
+	// with PLONK, each addition costs a constraint; constraining a
+	// boolean monotonic vector directly is cheaper (4n-1 constraints).
+	mask, err := api.Compiler().NewHint(StepIndicators, n, pivot)
+	if err != nil {
+		panic(err)
+	}
+	var sum frontend.Variable = 0
+	for i := range mask {
+		api.AssertIsBoolean(mask[i])
+		if i+1 < n {
+			// mask[i] == 1 → mask[i+1] == 1
+			api.AssertIsEqual(api.Mul(mask[i], mask[i+1]), mask[i])
+		}
+		sum = api.Add(sum, mask[i])
+	}
+	// the number of zeros is the pivot
+	api.AssertIsEqual(api.Sub(n, sum), pivot)
+
+	return mask
+}
+
+// StepIndicators is a hint which, given the input pivot, returns the
+// indicators [i >= pivot] for i ∈ [0, len(results)).
+func StepIndicators(_ ecc.ID, inputs []*big.Int, results []*big.Int) error {
+	for i := range results {
+		if inputs[0].Cmp(big.NewInt(int64(i))) <= 0 {
+			results[i].SetUint64(1)
+		} else {
+			results[i].SetUint64(0)
+		}
+	}
+	return nil
+}
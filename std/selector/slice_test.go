@@ -0,0 +1,116 @@
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/selector"
+	"github.com/consensys/gnark/test"
+)
+
+type partitionCircuit struct {
+	Pivot       frontend.Variable
+	Input       [6]frontend.Variable
+	Left, Right [6]frontend.Variable
+}
+
+func (c *partitionCircuit) Define(api frontend.API) error {
+	left := selector.Partition(api, c.Pivot, false, c.Input[:])
+	right := selector.Partition(api, c.Pivot, true, c.Input[:])
+	for i := range c.Input {
+		api.AssertIsEqual(left[i], c.Left[i])
+		api.AssertIsEqual(right[i], c.Right[i])
+	}
+	return nil
+}
+
+func TestPartition(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	input := [6]frontend.Variable{1, 2, 3, 4, 5, 6}
+	assert.ProverSucceeded(&partitionCircuit{}, &partitionCircuit{
+		Pivot: 2,
+		Input: input,
+		Left:  [6]frontend.Variable{1, 2, 0, 0, 0, 0},
+		Right: [6]frontend.Variable{0, 0, 3, 4, 5, 6},
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverSucceeded(&partitionCircuit{}, &partitionCircuit{
+		Pivot: 6,
+		Input: input,
+		Left:  input,
+		Right: [6]frontend.Variable{0, 0, 0, 0, 0, 0},
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&partitionCircuit{}, &partitionCircuit{
+		Pivot: 7,
+		Input: input,
+		Left:  input,
+		Right: [6]frontend.Variable{0, 0, 0, 0, 0, 0},
+	}, test.WithCurves(ecc.BN254))
+}
+
+type sliceCircuit struct {
+	Start, End frontend.Variable
+	Input      [5]frontend.Variable
+	Res        [5]frontend.Variable
+}
+
+func (c *sliceCircuit) Define(api frontend.API) error {
+	res := selector.Slice(api, c.Start, c.End, c.Input[:])
+	for i := range res {
+		api.AssertIsEqual(res[i], c.Res[i])
+	}
+	return nil
+}
+
+func TestSlice(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	input := [5]frontend.Variable{1, 2, 3, 4, 5}
+	assert.ProverSucceeded(&sliceCircuit{}, &sliceCircuit{Start: 1, End: 4, Input: input, Res: [5]frontend.Variable{2, 3, 4, 0, 0}}, test.WithCurves(ecc.BN254))
+	assert.ProverSucceeded(&sliceCircuit{}, &sliceCircuit{Start: 0, End: 5, Input: input, Res: input}, test.WithCurves(ecc.BN254))
+	assert.ProverSucceeded(&sliceCircuit{}, &sliceCircuit{Start: 3, End: 3, Input: input, Res: [5]frontend.Variable{0, 0, 0, 0, 0}}, test.WithCurves(ecc.BN254))
+	// start > end
+	assert.ProverFailed(&sliceCircuit{}, &sliceCircuit{Start: 4, End: 3, Input: input, Res: [5]frontend.Variable{0, 0, 0, 0, 0}}, test.WithCurves(ecc.BN254))
+}
+
+type concatCircuit struct {
+	A      [3]frontend.Variable
+	ALen   frontend.Variable
+	B      [4]frontend.Variable
+	BLen   frontend.Variable
+	Res    [7]frontend.Variable
+	ResLen frontend.Variable
+}
+
+func (c *concatCircuit) Define(api frontend.API) error {
+	res, resLen := selector.Concat(api, c.A[:], c.ALen, c.B[:], c.BLen)
+	for i := range res {
+		api.AssertIsEqual(res[i], c.Res[i])
+	}
+	api.AssertIsEqual(resLen, c.ResLen)
+	return nil
+}
+
+func TestConcat(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// trailing garbage after aLen and bLen is ignored
+	a := [3]frontend.Variable{1, 2, 9}
+	b := [4]frontend.Variable{3, 4, 5, 9}
+	assert.ProverSucceeded(&concatCircuit{}, &concatCircuit{
+		A: a, ALen: 2, B: b, BLen: 3,
+		Res:    [7]frontend.Variable{1, 2, 3, 4, 5, 0, 0},
+		ResLen: 5,
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverSucceeded(&concatCircuit{}, &concatCircuit{
+		A: a, ALen: 0, B: b, BLen: 4,
+		Res:    [7]frontend.Variable{3, 4, 5, 9, 0, 0, 0},
+		ResLen: 4,
+	}, test.WithCurves(ecc.BN254))
+	assert.ProverFailed(&concatCircuit{}, &concatCircuit{
+		A: a, ALen: 4, B: b, BLen: 3,
+		Res:    [7]frontend.Variable{1, 2, 9, 0, 3, 4, 5},
+		ResLen: 7,
+	}, test.WithCurves(ecc.BN254))
+}
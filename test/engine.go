@@ -32,7 +32,10 @@ import (
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/internal/utils"
+	"github.com/rs/zerolog"
 )
 
 // engine implements frontend.API
@@ -75,6 +78,12 @@ func IsSolved(circuit, witness frontend.Circuit, curveID ecc.ID, b backend.ID, o
 	// clone the circuit
 	c := shallowClone(circuit)
 
+	// the outputs which are not assigned are computed by the solver
+	witness, err = solveOutputs(circuit, witness, curveID, opts...)
+	if err != nil {
+		return err
+	}
+
 	// set the witness values
 	copyWitness(c, witness)
 
@@ -328,6 +337,43 @@ func (e *engine) Println(a ...frontend.Variable) {
 	fmt.Println(sbb.String())
 }
 
+// Printf formats as the solver does and logs to the CircuitLogger of the prover
+// options, with the same structured fields.
+func (e *engine) Printf(format string, a ...frontend.Variable) {
+	args := make([]interface{}, len(a))
+	for i := range a {
+		args[i] = a[i]
+	}
+	tokens, err := compiled.ExpandPrintf(format, args, tVariable)
+	if err != nil {
+		panic(err)
+	}
+	if e.opt.CircuitLogger.GetLevel() == zerolog.Disabled {
+		return
+	}
+
+	event := e.opt.CircuitLogger.Debug()
+
+	// prefix log line with file.go:line
+	if _, file, line, ok := runtime.Caller(1); ok {
+		event = event.Str(zerolog.CallerFieldName, fmt.Sprintf("%s:%d", filepath.Base(file), line))
+	}
+
+	var sbb strings.Builder
+	var values []interface{}
+	for _, t := range tokens {
+		if t.Directive == "" {
+			sbb.WriteString(t.Text)
+			continue
+		}
+		v := e.toBigInt(t.Value)
+		sbb.WriteString(t.Directive)
+		values = append(values, &v)
+		event = event.Str(t.Name, v.String())
+	}
+	event.Msg(fmt.Sprintf(sbb.String(), values...))
+}
+
 func (e *engine) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.Variable) ([]frontend.Variable, error) {
 
 	if nbOutputs <= 0 {
@@ -359,6 +405,65 @@ func (e *engine) NewHint(f hint.Function, nbOutputs int, inputs ...frontend.Vari
 	return out, nil
 }
 
+func (e *engine) NewRichHint(f hint.RichFunction, nbOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	if nbOutputs <= 0 {
+		return nil, fmt.Errorf("hint function must return at least one output")
+	}
+	res, n := e.callRichHint(f, nbOutputs, params, inputs)
+	if n != nbOutputs {
+		panic(fmt.Sprintf("NewRichHint: hint function set %d outputs, expected %d", n, nbOutputs))
+	}
+	return res, nil
+}
+
+func (e *engine) NewVariableHint(f hint.RichFunction, maxOutputs int, params []hint.Param, inputs ...frontend.Variable) ([]frontend.Variable, frontend.Variable, error) {
+	if maxOutputs <= 0 {
+		return nil, nil, fmt.Errorf("hint function must return at least one output")
+	}
+	res, n := e.callRichHint(f, maxOutputs, params, inputs)
+	if n < 0 || n > maxOutputs {
+		panic(fmt.Sprintf("NewVariableHint: hint function set %d outputs, expected at most %d", n, maxOutputs))
+	}
+	for i := n; i < maxOutputs; i++ {
+		res[i] = new(big.Int)
+	}
+	return res, big.NewInt(int64(n)), nil
+}
+
+// callRichHint calls f as the solver does and returns its outputs and the
+// number of outputs it set
+func (e *engine) callRichHint(f hint.RichFunction, nbOutputs int, params []hint.Param, inputs []frontend.Variable) ([]frontend.Variable, int) {
+	in := make([]*big.Int, len(inputs))
+	for i := 0; i < len(inputs); i++ {
+		v := e.toBigInt(inputs[i])
+		in[i] = &v
+	}
+	res := make([]*big.Int, nbOutputs)
+	for i := range res {
+		res[i] = new(big.Int)
+	}
+
+	ctx := &hint.Context{
+		CurveID: e.curveID,
+		Modulus: e.modulus(),
+		Params:  params,
+		Value:   e.opt.HintContext,
+	}
+	n, err := f(ctx, in, res)
+	if err != nil {
+		panic("NewHint: " + err.Error())
+	}
+
+	out := make([]frontend.Variable, len(res))
+	for i := range res {
+		out[i] = res[i].Mod(res[i], e.modulus())
+	}
+	return out, n
+}
+
+// Label is a no-op: the test engine has no wires
+func (e *engine) Label(v frontend.Variable, name string) {}
+
 // IsConstant returns true if v is a constant known at compile time
 func (e *engine) IsConstant(v frontend.Variable) bool {
 	// TODO @gbotrel this is a problem. if a circuit component has 2 code path depending
@@ -444,13 +549,65 @@ func shallowClone(circuit frontend.Circuit) frontend.Circuit {
 	return circuitCopy
 }
 
+// solveOutputs returns witness if all its outputs (see schema.Output) are
+// assigned. Otherwise, it returns a copy of witness whose unassigned outputs
+// are set as the solver of the R1CS of circuit sets them in the full witness.
+func solveOutputs(circuit, witness frontend.Circuit, curveID ecc.ID, opts ...backend.ProverOption) (frontend.Circuit, error) {
+	unassigned := false
+	_, _ = schema.Parse(witness, tVariable, func(visibility schema.Visibility, _ string, tInput reflect.Value) error {
+		unassigned = unassigned || (visibility == schema.Output && tInput.IsNil())
+		return nil
+	})
+	if !unassigned {
+		return witness, nil
+	}
+
+	ccs, err := frontend.Compile(curveID, r1cs.NewBuilder, shallowClone(circuit))
+	if err != nil {
+		return nil, err
+	}
+	w, err := frontend.NewWitness(witness, curveID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ccs.IsSolved(w, opts...); err != nil {
+		return nil, err
+	}
+
+	// the public witness holds the public variables and the outputs, in the
+	// order of the schema, after their number
+	publicWitness, err := w.Public()
+	if err != nil {
+		return nil, err
+	}
+	data, err := publicWitness.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n8 := curveID.Info().Fr.Bytes
+	data = data[4:]
+
+	res := shallowClone(witness)
+	_, _ = schema.Parse(res, tVariable, func(visibility schema.Visibility, _ string, tInput reflect.Value) error {
+		if !visibility.IsPublic() {
+			return nil
+		}
+		if visibility == schema.Output && tInput.IsNil() {
+			tInput.Set(reflect.ValueOf(new(big.Int).SetBytes(data[:n8])))
+		}
+		data = data[n8:]
+		return nil
+	})
+	return res, nil
+}
+
 func copyWitness(to, from frontend.Circuit) {
 	var wValues []interface{}
 
 	var collectHandler schema.LeafHandler = func(visibility schema.Visibility, name string, tInput reflect.Value) error {
 		v := tInput.Interface().(frontend.Variable)
 
-		if visibility == schema.Secret || visibility == schema.Public {
+		if visibility == schema.Secret || visibility.IsPublic() {
 			if v == nil {
 				return fmt.Errorf("when parsing variable %s: missing assignment", name)
 			}
@@ -464,7 +621,7 @@ func copyWitness(to, from frontend.Circuit) {
 
 	i := 0
 	var setHandler schema.LeafHandler = func(visibility schema.Visibility, name string, tInput reflect.Value) error {
-		if visibility == schema.Secret || visibility == schema.Public {
+		if visibility == schema.Secret || visibility.IsPublic() {
 			tInput.Set(reflect.ValueOf((wValues[i])))
 			i++
 		}
@@ -478,3 +635,17 @@ func copyWitness(to, from frontend.Circuit) {
 func (e *engine) Compiler() frontend.Compiler {
 	return e
 }
+
+// Call runs c.Define on inputs; the test engine has no constraints to reuse.
+func (e *engine) Call(c frontend.Component, inputs ...frontend.Variable) []frontend.Variable {
+	return c.Define(e, inputs...)
+}
+
+// CallConcurrently runs the Define methods of the calls, one after the other.
+func (e *engine) CallConcurrently(calls ...frontend.ComponentCall) [][]frontend.Variable {
+	res := make([][]frontend.Variable, len(calls))
+	for i, call := range calls {
+		res[i] = call.Component.Define(e, call.Inputs...)
+	}
+	return res
+}
@@ -114,9 +114,13 @@ func randomFiller(w frontend.Circuit, curve ecc.ID) {
 
 func fill(w frontend.Circuit, nextValue func() interface{}) {
 	var setHandler schema.LeafHandler = func(visibility schema.Visibility, name string, tInput reflect.Value) error {
-		if visibility == schema.Secret || visibility == schema.Public {
+		switch visibility {
+		case schema.Secret, schema.Public:
 			v := nextValue()
 			tInput.Set(reflect.ValueOf((v)))
+		case schema.Output:
+			// computed by the solvers
+			tInput.Set(reflect.Zero(tInput.Type()))
 		}
 		return nil
 	}
@@ -0,0 +1,473 @@
+/*
+Copyright © 2022 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	fr_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fr_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/analysis"
+	"github.com/consensys/gnark/frontend/compiled"
+	backend_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	witness_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/witness"
+	backend_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	witness_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	backend_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	witness_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/witness"
+	backend_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+	backend_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	witness_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/witness"
+	backend_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+	witness_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/witness"
+)
+
+// nbRandomMutations is the number of random values tried for each wire
+const nbRandomMutations = 2
+
+// Underconstrained fails the test if an internal wire of the solution of
+// validAssignment can take another value without breaking a constraint.
+//
+// The circuit is solved, then each hint output and internal wire of the
+// solution is perturbed in turn: random values, boundary values (0, 1, -1,
+// ±1/2), the opposite of the value (as the other square root) and its
+// neighbours are tried, and a perturbation satisfying all the constraints
+// referencing the wire is reported with the debug info of the wire. One wire
+// is perturbed at a time: a wire whose value is copied to another wire by a
+// constraint is not reported, nor are the hint outputs which are only free
+// together, such as a quotient and a remainder.
+//
+// Such structured alternatives are tried with WithAlternativeHint: the circuit
+// is solved again with the alternative hint function, which recomputes the
+// wires depending on its outputs, and its outputs are reported if they differ
+// and the solution is valid. See also analysis.Underconstrained.
+//
+// By default, this tests on all curves and proving schemes supported by gnark. See available TestingOption.
+func (assert *Assert) Underconstrained(circuit frontend.Circuit, validAssignment frontend.Circuit, opts ...TestingOption) {
+	opt := assert.options(opts...)
+
+	for _, curve := range opt.curves {
+		for _, b := range opt.backends {
+			curve := curve
+			b := b
+			assert.Run(func(assert *Assert) {
+				assert.underconstrained(circuit, validAssignment, b, curve, &opt)
+			}, curve.String(), b.String())
+		}
+	}
+}
+
+func (assert *Assert) underconstrained(circuit frontend.Circuit, validAssignment frontend.Circuit, b backend.ID, curve ecc.ID, opt *testingConfig) {
+	// parse assignment
+	validWitness, err := frontend.NewWitness(validAssignment, curve)
+	assert.NoError(err, "can't parse valid assignment")
+
+	checkError := func(err error) { assert.checkError(err, b, curve, validWitness) }
+
+	// 1- compile the circuit
+	ccs, err := assert.compile(circuit, curve, b, opt.compileOpts)
+	checkError(err)
+
+	// 2- solve it and mutate the solution
+	mutations, err := mutate(ccs, validWitness, opt.alternativeHints, opt.proverOpts...)
+	checkError(err)
+	if len(mutations) == 0 {
+		return
+	}
+
+	wireIDs := make([]int, len(mutations))
+	for i, m := range mutations {
+		wireIDs[i] = m.wireID
+	}
+	wires, err := analysis.Describe(ccs, wireIDs...)
+	checkError(err)
+
+	var sbb strings.Builder
+	fmt.Fprintf(&sbb, "%s(%s): %d wire(s) can be mutated without breaking a constraint", b.String(), curve.String(), len(mutations))
+	for i, m := range mutations {
+		fmt.Fprintf(&sbb, "\n%s\nvalue %s can be replaced by %s", strings.TrimSpace(wires[i].String()), m.value.String(), m.mutated.String())
+	}
+	assert.FailNow(sbb.String())
+}
+
+// mutation is another value of a wire of the solution, with which the
+// constraints are still satisfied
+type mutation struct {
+	wireID         int
+	value, mutated big.Int
+}
+
+// solution is the solution of a constraint system, in regular form
+type solution struct {
+	q        *big.Int
+	nbInputs int
+	values   []big.Int
+	coeffs   []big.Int
+
+	nbConstraints int
+	terms         func(cID int) []compiled.Term
+	check         func(cID int) bool
+
+	hints map[int]*compiled.Hint // hint of the output wires
+}
+
+// mutate solves ccs with the witness, then returns the mutations of its
+// internal wires which satisfy the constraints, sorted by wire ID: the
+// perturbations of a single wire, and the outputs of the hints which differ
+// when the circuit is solved with an alternative hint function
+func mutate(ccs frontend.CompiledConstraintSystem, fullWitness *witness.Witness, alternativeHints map[hint.ID][]hint.Function, opts ...backend.ProverOption) ([]mutation, error) {
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s, err := solve(ccs, fullWitness, opt)
+	if err != nil {
+		return nil, err
+	}
+	res := s.mutate()
+
+	mutated := make(map[int]bool, len(res))
+	for _, m := range res {
+		mutated[m.wireID] = true
+	}
+	for id, alternatives := range alternativeHints {
+		for _, alternative := range alternatives {
+			opt, err := backend.NewProverConfig(opts...)
+			if err != nil {
+				return nil, err
+			}
+			opt.HintFunctions[id] = alternative
+			alt, err := solve(ccs, fullWitness, opt)
+			if err != nil {
+				// the alternative results break a constraint
+				continue
+			}
+			for wID, h := range s.hints {
+				if h.ID != id || mutated[wID] || alt.values[wID].Cmp(&s.values[wID]) == 0 {
+					continue
+				}
+				mutated[wID] = true
+				m := mutation{wireID: wID}
+				m.value.Set(&s.values[wID])
+				m.mutated.Set(&alt.values[wID])
+				res = append(res, m)
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].wireID < res[j].wireID })
+	return res, nil
+}
+
+// solve solves ccs with the witness, and returns its solution
+func solve(ccs frontend.CompiledConstraintSystem, fullWitness *witness.Witness, opt backend.ProverConfig) (*solution, error) {
+	var s *solution
+	switch c := ccs.(type) {
+	case *backend_bls12377.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bls12377.Element, n), make([]fr_bls12377.Element, n), make([]fr_bls12377.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bls12381.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bls12381.Element, n), make([]fr_bls12381.Element, n), make([]fr_bls12381.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bn254.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bn254.Element, n), make([]fr_bn254.Element, n), make([]fr_bn254.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bw6761.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bw6761.Element, n), make([]fr_bw6761.Element, n), make([]fr_bw6761.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bls24315.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bls24315.Element, n), make([]fr_bls24315.Element, n), make([]fr_bls24315.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bw6633.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		n := len(c.Constraints)
+		v, err := c.Solve(*w, make([]fr_bw6633.Element, n), make([]fr_bw6633.Element, n), make([]fr_bw6633.Element, n), opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newR1CSSolution(&c.R1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bls12377.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bls12381.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bn254.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bw6761.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bls24315.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	case *backend_bw6633.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		v, err := c.Solve(*w, opt)
+		if err != nil {
+			return nil, err
+		}
+		s = newSparseR1CSSolution(&c.SparseR1CS, toBigInts(len(c.Coefficients), func(i int, r *big.Int) { c.Coefficients[i].ToBigIntRegular(r) }), toBigInts(len(v), func(i int, r *big.Int) { v[i].ToBigIntRegular(r) }))
+	default:
+		return nil, errors.New("unsupported constraint system")
+	}
+
+	return s, nil
+}
+
+// toBigInts returns the n elements of a vector, in regular form
+func toBigInts(n int, get func(i int, r *big.Int)) []big.Int {
+	res := make([]big.Int, n)
+	for i := range res {
+		get(i, &res[i])
+	}
+	return res
+}
+
+func newR1CSSolution(cs *compiled.R1CS, coeffs, values []big.Int) *solution {
+	s := &solution{
+		q:             cs.CurveID.Info().Fr.Modulus(),
+		nbInputs:      cs.NbPublicVariables + cs.NbSecretVariables,
+		values:        values,
+		coeffs:        coeffs,
+		nbConstraints: len(cs.Constraints),
+		hints:         cs.MHints,
+	}
+	s.terms = func(cID int) []compiled.Term {
+		c := &cs.Constraints[cID]
+		res := make([]compiled.Term, 0, len(c.L)+len(c.R)+len(c.O))
+		res = append(res, c.L...)
+		res = append(res, c.R...)
+		return append(res, c.O...)
+	}
+	s.check = func(cID int) bool {
+		// L·R == O
+		c := &cs.Constraints[cID]
+		var l, r, o big.Int
+		s.eval(&l, c.L...)
+		s.eval(&r, c.R...)
+		s.eval(&o, c.O...)
+		l.Mul(&l, &r).Sub(&l, &o)
+		return l.Mod(&l, s.q).Sign() == 0
+	}
+	return s
+}
+
+func newSparseR1CSSolution(cs *compiled.SparseR1CS, coeffs, values []big.Int) *solution {
+	s := &solution{
+		q:             cs.CurveID.Info().Fr.Modulus(),
+		nbInputs:      cs.NbPublicVariables + cs.NbSecretVariables,
+		values:        values,
+		coeffs:        coeffs,
+		nbConstraints: len(cs.Constraints),
+		hints:         cs.MHints,
+	}
+	s.terms = func(cID int) []compiled.Term {
+		c := &cs.Constraints[cID]
+		return []compiled.Term{c.L, c.R, c.O, c.M[0], c.M[1]}
+	}
+	s.check = func(cID int) bool {
+		// qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0
+		c := &cs.Constraints[cID]
+		var lro, m0, m1 big.Int
+		s.eval(&lro, c.L, c.R, c.O)
+		s.eval(&m0, c.M[0])
+		s.eval(&m1, c.M[1])
+		m0.Mul(&m0, &m1).Add(&m0, &lro).Add(&m0, &s.coeffs[c.K])
+		return m0.Mod(&m0, s.q).Sign() == 0
+	}
+	return s
+}
+
+// eval sets res to the sum of the terms
+func (s *solution) eval(res *big.Int, terms ...compiled.Term) {
+	res.SetUint64(0)
+	var t big.Int
+	for _, term := range terms {
+		cID, vID, _ := term.Unpack()
+		if cID == compiled.CoeffIdZero {
+			continue
+		}
+		res.Add(res, t.Mul(&s.coeffs[cID], &s.values[vID]))
+	}
+}
+
+// mutate returns the perturbations of the internal wires satisfying the
+// constraints which reference them
+func (s *solution) mutate() []mutation {
+	occurrences := make([][]int, len(s.values))
+	for cID := 0; cID < s.nbConstraints; cID++ {
+		for _, t := range s.terms(cID) {
+			if t.CoeffID() == compiled.CoeffIdZero {
+				continue
+			}
+			vID := t.WireID()
+			if occ := occurrences[vID]; len(occ) == 0 || occ[len(occ)-1] != cID {
+				occurrences[vID] = append(occ, cID)
+			}
+		}
+	}
+
+	var res []mutation
+	rnd := rand.New(rand.NewSource(1)) //#nosec G404 the mutations don't need to be unpredictable
+	for wID := s.nbInputs; wID < len(s.values); wID++ {
+		var m mutation
+		m.wireID = wID
+		m.value.Set(&s.values[wID])
+		for _, v := range s.candidates(&m.value, rnd) {
+			s.values[wID].Set(v)
+			satisfied := true
+			for _, cID := range occurrences[wID] {
+				if satisfied = s.check(cID); !satisfied {
+					break
+				}
+			}
+			if satisfied {
+				m.mutated.Set(v)
+				res = append(res, m)
+				break
+			}
+		}
+		s.values[wID].Set(&m.value)
+	}
+	return res
+}
+
+// candidates returns the values tried for a wire of value v
+func (s *solution) candidates(v *big.Int, rnd *rand.Rand) []*big.Int {
+	one := big.NewInt(1)
+	half := new(big.Int).Rsh(s.q, 1)
+	values := []*big.Int{
+		// perturbations of the value
+		new(big.Int).Neg(v),
+		new(big.Int).Add(v, one),
+		new(big.Int).Sub(v, one),
+		// boundary values
+		new(big.Int),
+		new(big.Int).Set(one),
+		new(big.Int).Sub(s.q, one),
+		half,
+		new(big.Int).Add(half, one),
+	}
+	for i := 0; i < nbRandomMutations; i++ {
+		values = append(values, new(big.Int).Rand(rnd, s.q))
+	}
+
+	res := values[:0]
+	for _, c := range values {
+		if c.Mod(c, s.q).Cmp(v) != 0 {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// sqrtHint returns a square root of its input
+func sqrtHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if outputs[0].ModSqrt(inputs[0], curveID.Info().Fr.Modulus()) == nil {
+		outputs[0].SetUint64(0)
+	}
+	return nil
+}
+
+type sqrtCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *sqrtCircuit) Define(api frontend.API) error {
+	r, err := api.Compiler().NewHint(sqrtHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	// the sign of the square root is free
+	api.AssertIsEqual(api.Mul(r[0], r[0]), circuit.X)
+	api.AssertIsEqual(api.Add(api.Div(circuit.X, circuit.Y), api.IsZero(circuit.Y)), circuit.Z)
+	return nil
+}
+
+// divHint returns the quotient and the remainder of the euclidean division of
+// its inputs
+func divHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].DivMod(inputs[0], inputs[1], outputs[1])
+	return nil
+}
+
+// shiftedDivHint returns another decomposition of the first input, with the
+// quotient of divHint minus one
+func shiftedDivHint(curveID ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].DivMod(inputs[0], inputs[1], outputs[1])
+	outputs[0].Sub(outputs[0], big.NewInt(1))
+	outputs[1].Add(outputs[1], inputs[1])
+	return nil
+}
+
+type divCircuit struct {
+	X frontend.Variable `gnark:",public"`
+}
+
+func (circuit *divCircuit) Define(api frontend.API) error {
+	qr, err := api.Compiler().NewHint(divHint, 2, circuit.X, 7)
+	if err != nil {
+		return err
+	}
+	// the remainder isn't range checked, the decomposition is free
+	api.AssertIsEqual(api.Add(api.Mul(qr[0], 7), qr[1]), circuit.X)
+	return nil
+}
+
+type soundCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *soundCircuit) Define(api frontend.API) error {
+	bits := api.ToBinary(circuit.X, 8)
+	x := api.FromBinary(bits[:4]...)
+	s := api.Select(bits[7], circuit.Y, api.Mul(circuit.Y, circuit.Y))
+	api.AssertIsEqual(api.Add(api.Div(x, circuit.Y), api.IsZero(circuit.Y), api.Cmp(circuit.X, circuit.Y), s), circuit.Z)
+	return nil
+}
+
+func TestUnderconstrained(t *testing.T) {
+	assert := NewAssert(t)
+
+	assert.Underconstrained(&soundCircuit{}, &soundCircuit{X: 0b10011001, Y: 3, Z: 7}, WithCurves(ecc.BN254))
+
+	proverOpts := []backend.ProverOption{backend.WithHints(sqrtHint)}
+	for _, b := range backend.Implemented() {
+		ccs, err := assert.compile(&sqrtCircuit{}, ecc.BN254, b, nil)
+		assert.NoError(err)
+		w, err := frontend.NewWitness(&sqrtCircuit{X: 4, Y: 2, Z: 2}, ecc.BN254)
+		assert.NoError(err)
+		mutations, err := mutate(ccs, w, nil, proverOpts...)
+		assert.NoError(err)
+
+		// the hint output is the first internal wire, its opposite is the other
+		// square root
+		assert.Len(mutations, 1, b.String())
+		_, nbSecret, nbPublic := ccs.GetNbVariables()
+		assert.Equal(nbPublic+nbSecret, mutations[0].wireID)
+		var sum big.Int
+		sum.Add(&mutations[0].value, &mutations[0].mutated).Mod(&sum, ecc.BN254.Info().Fr.Modulus())
+		assert.Equal(0, sum.Sign())
+	}
+}
+
+func TestUnderconstrainedAlternativeHint(t *testing.T) {
+	assert := NewAssert(t)
+
+	proverOpts := []backend.ProverOption{backend.WithHints(divHint)}
+	alternatives := map[hint.ID][]hint.Function{hint.UUID(divHint): {divHint, shiftedDivHint}}
+	for _, b := range backend.Implemented() {
+		ccs, err := assert.compile(&divCircuit{}, ecc.BN254, b, nil)
+		assert.NoError(err)
+		w, err := frontend.NewWitness(&divCircuit{X: 100}, ecc.BN254)
+		assert.NoError(err)
+
+		// a single output can't be perturbed
+		mutations, err := mutate(ccs, w, nil, proverOpts...)
+		assert.NoError(err)
+		assert.Empty(mutations, b.String())
+
+		// the other decomposition is found, and divHint itself isn't reported
+		mutations, err = mutate(ccs, w, alternatives, proverOpts...)
+		assert.NoError(err)
+		assert.Len(mutations, 2, b.String())
+		assert.Equal("14", mutations[0].value.String())
+		assert.Equal("13", mutations[0].mutated.String())
+		assert.Equal("2", mutations[1].value.String())
+		assert.Equal("9", mutations[1].mutated.String())
+	}
+}
@@ -19,6 +19,7 @@ package test
 import (
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -33,6 +34,7 @@ type testingConfig struct {
 	witnessSerialization bool
 	proverOpts           []backend.ProverOption
 	compileOpts          []frontend.CompileOption
+	alternativeHints     map[hint.ID][]hint.Function
 }
 
 // WithBackends is testing option which restricts the backends the assertions are
@@ -82,3 +84,21 @@ func WithCompileOpts(compileOpts ...frontend.CompileOption) TestingOption {
 		return nil
 	}
 }
+
+// WithAlternativeHint is a testing option which gives Assert.Underconstrained
+// an alternative to the hint function original, such as one returning the
+// other root or another decomposition of its inputs. The circuit is solved
+// again with alternative in place of original; if the solution is valid and
+// the outputs of original differ, they are reported as underconstrained.
+//
+// Several alternatives can be given for the same hint function.
+func WithAlternativeHint(original, alternative hint.Function) TestingOption {
+	return func(opt *testingConfig) error {
+		if opt.alternativeHints == nil {
+			opt.alternativeHints = make(map[hint.ID][]hint.Function)
+		}
+		id := hint.UUID(original)
+		opt.alternativeHints[id] = append(opt.alternativeHints[id], alternative)
+		return nil
+	}
+}